@@ -1,6 +1,7 @@
 package circuitbreaker
 
 import (
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -22,6 +23,7 @@ type CircuitBreaker struct {
 	halfOpenRate     float64
 	retryTimePeriod  time.Duration
 	lastFailTime     time.Time
+	mu               sync.Mutex // 保护lastFailTime
 }
 
 func NewCircuitBreaker(failureThreshold int, halfOpenRate float64, retryTimePeriod time.Duration) *CircuitBreaker {
@@ -38,7 +40,7 @@ func (cb *CircuitBreaker) AllowRequest() bool {
 	now := time.Now()
 	switch State(atomic.LoadInt32((*int32)(&cb.state))) {
 	case Open:
-		if now.Sub(cb.lastFailTime) >= cb.retryTimePeriod {
+		if now.Sub(cb.getLastFailTime()) >= cb.retryTimePeriod {
 			atomic.StoreInt32((*int32)(&cb.state), int32(HalfOpen))
 			return true
 		}
@@ -71,10 +73,10 @@ func (cb *CircuitBreaker) RecordSuccess() {
 // 记录失败
 func (cb *CircuitBreaker) RecordFail() {
 	atomic.AddInt32(&cb.failCount, 1)
-	cb.lastFailTime = time.Now()
+	cb.setLastFailTime(time.Now())
 	if State(atomic.LoadInt32((*int32)(&cb.state))) == HalfOpen {
 		atomic.StoreInt32((*int32)(&cb.state), int32(Open))
-		cb.lastFailTime = time.Now()
+		cb.setLastFailTime(time.Now())
 	} else if atomic.LoadInt32(&cb.failCount) >= cb.failureThreshold {
 		atomic.StoreInt32((*int32)(&cb.state), int32(Open))
 	}
@@ -89,3 +91,21 @@ func (cb *CircuitBreaker) resetCount() {
 func (cb *CircuitBreaker) State() State {
 	return State(atomic.LoadInt32((*int32)(&cb.state)))
 }
+
+// IsOpen 检测是否处于打开状态且未到重试时间，不会转换为半开状态
+func (cb *CircuitBreaker) IsOpen() bool {
+	return cb.State() == Open && time.Since(cb.getLastFailTime()) < cb.retryTimePeriod
+}
+
+func (cb *CircuitBreaker) getLastFailTime() time.Time {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return cb.lastFailTime
+}
+
+func (cb *CircuitBreaker) setLastFailTime(t time.Time) {
+	cb.mu.Lock()
+	cb.lastFailTime = t
+	cb.mu.Unlock()
+}
@@ -0,0 +1,103 @@
+package circuitbreaker
+
+import (
+	"sync"
+	"time"
+)
+
+// Group 熔断器组，按键（通常为实例ID）隔离熔断器
+type Group struct {
+	rw               sync.RWMutex
+	breakers         map[string]*CircuitBreaker
+	failureThreshold int
+	halfOpenRate     float64
+	retryTimePeriod  time.Duration
+}
+
+func NewGroup(failureThreshold int, halfOpenRate float64, retryTimePeriod time.Duration) *Group {
+	return &Group{
+		breakers:         make(map[string]*CircuitBreaker),
+		failureThreshold: failureThreshold,
+		halfOpenRate:     halfOpenRate,
+		retryTimePeriod:  retryTimePeriod,
+	}
+}
+
+// Get 获取熔断器，不存在时创建
+func (g *Group) Get(key string) *CircuitBreaker {
+	g.rw.RLock()
+	cb, ok := g.breakers[key]
+	g.rw.RUnlock()
+
+	if ok {
+		return cb
+	}
+
+	g.rw.Lock()
+	defer g.rw.Unlock()
+
+	if cb, ok = g.breakers[key]; !ok {
+		cb = NewCircuitBreaker(g.failureThreshold, g.halfOpenRate, g.retryTimePeriod)
+		g.breakers[key] = cb
+	}
+
+	return cb
+}
+
+// IsOpen 检测熔断器是否处于打开状态，打开状态下不会转换为半开状态
+func (g *Group) IsOpen(key string) bool {
+	g.rw.RLock()
+	cb, ok := g.breakers[key]
+	g.rw.RUnlock()
+
+	if !ok {
+		return false
+	}
+
+	return cb.IsOpen()
+}
+
+// AllowRequest 是否允许请求
+func (g *Group) AllowRequest(key string) bool {
+	return g.Get(key).AllowRequest()
+}
+
+// RecordSuccess 记录成功
+func (g *Group) RecordSuccess(key string) {
+	g.Get(key).RecordSuccess()
+}
+
+// RecordFail 记录失败
+func (g *Group) RecordFail(key string) {
+	g.Get(key).RecordFail()
+}
+
+// Retain 仅保留给定键的熔断器，用于清理已下线实例的熔断器
+func (g *Group) Retain(keys ...string) {
+	mp := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		mp[key] = struct{}{}
+	}
+
+	g.rw.Lock()
+	defer g.rw.Unlock()
+
+	for key := range g.breakers {
+		if _, ok := mp[key]; !ok {
+			delete(g.breakers, key)
+		}
+	}
+}
+
+// States 获取所有熔断器状态
+func (g *Group) States() map[string]State {
+	g.rw.RLock()
+	defer g.rw.RUnlock()
+
+	states := make(map[string]State, len(g.breakers))
+	for key, cb := range g.breakers {
+		states[key] = cb.State()
+	}
+
+	return states
+}
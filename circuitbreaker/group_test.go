@@ -0,0 +1,80 @@
+package circuitbreaker
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGroup_Isolation(t *testing.T) {
+	g := NewGroup(3, 0.5, 100*time.Millisecond)
+
+	// 实例a连续失败3次后熔断
+	for i := 0; i < 3; i++ {
+		if !g.AllowRequest("a") {
+			t.Fatalf("expected allow request for a")
+		}
+		g.RecordFail("a")
+	}
+
+	if !g.IsOpen("a") {
+		t.Fatalf("expected breaker a open, got %v", g.Get("a").State())
+	}
+
+	if g.AllowRequest("a") {
+		t.Fatalf("expected deny request for a")
+	}
+
+	// 实例b不受影响
+	if g.IsOpen("b") || !g.AllowRequest("b") {
+		t.Fatalf("expected breaker b closed")
+	}
+
+	// 超过重试周期后不再视为打开
+	time.Sleep(110 * time.Millisecond)
+	if g.IsOpen("a") {
+		t.Fatalf("expected breaker a not open after retry period")
+	}
+}
+
+func TestGroup_Retain(t *testing.T) {
+	g := NewGroup(3, 0.5, time.Second)
+	g.Get("a")
+	g.Get("b")
+	g.Get("c")
+
+	g.Retain("a", "c")
+
+	states := g.States()
+	if len(states) != 2 {
+		t.Fatalf("expected 2 breakers, got %d", len(states))
+	}
+
+	if _, ok := states["b"]; ok {
+		t.Fatalf("expected breaker b removed")
+	}
+}
+
+func TestGroup_ConcurrentIsOpen(t *testing.T) {
+	g := NewGroup(1, 0.5, time.Millisecond)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			g.RecordFail("a")
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			g.IsOpen("a")
+			g.AllowRequest("a")
+		}
+	}()
+
+	wg.Wait()
+}
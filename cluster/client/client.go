@@ -6,6 +6,7 @@ import (
 	"gatesvr/cluster"
 	"gatesvr/component"
 	"gatesvr/core/info"
+	"gatesvr/crypto/ecdh"
 	"gatesvr/errors"
 	"gatesvr/log"
 	"gatesvr/network"
@@ -50,6 +51,10 @@ func NewClient(opts ...Option) *Client {
 	c.events = make(map[cluster.Event][]EventHandler)
 	c.hooks = make(map[cluster.Hook][]HookHandler)
 	c.ctx, c.cancel = context.WithCancel(o.ctx)
+
+	if c.handshakeEnabled() && o.encryptor != nil {
+		log.Warnf("handshake is enabled, the %s encryptor will be ignored", o.encryptor.Name())
+	}
 	c.state = int32(cluster.Shut)
 
 	return c
@@ -106,6 +111,21 @@ func (c *Client) handleDisconnect(conn network.Conn) {
 
 	c.conns.Delete(conn)
 
+	cc := val.(*Conn)
+	cc.cancelPending(errors.ErrConnectionClosed)
+
+	switch prev, next := cc.hang(); {
+	case prev == connReconnecting:
+		// 重连期间建立的新连接断开，由重连协程处理
+	case next == connReconnecting:
+		xcall.Go(func() { c.reconnect(cc) })
+	default:
+		c.disconnected(cc)
+	}
+}
+
+// 触发断开连接事件
+func (c *Client) disconnected(cc *Conn) {
 	handlers, ok := c.events[cluster.Disconnect]
 	if !ok {
 		return
@@ -113,7 +133,7 @@ func (c *Client) handleDisconnect(conn network.Conn) {
 
 	for _, handler := range handlers {
 		xcall.Call(func() {
-			handler(val.(*Conn))
+			handler(cc)
 		})
 	}
 }
@@ -134,6 +154,32 @@ func (c *Client) handleReceive(conn network.Conn, data []byte) {
 		return
 	}
 
+	if c.handshakeEnabled() {
+		if message.Route == c.opts.handshakeRoute && val.(*Conn).shake != nil {
+			c.finishHandshake(val.(*Conn), message.Buffer)
+			return
+		}
+
+		cipher := conn.Cipher()
+		if cipher == nil {
+			log.Warnf("receive message before handshake, route: %v", message.Route)
+			return
+		}
+
+		if message.Buffer, err = cipher.Open(message.Seq, message.Buffer); err != nil {
+			log.Warnf("open message failed, seq: %v route: %v err: %v", message.Seq, message.Route, err)
+			return
+		}
+	}
+
+	if val.(*Conn).resolve(message) {
+		return
+	}
+
+	if message.Route == c.opts.resumeRoute && c.opts.resumeRoute >= 0 {
+		c.saveToken(val.(*Conn), message)
+	}
+
 	handlers, ok := c.routes[message.Route]
 	if ok {
 		for _, handler := range handlers {
@@ -156,6 +202,17 @@ func (c *Client) handleReceive(conn network.Conn, data []byte) {
 	}
 }
 
+// 保存网关下发的会话恢复令牌，断线重连后出示
+func (c *Client) saveToken(cc *Conn, message *packet.Message) {
+	token, err := (&Context{conn: cc, message: message}).decode()
+	if err != nil {
+		log.Warnf("decode resume token failed: %v", err)
+		return
+	}
+
+	cc.setToken(token)
+}
+
 // 拨号
 func (c *Client) dial(opts ...DialOption) (*Conn, error) {
 	if c.getState() == cluster.Shut {
@@ -172,14 +229,36 @@ func (c *Client) dial(opts ...DialOption) (*Conn, error) {
 		return nil, err
 	}
 
-	cc := &Conn{conn: conn, client: c}
+	cc := &Conn{conn: conn, client: c, addr: o.addr, reconnect: c.opts.reconnect}
+
+	if o.reconnect != nil {
+		cc.reconnect = o.reconnect
+	}
 
 	for key, value := range o.attrs {
 		cc.SetAttr(key, value)
 	}
 
+	if c.handshakeEnabled() {
+		key, err := ecdh.GenerateKey()
+		if err != nil {
+			_ = conn.Close(true)
+			return nil, err
+		}
+
+		cc.shake = &handshake{key: key, done: make(chan error, 1)}
+	}
+
 	c.conns.Store(conn, cc)
 
+	if cc.shake != nil {
+		if err = c.handshake(cc); err != nil {
+			c.conns.Delete(conn)
+			_ = conn.Close(true)
+			return nil, err
+		}
+	}
+
 	if handlers, ok := c.events[cluster.Connect]; ok {
 		for _, handler := range handlers {
 			xcall.Call(func() {
@@ -1,38 +1,56 @@
 package client
 
 import (
+	"context"
 	"gatesvr/cluster"
 	"gatesvr/core/value"
+	"gatesvr/errors"
 	"gatesvr/network"
 	"gatesvr/packet"
 	"net"
 	"sync"
+	"sync/atomic"
+)
+
+const (
+	connOpened       int32 = iota // 连接打开
+	connReconnecting              // 断线重连中
+	connClosed                    // 连接关闭
 )
 
 type Conn struct {
-	conn   network.Conn
-	client *Client
-	attrs  sync.Map
+	rw        sync.RWMutex
+	conn      network.Conn
+	client    *Client
+	attrs     sync.Map
+	shake     *handshake
+	seq       int32            // 请求序列号
+	pending   sync.Map         // 等待响应的请求
+	state     int32            // 连接状态
+	addr      string           // 拨号地址
+	reconnect *ReconnectPolicy // 断线重连策略，为nil时不重连
+	buffer    [][]byte         // 重连期间缓存的消息
+	token     []byte           // 会话恢复令牌
 }
 
-// ID 获取连接ID
+// ID 获取连接ID，重连后为新连接的ID
 func (c *Conn) ID() int64 {
-	return c.conn.ID()
+	return c.getConn().ID()
 }
 
 // UID 获取用户ID
 func (c *Conn) UID() int64 {
-	return c.conn.UID()
+	return c.getConn().UID()
 }
 
 // Bind 绑定用户ID
 func (c *Conn) Bind(uid int64) {
-	c.conn.Bind(uid)
+	c.getConn().Bind(uid)
 }
 
 // Unbind 解绑用户ID
 func (c *Conn) Unbind() {
-	c.conn.Unbind()
+	c.getConn().Unbind()
 }
 
 // SetAttr 设置属性值
@@ -56,29 +74,40 @@ func (c *Conn) DelAttr(key any) {
 
 // LocalIP 获取本地IP
 func (c *Conn) LocalIP() (string, error) {
-	return c.conn.LocalIP()
+	return c.getConn().LocalIP()
 }
 
 // LocalAddr 获取本地地址
 func (c *Conn) LocalAddr() (net.Addr, error) {
-	return c.conn.LocalAddr()
+	return c.getConn().LocalAddr()
 }
 
 // RemoteIP 获取远端IP
 func (c *Conn) RemoteIP() (string, error) {
-	return c.conn.RemoteIP()
+	return c.getConn().RemoteIP()
 }
 
 // RemoteAddr 获取远端地址
 func (c *Conn) RemoteAddr() (net.Addr, error) {
-	return c.conn.RemoteAddr()
+	return c.getConn().RemoteAddr()
 }
 
-// Push 推送消息
+// Push 推送消息，重连期间消息将被缓存，待重连成功后发送
 func (c *Conn) Push(message *cluster.Message) error {
+	msg, err := c.pack(message)
+	if err != nil {
+		return err
+	}
+
+	return c.send(msg)
+}
+
+// 序列化、压缩、加密并打包消息
+func (c *Conn) pack(message *cluster.Message) ([]byte, error) {
 	var (
-		err    error
-		buffer []byte
+		err        error
+		buffer     []byte
+		compressed bool
 	)
 
 	if message.Data != nil {
@@ -89,42 +118,181 @@ func (c *Conn) Push(message *cluster.Message) error {
 			//log.Debugf("client推送消息序列化后为: %v,消息长度%d", buffer, len(buffer))
 
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
-		if c.client.opts.compressor != nil {
+		if c.client.opts.compressor != nil && len(buffer) >= c.client.opts.compressThreshold {
 			buffer, err = c.client.opts.compressor.Compress(buffer)
 			//log.Debugf("client推送消息压缩后为: %v,消息长度：%d", buffer, len(buffer))
 			if err != nil {
-				return err
+				return nil, err
 			}
+			compressed = true
 		}
 
 		//加密
-		if c.client.opts.encryptor != nil {
+		if c.client.opts.encryptor != nil && !c.client.handshakeEnabled() {
 			buffer, err = c.client.opts.encryptor.Encrypt(buffer)
 			//log.Debugf("client推送消息加密后为: %v,消息长度：%d", buffer, len(buffer))
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
 	}
 
 	msg, err := packet.PackMessage(&packet.Message{
-		Seq:        message.Seq,
-		Route:      message.Route,
-		IsCritical: message.IsCritical,
-		Buffer:     buffer,
+		Seq:          message.Seq,
+		Route:        message.Route,
+		IsCritical:   message.IsCritical,
+		IsCompressed: compressed,
+		Buffer:       buffer,
 	})
 	//log.Debugf("client推送消息打包后为: %v", msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// 发送消息，重连期间缓存消息，待重连成功后按序发送
+func (c *Conn) send(msg []byte) error {
+	c.rw.RLock()
+	if c.state == connOpened {
+		conn := c.conn
+		c.rw.RUnlock()
+		return c.write(conn, msg)
+	}
+	c.rw.RUnlock()
+
+	c.rw.Lock()
+	defer c.rw.Unlock()
+
+	switch c.state {
+	case connOpened:
+		return c.write(c.conn, msg)
+	case connReconnecting:
+		if len(c.buffer) >= c.reconnect.Buffer {
+			return errors.ErrConnectionHanged
+		}
+
+		c.buffer = append(c.buffer, msg)
+
+		return nil
+	default:
+		return errors.ErrConnectionClosed
+	}
+}
+
+// 写入消息至底层连接
+func (c *Conn) write(conn network.Conn, msg []byte) (err error) {
+	//握手完成后由连接使用会话密钥加密
+	if c.client.handshakeEnabled() {
+		if err = c.client.check(conn); err != nil {
+			return err
+		}
+	}
+
+	return conn.Push(msg)
+}
+
+// 获取底层连接
+func (c *Conn) getConn() network.Conn {
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	return c.conn
+}
+
+// Request 发送请求并等待响应，响应解析到reply中
+// 请求序列号由连接自动分配，需开启消息序列号（packet.seqBytes大于0），服务端返回通知时以错误形式返回
+func (c *Conn) Request(ctx context.Context, message *cluster.Message, reply any) error {
+	future, err := c.Go(message)
 	if err != nil {
 		return err
 	}
 
-	return c.conn.Push(msg)
+	return future.Wait(ctx, reply)
 }
 
-// Close 关闭连接
+// Go 发送请求并返回异步响应
+func (c *Conn) Go(message *cluster.Message) (*Future, error) {
+	msg := *message
+
+	for {
+		seq := c.nextSeq()
+		future := newFuture(c, seq, msg.Route)
+
+		if _, loaded := c.pending.LoadOrStore(seq, future); loaded {
+			continue
+		}
+
+		msg.Seq = seq
+
+		err := c.Push(&msg)
+		if err == nil {
+			return future, nil
+		}
+
+		c.pending.Delete(seq)
+
+		//序列号超出编码范围时从头分配
+		if errors.Is(err, errors.ErrSeqOverflow) && seq > 1 {
+			atomic.CompareAndSwapInt32(&c.seq, seq, 0)
+			continue
+		}
+
+		return nil, err
+	}
+}
+
+// 分配请求序列号，序列号从1开始，0保留给推送消息
+func (c *Conn) nextSeq() int32 {
+	for {
+		if seq := atomic.AddInt32(&c.seq, 1); seq > 0 {
+			return seq
+		} else {
+			atomic.CompareAndSwapInt32(&c.seq, seq, 0)
+		}
+	}
+}
+
+// 完成等待中的请求
+func (c *Conn) resolve(message *packet.Message) bool {
+	if message.Seq <= 0 {
+		return false
+	}
+
+	val, ok := c.pending.LoadAndDelete(message.Seq)
+	if !ok {
+		return false
+	}
+
+	val.(*Future).resolve(message)
+
+	return true
+}
+
+// 取消所有等待中的请求
+func (c *Conn) cancelPending(err error) {
+	c.pending.Range(func(_, val any) bool {
+		val.(*Future).cancel(err)
+		return true
+	})
+}
+
+// Close 关闭连接，主动关闭的连接不会重连
 func (c *Conn) Close(force ...bool) error {
-	return c.conn.Close(force...)
+	c.rw.Lock()
+	state := c.state
+	c.state = connClosed
+	c.buffer = nil
+	conn := c.conn
+	c.rw.Unlock()
+
+	if state == connReconnecting {
+		return nil
+	}
+
+	return conn.Close(force...)
 }
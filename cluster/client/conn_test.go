@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"gatesvr/cluster"
+	"gatesvr/encoding"
+	"gatesvr/errors"
+	"gatesvr/network"
+	"gatesvr/packet"
+	"gatesvr/utils/codes"
+	"testing"
+	"time"
+)
+
+type greeting struct {
+	Text string `json:"text"`
+}
+
+// 模拟网关，收到请求后按回调返回响应
+type mockConn struct {
+	network.Conn
+	client *Client
+	reply  func(msg *packet.Message) *packet.Message
+}
+
+func (c *mockConn) Push(data []byte) error {
+	msg, err := packet.UnpackMessage(data)
+	if err != nil {
+		return err
+	}
+
+	if res := c.reply(msg); res != nil {
+		buffer, err := packet.PackMessage(res)
+		if err != nil {
+			return err
+		}
+
+		go c.client.handleReceive(c, buffer)
+	}
+
+	return nil
+}
+
+func newMockConn(reply func(msg *packet.Message) *packet.Message) (*Client, *Conn) {
+	c := NewClient(WithCodec(encoding.Invoke("json")), WithTimeout(100*time.Millisecond))
+	mc := &mockConn{client: c, reply: reply}
+	cc := &Conn{conn: mc, client: c}
+	c.conns.Store(network.Conn(mc), cc)
+
+	return c, cc
+}
+
+func TestConn_Request(t *testing.T) {
+	codec := encoding.Invoke("json")
+
+	_, conn := newMockConn(func(msg *packet.Message) *packet.Message {
+		req := &greeting{}
+		if err := codec.Unmarshal(msg.Buffer, req); err != nil {
+			t.Fatal(err)
+		}
+
+		buffer, _ := codec.Marshal(&greeting{Text: "hello " + req.Text})
+
+		return &packet.Message{Seq: msg.Seq, Route: msg.Route, Buffer: buffer}
+	})
+
+	for i := 0; i < 3; i++ {
+		reply := &greeting{}
+		if err := conn.Request(context.Background(), &cluster.Message{Route: 1, Data: &greeting{Text: "bot"}}, reply); err != nil {
+			t.Fatal(err)
+		}
+
+		if reply.Text != "hello bot" {
+			t.Fatalf("unexpected reply: %s", reply.Text)
+		}
+	}
+}
+
+func TestConn_RequestNotification(t *testing.T) {
+	codec := encoding.Invoke("json")
+
+	_, conn := newMockConn(func(msg *packet.Message) *packet.Message {
+		buffer, _ := codec.Marshal(&packet.Notification{Code: codes.NotFound.Code(), Message: "not found route"})
+
+		return &packet.Message{Seq: msg.Seq, Route: 0, Buffer: buffer}
+	})
+
+	err := conn.Request(context.Background(), &cluster.Message{Route: 1, Data: &greeting{}}, nil)
+	if err == nil {
+		t.Fatal("expect notification error")
+	}
+
+	if code := errors.Code(err); code == nil || code.Code() != codes.NotFound.Code() {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestConn_RequestTimeout(t *testing.T) {
+	_, conn := newMockConn(func(msg *packet.Message) *packet.Message { return nil })
+
+	if err := conn.Request(context.Background(), &cluster.Message{Route: 1}, nil); !errors.Is(err, errors.ErrDeadlineExceeded) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	conn.pending.Range(func(key, _ any) bool {
+		t.Fatalf("pending request is not removed, seq: %v", key)
+		return false
+	})
+}
+
+func TestConn_RequestDisconnect(t *testing.T) {
+	c, conn := newMockConn(func(msg *packet.Message) *packet.Message { return nil })
+
+	future, err := conn.Go(&cluster.Message{Route: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.handleDisconnect(conn.conn)
+
+	if err = future.Wait(context.Background(), nil); !errors.Is(err, errors.ErrConnectionClosed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"gatesvr/errors"
 	"gatesvr/packet"
 )
 
@@ -47,32 +48,38 @@ func (c *Context) Data() interface{} {
 }
 
 // Parse 解析消息
-func (c *Context) Parse(v interface{}) (err error) {
-	buffer := c.message.Buffer
+func (c *Context) Parse(v interface{}) error {
+	buffer, err := c.decode()
+	if err != nil {
+		return err
+	}
+
+	return c.conn.client.opts.codec.Unmarshal(buffer, v)
+}
+
+// 解密并解压消息
+func (c *Context) decode() (buffer []byte, err error) {
+	buffer = c.message.Buffer
 
-	if c.conn.client.opts.encryptor != nil {
+	if c.conn.client.opts.encryptor != nil && !c.conn.client.handshakeEnabled() {
 		buffer, err = c.conn.client.opts.encryptor.Decrypt(buffer)
 		//log.Debugf("client收到响应解密后的消息为：%v", buffer)
 		if err != nil {
 			return
 		}
 	}
-	if c.conn.client.opts.compressor != nil {
+
+	//解压缩
+	if c.message.IsCompressed {
+		if c.conn.client.opts.compressor == nil {
+			return nil, errors.ErrMissingCompressor
+		}
+
 		buffer, err = c.conn.client.opts.compressor.Decompress(buffer)
-		//log.Debugf("client收到响应解压缩后的消息为：%v", buffer)
 		if err != nil {
 			return
 		}
 	}
 
-	////解压缩
-	//compressor := &lz4.LZ4Compressor{}
-	//decompressedBuffer, err := compressor.Decompress(buffer)
-	//if err != nil {
-	//	return
-	//}
-	//log.Debugf("client收到响应解压缩后的消息为：%v", decompressedBuffer)
-	//buffer = decompressedBuffer
-
-	return c.conn.client.opts.codec.Unmarshal(buffer, v)
+	return
 }
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"gatesvr/errors"
+	"gatesvr/packet"
+	"gatesvr/utils/codes"
+	"sync"
+)
+
+// Future 请求的异步响应
+type Future struct {
+	conn  *Conn           // 连接
+	seq   int32           // 请求序列号
+	route int32           // 请求路由
+	once  sync.Once       // 保证只完成一次
+	done  chan struct{}   // 完成信号
+	reply *packet.Message // 响应消息
+	err   error           // 请求错误
+}
+
+func newFuture(conn *Conn, seq, route int32) *Future {
+	return &Future{conn: conn, seq: seq, route: route, done: make(chan struct{})}
+}
+
+// Seq 获取请求序列号
+func (f *Future) Seq() int32 {
+	return f.seq
+}
+
+// Done 响应到达、连接断开或请求取消时关闭
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait 等待响应并解析到reply中，reply为nil时不解析
+// ctx未设置截止时间时使用客户端的超时时间；服务端返回通知时以错误形式返回，可通过errors.Code获取错误码
+func (f *Future) Wait(ctx context.Context, reply any) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.conn.client.opts.timeout)
+		defer cancel()
+	}
+
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			f.cancel(errors.ErrDeadlineExceeded)
+		} else {
+			f.cancel(ctx.Err())
+		}
+	}
+
+	if f.err != nil {
+		return f.err
+	}
+
+	res := &Context{ctx: ctx, conn: f.conn, message: f.reply}
+
+	if f.reply.Route != f.route {
+		notification := &packet.Notification{}
+		if err := res.Parse(notification); err != nil {
+			return err
+		}
+
+		return errors.NewError(notification.Message, codes.NewCode(notification.Code, notification.Message))
+	}
+
+	if reply == nil {
+		return nil
+	}
+
+	return res.Parse(reply)
+}
+
+// 完成请求
+func (f *Future) resolve(reply *packet.Message) {
+	f.once.Do(func() {
+		f.reply = reply
+		close(f.done)
+	})
+}
+
+// 取消请求
+func (f *Future) cancel(err error) {
+	f.once.Do(func() {
+		f.conn.pending.CompareAndDelete(f.seq, f)
+		f.err = err
+		close(f.done)
+	})
+}
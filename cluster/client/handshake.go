@@ -0,0 +1,88 @@
+package client
+
+import (
+	"gatesvr/crypto/ecdh"
+	"gatesvr/errors"
+	"gatesvr/network"
+	"gatesvr/packet"
+	"time"
+)
+
+// 握手状态
+type handshake struct {
+	key  *ecdh.KeyPair // 临时密钥对
+	done chan error    // 握手结果
+}
+
+// 是否启用握手
+func (c *Client) handshakeEnabled() bool {
+	return c.opts.handshakeRoute >= 0
+}
+
+// 与网关握手，协商连接独立的会话密钥
+func (c *Client) handshake(cc *Conn) error {
+	msg, err := packet.PackMessage(&packet.Message{
+		Route:  c.opts.handshakeRoute,
+		Buffer: cc.shake.key.PublicKey(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = cc.conn.Push(msg); err != nil {
+		return err
+	}
+
+	select {
+	case err = <-cc.shake.done:
+		return err
+	case <-time.After(c.opts.timeout):
+		return errors.ErrHandshakeFailed
+	}
+}
+
+// 处理握手响应，在读协程中设置会话密钥，保证后续消息均可解密
+func (c *Client) finishHandshake(cc *Conn, buffer []byte) {
+	select {
+	case cc.shake.done <- c.negotiate(cc, buffer):
+	default:
+	}
+}
+
+// 校验网关公钥并协商会话密钥
+func (c *Client) negotiate(cc *Conn, buffer []byte) error {
+	if cc.conn.Cipher() != nil || len(buffer) < ecdh.PublicKeySize {
+		return errors.ErrHandshakeFailed
+	}
+
+	peer, signature := buffer[:ecdh.PublicKeySize], buffer[ecdh.PublicKeySize:]
+
+	if c.opts.handshakeSigner != nil {
+		ok, err := c.opts.handshakeSigner.Verify(append(cc.shake.key.PublicKey(), peer...), signature)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return errors.ErrHandshakeFailed
+		}
+	}
+
+	cipher, err := cc.shake.key.Negotiate(ecdh.Client, peer, ecdh.WithRotation(c.opts.handshakeRotation))
+	if err != nil {
+		return err
+	}
+
+	cc.conn.SetCipher(cipher)
+
+	return nil
+}
+
+// 检查连接握手是否已完成，消息由连接以会话密钥加密
+func (c *Client) check(conn network.Conn) error {
+	if conn.Cipher() == nil {
+		return errors.ErrHandshakeNotCompleted
+	}
+
+	return nil
+}
@@ -12,30 +12,54 @@ import (
 )
 
 const (
-	defaultName    = "client"        // 默认客户端名称
-	defaultCodec   = "proto"         // 默认编解码器名称
-	defaultTimeout = 3 * time.Second // 默认超时时间
+	defaultName              = "client"        // 默认客户端名称
+	defaultCodec             = "proto"         // 默认编解码器名称
+	defaultTimeout           = 3 * time.Second // 默认超时时间
+	defaultHandshakeRoute    = -1              // 默认握手路由
+	defaultHandshakeRotation = 1 << 16         // 默认会话密钥轮换间隔（消息数）
+	defaultCompressThreshold = 0               // 默认压缩阈值，0为全部压缩
+	defaultResumeRoute       = -1              // 默认会话恢复路由
+	defaultReconnectAttempts = 0               // 默认最大重连次数，0为不限制
+	defaultReconnectMin      = "1s"            // 默认重连初始退避时间
+	defaultReconnectMax      = "30s"           // 默认重连最大退避时间
+	defaultReconnectBuffer   = 1024            // 默认重连期间缓存的消息数上限
 )
 
 const (
-	defaultIDKey       = "etc.cluster.client.id"
-	defaultNameKey     = "etc.cluster.client.name"
-	defaultCodecKey    = "etc.cluster.client.codec"
-	defaultTimeoutKey  = "etc.cluster.client.timeout"
-	defaultAutoDialKey = "etc.cluster.client.autoDial"
+	defaultIDKey                = "etc.cluster.client.id"
+	defaultNameKey              = "etc.cluster.client.name"
+	defaultCodecKey             = "etc.cluster.client.codec"
+	defaultTimeoutKey           = "etc.cluster.client.timeout"
+	defaultAutoDialKey          = "etc.cluster.client.autoDial"
+	defaultHandshakeRouteKey    = "etc.cluster.client.handshake.route"
+	defaultHandshakeRotationKey = "etc.cluster.client.handshake.rotation"
+	defaultCompressorKey        = "etc.cluster.client.compressor"
+	defaultCompressThresholdKey = "etc.cluster.client.compressThreshold"
+	defaultResumeRouteKey       = "etc.cluster.client.resume.route"
+	defaultReconnectEnableKey   = "etc.cluster.client.reconnect.enable"
+	defaultReconnectAttemptsKey = "etc.cluster.client.reconnect.attempts"
+	defaultReconnectMinKey      = "etc.cluster.client.reconnect.minBackoff"
+	defaultReconnectMaxKey      = "etc.cluster.client.reconnect.maxBackoff"
+	defaultReconnectBufferKey   = "etc.cluster.client.reconnect.buffer"
 )
 
 type Option func(o *options)
 
 type options struct {
-	id         string              // 实例ID
-	name       string              // 实例名称
-	ctx        context.Context     // 上下文
-	codec      encoding.Codec      // 编解码器
-	client     network.Client      // 网络客户端
-	timeout    time.Duration       // RPC调用超时时间
-	encryptor  crypto.Encryptor    // 消息加密器
-	compressor compress.Compressor // 消息压缩器
+	id                string              // 实例ID
+	name              string              // 实例名称
+	ctx               context.Context     // 上下文
+	codec             encoding.Codec      // 编解码器
+	client            network.Client      // 网络客户端
+	timeout           time.Duration       // RPC调用超时时间
+	encryptor         crypto.Encryptor    // 消息加密器
+	compressor        compress.Compressor // 消息压缩器
+	handshakeRoute    int32               // 握手路由，小于0为不启用
+	handshakeRotation uint64              // 会话密钥轮换间隔（消息数）
+	handshakeSigner   crypto.Signer       // 握手签名器，用于校验网关公钥
+	compressThreshold int                 // 压缩阈值，消息长度小于该值时不压缩
+	resumeRoute       int32               // 会话恢复路由，小于0为不启用
+	reconnect         *ReconnectPolicy    // 断线重连策略，为nil时不重连
 }
 
 func defaultOptions() *options {
@@ -64,6 +88,24 @@ func defaultOptions() *options {
 		opts.timeout = time.Duration(timeout) * time.Second
 	}
 
+	if name := etc.Get(defaultCompressorKey).String(); name != "" {
+		opts.compressor = compress.InvokeCompressor(name)
+	}
+
+	opts.handshakeRoute = etc.Get(defaultHandshakeRouteKey, defaultHandshakeRoute).Int32()
+	opts.handshakeRotation = etc.Get(defaultHandshakeRotationKey, defaultHandshakeRotation).Uint64()
+	opts.compressThreshold = etc.Get(defaultCompressThresholdKey, defaultCompressThreshold).Int()
+	opts.resumeRoute = etc.Get(defaultResumeRouteKey, defaultResumeRoute).Int32()
+
+	if etc.Get(defaultReconnectEnableKey).Bool() {
+		opts.reconnect = &ReconnectPolicy{
+			MaxAttempts: etc.Get(defaultReconnectAttemptsKey, defaultReconnectAttempts).Int(),
+			MinBackoff:  etc.Get(defaultReconnectMinKey, defaultReconnectMin).Duration(),
+			MaxBackoff:  etc.Get(defaultReconnectMaxKey, defaultReconnectMax).Duration(),
+			Buffer:      etc.Get(defaultReconnectBufferKey, defaultReconnectBuffer).Int(),
+		}
+	}
+
 	return opts
 }
 
@@ -101,15 +143,48 @@ func WithTimeout(timeout time.Duration) Option {
 func WithEncryptor(encryptor crypto.Encryptor) Option {
 	return func(o *options) { o.encryptor = encryptor }
 }
+
+// WithCompressor 设置消息压缩器
 func WithCompressor(compressor compress.Compressor) Option {
 	return func(o *options) { o.compressor = compressor }
 }
 
+// WithCompressThreshold 设置压缩阈值，消息长度小于该值时不压缩
+func WithCompressThreshold(threshold int) Option {
+	return func(o *options) { o.compressThreshold = threshold }
+}
+
+// WithHandshakeRoute 设置握手路由，启用后拨号时与网关协商连接独立的会话密钥，静态加密器不再生效
+func WithHandshakeRoute(route int32) Option {
+	return func(o *options) { o.handshakeRoute = route }
+}
+
+// WithHandshakeRotation 设置会话密钥轮换间隔，每个密钥加密的消息数达到该值后轮换密钥
+func WithHandshakeRotation(rotation uint64) Option {
+	return func(o *options) { o.handshakeRotation = rotation }
+}
+
+// WithHandshakeSigner 设置握手签名器，用于校验网关握手响应的签名
+func WithHandshakeSigner(signer crypto.Signer) Option {
+	return func(o *options) { o.handshakeSigner = signer }
+}
+
+// WithResumeRoute 设置会话恢复路由，需与网关一致；重连后自动出示网关下发的恢复令牌
+func WithResumeRoute(route int32) Option {
+	return func(o *options) { o.resumeRoute = route }
+}
+
+// WithReconnect 设置断线重连策略，为nil时不重连
+func WithReconnect(policy *ReconnectPolicy) Option {
+	return func(o *options) { o.reconnect = policy }
+}
+
 type DialOption func(o *dialOptions)
 
 type dialOptions struct {
-	addr  string
-	attrs map[string]any
+	addr      string
+	attrs     map[string]any
+	reconnect *ReconnectPolicy
 }
 
 // WithDialAddr 设置拨号地址
@@ -117,6 +192,11 @@ func WithDialAddr(addr string) DialOption {
 	return func(o *dialOptions) { o.addr = addr }
 }
 
+// WithDialReconnect 设置连接的断线重连策略，覆盖客户端的重连策略
+func WithDialReconnect(policy *ReconnectPolicy) DialOption {
+	return func(o *dialOptions) { o.reconnect = policy }
+}
+
 // WithConnAttr 设置连接属性
 func WithConnAttr(key string, value any) DialOption {
 	return func(o *dialOptions) { o.attrs[key] = value }
@@ -0,0 +1,193 @@
+package client
+
+import (
+	"gatesvr/cluster"
+	"gatesvr/crypto/ecdh"
+	"gatesvr/errors"
+	"gatesvr/log"
+	"gatesvr/network"
+	"gatesvr/utils/xcall"
+	"gatesvr/utils/xrand"
+	"time"
+)
+
+// ReconnectPolicy 断线重连策略
+type ReconnectPolicy struct {
+	MaxAttempts int           // 最大重连次数，小于等于0时不限制
+	MinBackoff  time.Duration // 初始退避时间，每次重连失败后翻倍
+	MaxBackoff  time.Duration // 最大退避时间
+	Buffer      int           // 重连期间缓存的消息数上限，超出后发送失败
+}
+
+// 计算第attempt次重连前的退避时间，在指数退避的基础上叠加随机抖动，避免大量连接同时重连
+func (p *ReconnectPolicy) backoff(attempt int) time.Duration {
+	d := p.MinBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+
+	if d <= 1 {
+		return d
+	}
+
+	half := int64(d / 2)
+
+	return time.Duration(half + xrand.Int64(0, half))
+}
+
+// 底层连接断开后切换连接状态，启用重连时进入重连状态，否则关闭连接；返回切换前后的状态
+func (c *Conn) hang() (prev, next int32) {
+	c.rw.Lock()
+	defer c.rw.Unlock()
+
+	prev = c.state
+
+	if prev == connOpened {
+		if c.reconnect != nil && c.client.getState() != cluster.Shut {
+			c.state = connReconnecting
+		} else {
+			c.state = connClosed
+		}
+	}
+
+	return prev, c.state
+}
+
+// 保存会话恢复令牌
+func (c *Conn) setToken(token []byte) {
+	c.rw.Lock()
+	c.token = append(c.token[:0], token...)
+	c.rw.Unlock()
+}
+
+// 断线重连，按退避策略重复拨号直至成功或达到最大重连次数
+func (c *Client) reconnect(cc *Conn) {
+	policy := cc.reconnect
+
+	for attempt := 1; policy.MaxAttempts <= 0 || attempt <= policy.MaxAttempts; attempt++ {
+		select {
+		case <-c.ctx.Done():
+			c.abandon(cc)
+			return
+		case <-time.After(policy.backoff(attempt)):
+		}
+
+		if cc.isClosed() || c.getState() == cluster.Shut {
+			c.abandon(cc)
+			return
+		}
+
+		conn, err := c.opts.client.Dial(cc.addr)
+		if err != nil {
+			log.Warnf("reconnect failed, addr: %s attempt: %d err: %v", cc.addr, attempt, err)
+			continue
+		}
+
+		if err = c.restore(cc, conn); err != nil {
+			c.conns.Delete(conn)
+			_ = conn.Close(true)
+			log.Warnf("restore connection failed, addr: %s attempt: %d err: %v", cc.addr, attempt, err)
+			continue
+		}
+
+		if handlers, ok := c.events[cluster.Reconnect]; ok {
+			for _, handler := range handlers {
+				xcall.Call(func() {
+					handler(cc)
+				})
+			}
+		}
+
+		return
+	}
+
+	log.Warnf("reconnect abandoned, addr: %s attempts: %d", cc.addr, policy.MaxAttempts)
+
+	c.abandon(cc)
+}
+
+// 使用新连接恢复会话：重新握手、出示恢复令牌并发送重连期间缓存的消息
+func (c *Client) restore(cc *Conn, conn network.Conn) error {
+	cc.rw.Lock()
+	prev := cc.conn
+	cc.conn = conn
+	token := cc.token
+	cc.rw.Unlock()
+
+	if uid := prev.UID(); uid != 0 {
+		conn.Bind(uid)
+	}
+
+	if c.handshakeEnabled() {
+		key, err := ecdh.GenerateKey()
+		if err != nil {
+			return err
+		}
+
+		cc.shake = &handshake{key: key, done: make(chan error, 1)}
+	}
+
+	c.conns.Store(conn, cc)
+
+	if cc.shake != nil {
+		if err := c.handshake(cc); err != nil {
+			return err
+		}
+	}
+
+	cc.rw.Lock()
+	defer cc.rw.Unlock()
+
+	if cc.state != connReconnecting {
+		return errors.ErrConnectionClosed
+	}
+
+	//新连接在恢复期间已断开
+	if conn.State() == network.ConnClosed {
+		return errors.ErrConnectionClosed
+	}
+
+	if len(token) > 0 && c.opts.resumeRoute >= 0 {
+		msg, err := cc.pack(&cluster.Message{Route: c.opts.resumeRoute, Data: token})
+		if err != nil {
+			return err
+		}
+
+		if err = cc.write(conn, msg); err != nil {
+			return err
+		}
+	}
+
+	for _, msg := range cc.buffer {
+		if err := cc.write(conn, msg); err != nil {
+			return err
+		}
+	}
+
+	cc.buffer = nil
+	cc.state = connOpened
+
+	return nil
+}
+
+// 放弃重连，丢弃缓存的消息并触发断开事件
+func (c *Client) abandon(cc *Conn) {
+	cc.rw.Lock()
+	cc.state = connClosed
+	cc.buffer = nil
+	cc.rw.Unlock()
+
+	c.disconnected(cc)
+}
+
+// 是否已关闭
+func (c *Conn) isClosed() bool {
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	return c.state == connClosed
+}
@@ -0,0 +1,202 @@
+package client
+
+import (
+	"gatesvr/cluster"
+	"gatesvr/encoding"
+	"gatesvr/errors"
+	"gatesvr/network"
+	"gatesvr/packet"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 模拟底层连接，记录推送的消息
+type dialedConn struct {
+	network.Conn
+	id     int64
+	uid    int64
+	closed atomic.Bool
+	pushed chan *packet.Message
+}
+
+func (c *dialedConn) ID() int64 { return c.id }
+
+func (c *dialedConn) UID() int64 { return atomic.LoadInt64(&c.uid) }
+
+func (c *dialedConn) Bind(uid int64) { atomic.StoreInt64(&c.uid, uid) }
+
+func (c *dialedConn) Cipher() network.Cipher { return nil }
+
+func (c *dialedConn) State() network.ConnState {
+	if c.closed.Load() {
+		return network.ConnClosed
+	}
+
+	return network.ConnOpened
+}
+
+func (c *dialedConn) Close(_ ...bool) error {
+	c.closed.Store(true)
+	return nil
+}
+
+func (c *dialedConn) Push(data []byte) error {
+	msg, err := packet.UnpackMessage(data)
+	if err != nil {
+		return err
+	}
+
+	c.pushed <- msg
+
+	return nil
+}
+
+// 模拟网络客户端，fail为true时拨号失败
+type dialer struct {
+	network.Client
+	mu    sync.Mutex
+	fail  bool
+	conns []*dialedConn
+}
+
+func (d *dialer) Dial(_ ...string) (network.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.fail {
+		return nil, errors.ErrConnectionNotOpened
+	}
+
+	conn := &dialedConn{id: int64(len(d.conns) + 1), pushed: make(chan *packet.Message, 10)}
+	d.conns = append(d.conns, conn)
+
+	return conn, nil
+}
+
+func (d *dialer) conn(i int) *dialedConn {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.conns[i]
+}
+
+func newReconnectClient(d *dialer, attempts int) *Client {
+	return NewClient(
+		WithClient(d),
+		WithCodec(encoding.Invoke("json")),
+		WithResumeRoute(9),
+		WithReconnect(&ReconnectPolicy{
+			MaxAttempts: attempts,
+			MinBackoff:  10 * time.Millisecond,
+			MaxBackoff:  20 * time.Millisecond,
+			Buffer:      10,
+		}),
+	)
+}
+
+func TestClient_Reconnect(t *testing.T) {
+	d := &dialer{}
+	c := newReconnectClient(d, 3)
+
+	reconnected := make(chan *Conn, 1)
+	c.addEventListener(cluster.Reconnect, func(conn *Conn) { reconnected <- conn })
+	c.addEventListener(cluster.Disconnect, func(conn *Conn) { t.Error("unexpected disconnect event") })
+	c.setState(cluster.Work)
+
+	conn, err := c.dial(WithConnAttr("name", "bot"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Bind(1)
+
+	token, _ := packet.PackMessage(&packet.Message{Route: 9, Buffer: []byte("token")})
+	c.handleReceive(d.conn(0), token)
+
+	d.mu.Lock()
+	d.fail = true
+	d.mu.Unlock()
+
+	_ = d.conn(0).Close()
+	c.handleDisconnect(d.conn(0))
+
+	if err = conn.Push(&cluster.Message{Route: 1, Data: []byte("buffered")}); err != nil {
+		t.Fatal(err)
+	}
+
+	d.mu.Lock()
+	d.fail = false
+	d.mu.Unlock()
+
+	select {
+	case <-reconnected:
+	case <-time.After(time.Second):
+		t.Fatal("reconnect timeout")
+	}
+
+	if conn.ID() != 2 || conn.UID() != 1 || conn.GetAttr("name").String() != "bot" {
+		t.Fatalf("connection is not restored, id: %d uid: %d", conn.ID(), conn.UID())
+	}
+
+	for _, expect := range []string{"token", "buffered"} {
+		msg := <-d.conn(1).pushed
+		if string(msg.Buffer) != expect {
+			t.Fatalf("unexpected message: %s", msg.Buffer)
+		}
+	}
+}
+
+func TestClient_ReconnectAbandon(t *testing.T) {
+	d := &dialer{}
+	c := newReconnectClient(d, 2)
+
+	disconnected := make(chan *Conn, 1)
+	c.addEventListener(cluster.Disconnect, func(conn *Conn) { disconnected <- conn })
+	c.setState(cluster.Work)
+
+	conn, err := c.dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d.mu.Lock()
+	d.fail = true
+	d.mu.Unlock()
+
+	_ = d.conn(0).Close()
+	c.handleDisconnect(d.conn(0))
+
+	select {
+	case <-disconnected:
+	case <-time.After(time.Second):
+		t.Fatal("disconnect timeout")
+	}
+
+	if err = conn.Push(&cluster.Message{Route: 1}); !errors.Is(err, errors.ErrConnectionClosed) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestClient_CloseWithoutReconnect(t *testing.T) {
+	d := &dialer{}
+	c := newReconnectClient(d, 0)
+
+	disconnected := make(chan *Conn, 1)
+	c.addEventListener(cluster.Disconnect, func(conn *Conn) { disconnected <- conn })
+	c.setState(cluster.Work)
+
+	conn, err := c.dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = conn.Close()
+	c.handleDisconnect(d.conn(0))
+
+	select {
+	case <-disconnected:
+	case <-time.After(time.Second):
+		t.Fatal("disconnect timeout")
+	}
+}
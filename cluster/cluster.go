@@ -120,6 +120,19 @@ type MulticastArgs struct {
 	Message *Message     // 消息
 }
 
+type GroupArgs struct {
+	GID    string       // 网关ID，会话类型为用户时可忽略此参数
+	Kind   session.Kind // 会话类型，session.Conn 或 session.User
+	Target int64        // 会话目标，CID 或 UID
+	Group  string       // 分组名
+}
+
+type MulticastGroupArgs struct {
+	GID     string   // 网关ID，为空时推送至所有网关
+	Group   string   // 分组名
+	Message *Message // 消息
+}
+
 type BroadcastArgs struct {
 	Kind    session.Kind // 会话类型，session.Conn 或 session.User
 	Message *Message     // 消息
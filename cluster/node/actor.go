@@ -2,7 +2,10 @@ package node
 
 import (
 	"gatesvr/cluster"
-	"gatesvr/utils/xcall"
+	"gatesvr/errors"
+	"gatesvr/log"
+	"gatesvr/metrics"
+	"gatesvr/utils/xcron"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -26,7 +29,15 @@ type Actor struct {
 	rw        sync.RWMutex                   // 锁
 	mailbox   chan Context                   // 邮箱
 	fnChan    chan func()                    // 调用函数
+	fnBacklog *backlog                       // 调用函数的积压队列
 	binds     sync.Map                       // 绑定的用户
+	creator   Creator                        // 处理器创建器
+	children  sync.Map                       // 子Actor
+	restarts  []time.Time                    // 时间窗口内的重启时间
+	stopped   bool                           // 是否已停止处理消息
+	active    time.Time                      // 最近一次处理消息的时间
+	idleTask  *wheelTask                     // 空闲检测任务
+	dormant   atomic.Bool                    // 是否因钝化而销毁
 }
 
 // ID 获取Actor的ID
@@ -46,7 +57,18 @@ func (a *Actor) Kind() string {
 
 // Spawn 衍生出一个Actor
 func (a *Actor) Spawn(creator Creator, opts ...ActorOption) (*Actor, error) {
-	return a.scheduler.spawn(creator, opts...)
+	return a.scheduler.spawn(creator, append(opts, withActorParent(a))...)
+}
+
+// Parent 获取父Actor，非通过Actor衍生时返回nil
+func (a *Actor) Parent() *Actor {
+	return a.opts.parent
+}
+
+// Fence 获取集群单例Actor的防护令牌，令牌随每次在新领导者上衍生单调递增，非单例Actor返回0
+// 单例Actor写入外部存储时应携带该令牌，以拒绝已卸任节点上残留的旧实例的写入
+func (a *Actor) Fence() int64 {
+	return a.opts.fence
 }
 
 // Proxy 获取代理API
@@ -56,14 +78,21 @@ func (a *Actor) Proxy() *Proxy {
 
 // Invoke 调用函数（Actor内线程安全）
 func (a *Actor) Invoke(fn func()) {
+	a.post(fn)
+}
+
+// 投递函数到调用队列，Actor未启动时返回false
+func (a *Actor) post(fn func()) bool {
 	a.rw.RLock()
 	defer a.rw.RUnlock()
 
 	if a.state.Load() != started {
-		return
+		return false
 	}
 
 	a.fnChan <- fn
+
+	return true
 }
 
 // AfterFunc 延迟调用，与官方的time.AfterFunc用法一致
@@ -72,18 +101,24 @@ func (a *Actor) AfterFunc(d time.Duration, f func()) *Timer {
 		return nil
 	}
 
-	timer := time.AfterFunc(d, func() {
-		a.rw.RLock()
-		defer a.rw.RUnlock()
+	wheel := a.scheduler.node.wheel
 
-		if a.state.Load() != started {
-			return
-		}
+	task := wheel.afterFunc(d, func() bool {
+		go func() {
+			a.rw.RLock()
+			defer a.rw.RUnlock()
+
+			if a.state.Load() != started {
+				return
+			}
 
-		f()
+			f()
+		}()
+
+		return true
 	})
 
-	return &Timer{timer: timer}
+	return &Timer{wheel: wheel, task: task}
 }
 
 // AfterInvoke 延迟调用（线程安全）
@@ -92,18 +127,72 @@ func (a *Actor) AfterInvoke(d time.Duration, f func()) *Timer {
 		return nil
 	}
 
-	timer := time.AfterFunc(d, func() {
-		a.rw.RLock()
-		defer a.rw.RUnlock()
+	wheel := a.scheduler.node.wheel
 
-		if a.state.Load() != started {
-			return
-		}
+	task := wheel.afterFunc(d, func() bool {
+		return a.deliver(f)
+	})
 
-		a.fnChan <- f
+	return &Timer{wheel: wheel, task: task}
+}
+
+// TickInvoke 按固定间隔重复调用（线程安全），回调在Actor分发协程中执行，直至定时器停止或Actor销毁
+func (a *Actor) TickInvoke(d time.Duration, f func()) *Timer {
+	if a.state.Load() != started {
+		return nil
+	}
+
+	wheel := a.scheduler.node.wheel
+
+	task := wheel.tickFunc(d, func() bool {
+		return a.deliver(f)
 	})
 
-	return &Timer{timer: timer}
+	return &Timer{wheel: wheel, task: task}
+}
+
+// CronInvoke 按cron表达式重复调用（线程安全），回调在Actor分发协程中执行，表达式格式参见xcron.Parse
+func (a *Actor) CronInvoke(spec string, f func()) (*Timer, error) {
+	if a.state.Load() != started {
+		return nil, errors.ErrIllegalOperation
+	}
+
+	schedule, err := xcron.Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	wheel := a.scheduler.node.wheel
+
+	task := wheel.cronFunc(schedule, func() bool {
+		return a.deliver(f)
+	})
+
+	return &Timer{wheel: wheel, task: task}, nil
+}
+
+// 在时间轮协程中投递函数到调用队列，队列已满时暂存到积压队列以免阻塞时间轮，积压队列已满时丢弃函数，Actor未启动时返回false
+func (a *Actor) deliver(fn func()) bool {
+	a.rw.RLock()
+	defer a.rw.RUnlock()
+
+	if a.state.Load() != started {
+		return false
+	}
+
+	if !a.fnBacklog.pending() {
+		select {
+		case a.fnChan <- fn:
+			return true
+		default:
+		}
+	}
+
+	if !a.fnBacklog.push(fn) {
+		log.Warnf("actor invoke backlog is full, function dropped, pid: %s", a.PID())
+	}
+
+	return true
 }
 
 // AddRouteHandler 添加路由处理器
@@ -144,25 +233,128 @@ func (a *Actor) AddEventHandler(event cluster.Event, handler EventHandler) {
 	}
 }
 
-// Next 投递消息到Actor中进行处理
-func (a *Actor) Next(ctx Context) {
+// Next 投递消息到Actor中进行处理，邮箱已满时按溢出策略处理，Actor已钝化时转投递到重新激活的Actor
+func (a *Actor) Next(ctx Context) error {
+	if ok, err := a.enqueue(ctx); ok {
+		return err
+	}
+
+	// 钝化后的Actor不再接收事件
+	if ctx.Kind() == Event {
+		return nil
+	}
+
+	act, err := a.scheduler.obtain(a.Kind(), a.ID())
+	if err != nil {
+		return err
+	}
+
+	return act.Next(ctx)
+}
+
+// 投递消息到邮箱，Actor已钝化时返回false
+func (a *Actor) enqueue(ctx Context) (bool, error) {
 	a.rw.RLock()
 	defer a.rw.RUnlock()
 
+	if a.dormant.Load() {
+		return false, nil
+	}
+
 	if a.state.Load() != started {
-		return
+		return true, nil
 	}
 
 	ctx.storeActor(a)
 
-	ctx.incrVersion()
+	version := ctx.incrVersion()
 
 	ctx.Cancel()
 
-	a.mailbox <- ctx
+	metrics.ActorMailboxDepth.Observe(float64(len(a.mailbox)), a.Kind())
+
+	select {
+	case a.mailbox <- ctx:
+		return true, nil
+	default:
+	}
+
+	return true, a.overflow(ctx, version)
+}
+
+// 邮箱溢出处理
+func (a *Actor) overflow(ctx Context, version int32) error {
+	switch a.opts.overflow {
+	case OverflowDropOldest:
+		for {
+			select {
+			case old := <-a.mailbox:
+				release(old)
+				metrics.ActorMailboxOverflows.Add(1, a.Kind(), a.opts.overflow.String())
+			default:
+			}
+
+			select {
+			case a.mailbox <- ctx:
+				return nil
+			default:
+			}
+		}
+	case OverflowDropNewest:
+		ctx.rollbackVersion(version)
+		metrics.ActorMailboxOverflows.Add(1, a.Kind(), a.opts.overflow.String())
+		return nil
+	case OverflowReject:
+		ctx.rollbackVersion(version)
+		metrics.ActorMailboxOverflows.Add(1, a.Kind(), a.opts.overflow.String())
+		return errors.ErrActorMailboxFull
+	default:
+		if a.opts.blockTimeout <= 0 {
+			a.mailbox <- ctx
+			return nil
+		}
+
+		timer := time.NewTimer(a.opts.blockTimeout)
+		defer timer.Stop()
+
+		select {
+		case a.mailbox <- ctx:
+			return nil
+		case <-timer.C:
+			ctx.rollbackVersion(version)
+			metrics.ActorMailboxOverflows.Add(1, a.Kind(), a.opts.overflow.String())
+			return errors.ErrActorMailboxFull
+		}
+	}
 }
 
-// Deliver 投递消息到当前Actor中进行处理
+// 释放被丢弃消息在当前邮箱中持有的引用，事件会被投递到多个Actor的邮箱中，仅在为最后一个引用时回收
+// 无法确认是否为最后一个引用时仅回退版本号，由其他持有者回收
+func release(ctx Context) {
+	for {
+		version := ctx.loadVersion()
+		if version <= 1 {
+			ctx.compareVersionRecycle(version)
+			return
+		}
+
+		if ctx.rollbackVersion(version) {
+			return
+		}
+	}
+}
+
+// MailboxDepth 获取邮箱中待处理的消息数
+func (a *Actor) MailboxDepth() int {
+	return len(a.mailbox)
+}
+
+// MailboxSize 获取邮箱容量
+func (a *Actor) MailboxSize() int {
+	return cap(a.mailbox)
+}
+
+// Deliver 投递消息到当前Actor中进行处理，邮箱已满时按溢出策略处理
 func (a *Actor) Deliver(uid int64, message *cluster.Message) error {
 	buf, err := a.scheduler.node.proxy.PackBuffer(message.Data)
 	if err != nil {
@@ -176,13 +368,22 @@ func (a *Actor) Deliver(uid int64, message *cluster.Message) error {
 	req.message.Route = message.Route
 	req.message.Data = buf
 
-	a.Next(req)
+	if err = a.Next(req); err != nil {
+		req.compareVersionRecycle(req.loadVersion())
+		return err
+	}
 
 	return nil
 }
 
-// Push 推送消息到本地Node队列上进行处理
+// Push 推送消息到本地Node队列上进行处理，拒绝策略下邮箱已满时返回错误
+// 邮箱是否已满仅在投递到队列前检查，与消息实际进入邮箱之间存在竞争，此时由路由处理器中的Context.Next返回错误
 func (a *Actor) Push(uid int64, message *cluster.Message) error {
+	if a.opts.overflow == OverflowReject && len(a.mailbox) >= cap(a.mailbox) {
+		metrics.ActorMailboxOverflows.Add(1, a.Kind(), a.opts.overflow.String())
+		return errors.ErrActorMailboxFull
+	}
+
 	buf, err := a.scheduler.node.proxy.PackBuffer(message.Data)
 	if err != nil {
 		return err
@@ -209,8 +410,23 @@ func (a *Actor) destroy() bool {
 		return false
 	}
 
+	if a.idleTask != nil {
+		a.scheduler.node.wheel.cancel(a.idleTask)
+	}
+
 	a.processor.Destroy()
 
+	a.killChildren()
+
+	if a.opts.parent != nil {
+		a.opts.parent.children.Delete(a.PID())
+	}
+
+	// 钝化的全局Actor保留目录记录，以便其他节点发送的消息可激活该Actor
+	if a.opts.global && !a.dormant.Load() {
+		a.scheduler.deregister(a)
+	}
+
 	a.scheduler.batchUnbindActor(func(relations map[int64]map[string]*Actor) {
 		a.binds.Range(func(uid, _ any) bool {
 			delete(relations[uid.(int64)], a.Kind())
@@ -256,17 +472,24 @@ func (a *Actor) dispatch() {
 
 			version := ctx.loadVersion()
 
+			a.touch()
+
+			if a.stopped {
+				ctx.compareVersionRecycle(version)
+				continue
+			}
+
 			if ctx.Kind() == Event {
 				if handler, ok := a.events[ctx.Event()]; ok {
-					xcall.Call(func() { handler(ctx) })
-
-					ctx.compareVersionExecDefer(version)
+					if a.call(func() { handler(ctx) }) {
+						ctx.compareVersionExecDefer(version)
+					}
 				}
 			} else {
 				if handler, ok := a.routes[ctx.Route()]; ok {
-					xcall.Call(func() { handler(ctx) })
-
-					ctx.compareVersionExecDefer(version)
+					if a.call(func() { handler(ctx) }) {
+						ctx.compareVersionExecDefer(version)
+					}
 				}
 			}
 
@@ -276,7 +499,9 @@ func (a *Actor) dispatch() {
 				return
 			}
 
-			xcall.Call(handle)
+			if !a.stopped && handle != nil {
+				a.call(handle)
+			}
 		}
 	}
 }
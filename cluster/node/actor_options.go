@@ -1,17 +1,54 @@
 package node
 
+import "time"
+
+const (
+	defaultMailboxSize = 4096 // 默认邮箱容量
+)
+
+const (
+	OverflowBlock      OverflowPolicy = iota // 阻塞等待，设置了阻塞超时时间时超时后返回错误
+	OverflowDropOldest                       // 丢弃邮箱中最早的消息
+	OverflowDropNewest                       // 丢弃当前投递的消息
+	OverflowReject                           // 拒绝投递并返回错误
+)
+
+// OverflowPolicy 邮箱溢出策略
+type OverflowPolicy int
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowDropOldest:
+		return "drop_oldest"
+	case OverflowDropNewest:
+		return "drop_newest"
+	case OverflowReject:
+		return "reject"
+	default:
+		return "block"
+	}
+}
+
 type actorOptions struct {
-	id       string // Actor编号
-	kind     string // Actor类型
-	args     []any  // 传递到Processor中的参数
-	wait     bool   // 是否需要等待
-	dispatch bool   // 是否接受调度器调度
+	id           string         // Actor编号
+	kind         string         // Actor类型
+	args         []any          // 传递到Processor中的参数
+	wait         bool           // 是否需要等待
+	dispatch     bool           // 是否接受调度器调度
+	mailboxSize  int            // 邮箱容量
+	overflow     OverflowPolicy // 邮箱溢出策略
+	blockTimeout time.Duration  // 阻塞策略的超时时间，0为一直阻塞
+	supervisor   *Supervisor    // 监督者，为空时处理器panic后仅记录日志
+	parent       *Actor         // 父Actor
+	idleTimeout  time.Duration  // 空闲超时时间，超时后钝化Actor，0为不钝化
+	global       bool           // 是否注册到Actor目录
+	fence        int64          // 集群单例Actor当选时的防护令牌
 }
 
 type ActorOption func(o *actorOptions)
 
 func defaultActorOptions() *actorOptions {
-	return &actorOptions{wait: true, dispatch: true}
+	return &actorOptions{wait: true, dispatch: true, mailboxSize: defaultMailboxSize}
 }
 
 // WithActorID 设置Actor编号
@@ -38,3 +75,43 @@ func WithActorNonWait() ActorOption {
 func WithActorNonDispatch() ActorOption {
 	return func(o *actorOptions) { o.dispatch = false }
 }
+
+// WithActorMailboxSize 设置Actor邮箱容量，默认为4096
+func WithActorMailboxSize(size int) ActorOption {
+	return func(o *actorOptions) { o.mailboxSize = size }
+}
+
+// WithActorOverflowPolicy 设置Actor邮箱溢出策略，默认为阻塞等待
+func WithActorOverflowPolicy(policy OverflowPolicy) ActorOption {
+	return func(o *actorOptions) { o.overflow = policy }
+}
+
+// WithActorBlockTimeout 设置阻塞策略的超时时间，超时后投递失败，默认一直阻塞
+func WithActorBlockTimeout(timeout time.Duration) ActorOption {
+	return func(o *actorOptions) { o.blockTimeout = timeout }
+}
+
+// WithActorSupervisor 设置Actor监督者，处理器panic后按监督策略重启、停止或上报至父Actor
+func WithActorSupervisor(supervisor *Supervisor) ActorOption {
+	return func(o *actorOptions) { o.supervisor = supervisor }
+}
+
+// WithActorIdleTimeout 设置空闲超时时间，超时未处理消息时回调处理器的OnPassivate后移除Actor，并保留用户绑定关系，待再次收到消息时通过工厂重新激活
+func WithActorIdleTimeout(timeout time.Duration) ActorOption {
+	return func(o *actorOptions) { o.idleTimeout = timeout }
+}
+
+// WithActorGlobal 设置为全局Actor，衍生后注册到定位器的Actor目录，其他节点可通过kind/id定位并发送消息，定位器须实现locate.ActorLocator
+func WithActorGlobal() ActorOption {
+	return func(o *actorOptions) { o.global = true }
+}
+
+// 设置集群单例Actor的防护令牌
+func withActorFence(fence int64) ActorOption {
+	return func(o *actorOptions) { o.fence = fence }
+}
+
+// 设置父Actor
+func withActorParent(parent *Actor) ActorOption {
+	return func(o *actorOptions) { o.parent = parent }
+}
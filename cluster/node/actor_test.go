@@ -0,0 +1,192 @@
+package node
+
+import (
+	"gatesvr/encoding/json"
+	"gatesvr/errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 别名避免嵌入字段与Context方法同名
+type embeddedContext = Context
+
+type testContext struct {
+	embeddedContext
+	id       int
+	version  atomic.Int32
+	recycled atomic.Bool
+}
+
+func (c *testContext) Cancel() {}
+
+func (c *testContext) storeActor(_ *Actor) {}
+
+func (c *testContext) incrVersion() int32 { return c.version.Add(1) }
+
+func (c *testContext) loadVersion() int32 { return c.version.Load() }
+
+func (c *testContext) rollbackVersion(version int32) bool {
+	return c.version.CompareAndSwap(version, version-1)
+}
+
+func (c *testContext) compareVersionRecycle(version int32) {
+	if c.version.CompareAndSwap(version, 0) {
+		c.recycled.Store(true)
+	}
+}
+
+// 新建未启动分发的Actor，邮箱中的消息不会被消费
+func newTestActor(opts ...ActorOption) *Actor {
+	o := defaultActorOptions()
+	o.kind = "test"
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	act := &Actor{opts: o, mailbox: make(chan Context, o.mailboxSize)}
+	act.state.Store(started)
+
+	return act
+}
+
+func fill(t *testing.T, act *Actor) []*testContext {
+	ctxs := make([]*testContext, 0, act.MailboxSize())
+	for i := 0; i < act.MailboxSize(); i++ {
+		ctx := &testContext{id: i}
+		if err := act.Next(ctx); err != nil {
+			t.Fatal(err)
+		}
+		ctxs = append(ctxs, ctx)
+	}
+
+	return ctxs
+}
+
+func TestActor_OverflowReject(t *testing.T) {
+	act := newTestActor(WithActorMailboxSize(2), WithActorOverflowPolicy(OverflowReject))
+	fill(t, act)
+
+	ctx := &testContext{}
+	if err := act.Next(ctx); !errors.Is(err, errors.ErrActorMailboxFull) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ctx.loadVersion() != 0 {
+		t.Fatalf("version is not rolled back: %d", ctx.loadVersion())
+	}
+
+	if act.MailboxDepth() != 2 {
+		t.Fatalf("unexpected mailbox depth: %d", act.MailboxDepth())
+	}
+}
+
+func TestActor_OverflowDropNewest(t *testing.T) {
+	act := newTestActor(WithActorMailboxSize(2), WithActorOverflowPolicy(OverflowDropNewest))
+	ctxs := fill(t, act)
+
+	if err := act.Next(&testContext{id: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expect := range ctxs {
+		if ctx := (<-act.mailbox).(*testContext); ctx != expect {
+			t.Fatalf("unexpected message: %d", ctx.id)
+		}
+	}
+}
+
+func TestActor_OverflowDropOldest(t *testing.T) {
+	act := newTestActor(WithActorMailboxSize(2), WithActorOverflowPolicy(OverflowDropOldest))
+	ctxs := fill(t, act)
+
+	newest := &testContext{id: 2}
+	if err := act.Next(newest); err != nil {
+		t.Fatal(err)
+	}
+
+	if !ctxs[0].recycled.Load() {
+		t.Fatal("oldest message is not recycled")
+	}
+
+	for _, expect := range []*testContext{ctxs[1], newest} {
+		if ctx := (<-act.mailbox).(*testContext); ctx != expect {
+			t.Fatalf("unexpected message: %d", ctx.id)
+		}
+	}
+}
+
+func TestActor_OverflowDropOldestShared(t *testing.T) {
+	act := newTestActor(WithActorMailboxSize(1), WithActorOverflowPolicy(OverflowDropOldest))
+	other := newTestActor(WithActorMailboxSize(1))
+
+	// 同一事件被投递到多个Actor的邮箱中
+	shared := &testContext{}
+	if err := act.Next(shared); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := other.Next(shared); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := act.Next(&testContext{id: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if shared.recycled.Load() {
+		t.Fatal("shared message is recycled while still in another mailbox")
+	}
+
+	ctx := <-other.mailbox
+	ctx.compareVersionRecycle(ctx.loadVersion())
+
+	if !shared.recycled.Load() {
+		t.Fatal("shared message is not recycled by the last holder")
+	}
+}
+
+func TestActor_OverflowBlockTimeout(t *testing.T) {
+	act := newTestActor(WithActorMailboxSize(1), WithActorBlockTimeout(20*time.Millisecond))
+	fill(t, act)
+
+	start := time.Now()
+	if err := act.Next(&testContext{}); !errors.Is(err, errors.ErrActorMailboxFull) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if time.Since(start) < 20*time.Millisecond {
+		t.Fatal("expected blocking until timeout")
+	}
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		<-act.mailbox
+	}()
+
+	if err := act.Next(&testContext{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRequest_ObserveAfterActorHandled(t *testing.T) {
+	n := NewNode(WithID("node-1"), WithCodec(json.DefaultCodec))
+
+	req := n.reqPool.Get().(*request)
+	req.start = time.Now().Add(-50 * time.Millisecond)
+
+	// 路由处理器将请求投递到Actor后返回，请求尚未处理完成
+	version := req.incrVersion()
+	actorVersion := req.incrVersion()
+	req.compareVersionRecycle(version)
+
+	if latency := n.router.latency.Load(); latency != 0 {
+		t.Fatalf("unexpected latency before actor handled: %d", latency)
+	}
+
+	req.compareVersionRecycle(actorVersion)
+
+	if latency := time.Duration(n.router.latency.Load()); latency < 50*time.Millisecond/8 {
+		t.Fatalf("unexpected latency after actor handled: %v", latency)
+	}
+}
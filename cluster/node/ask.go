@@ -0,0 +1,285 @@
+package node
+
+import (
+	"context"
+	"gatesvr/encoding"
+	"gatesvr/errors"
+	"reflect"
+	"sync"
+)
+
+// Receiver Actor消息接收器，处理器实现该接口后可接收其他Actor通过Ask、Tell发送的消息
+type Receiver interface {
+	// Receive 接收消息，在Actor分发协程中执行
+	Receive(envelope *Envelope)
+}
+
+// Envelope Actor间传递的消息
+type Envelope struct {
+	sender  string                     // 发送方PID，来自其他节点时为全局PID
+	message any                        // 消息，来自其他节点时为编码后的字节数组
+	codec   encoding.Codec             // 来自其他节点的消息的编解码器
+	respond func(reply any, err error) // Ask请求的回复函数，Tell发送的消息为nil
+}
+
+// Sender 获取发送方Actor的PID
+func (e *Envelope) Sender() string {
+	return e.sender
+}
+
+// Message 获取消息，来自其他节点的消息为编码后的字节数组，可通过Parse解析
+func (e *Envelope) Message() any {
+	return e.message
+}
+
+// Remote 是否为来自其他节点的消息
+func (e *Envelope) Remote() bool {
+	return e.codec != nil
+}
+
+// Parse 解析消息到v中，v须为指针
+func (e *Envelope) Parse(v any) error {
+	if e.codec != nil {
+		return e.codec.Unmarshal(e.message.([]byte), v)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.ErrInvalidPointer
+	}
+
+	mv := reflect.ValueOf(e.message)
+	if !mv.IsValid() {
+		return nil
+	}
+
+	elem := rv.Elem()
+
+	switch {
+	case mv.Type().AssignableTo(elem.Type()):
+		elem.Set(mv)
+	case mv.Kind() == reflect.Pointer && !mv.IsNil() && mv.Elem().Type().AssignableTo(elem.Type()):
+		elem.Set(mv.Elem())
+	default:
+		return errors.ErrInvalidMessage
+	}
+
+	return nil
+}
+
+// Reply 回复Ask请求，可在Receive返回后异步回复，Tell发送的消息无法回复
+func (e *Envelope) Reply(reply any) error {
+	if e.respond == nil {
+		return errors.ErrIllegalOperation
+	}
+
+	e.respond(reply, nil)
+
+	return nil
+}
+
+// Fail 以错误回复Ask请求
+func (e *Envelope) Fail(err error) error {
+	if e.respond == nil {
+		return errors.ErrIllegalOperation
+	}
+
+	e.respond(nil, err)
+
+	return nil
+}
+
+// Future Ask请求的异步回复
+type Future struct {
+	actor  *Actor             // 发送方Actor
+	once   sync.Once          // 保证只完成一次
+	done   chan struct{}      // 完成信号
+	reply  any                // 回复
+	err    error              // 错误
+	codec  encoding.Codec     // 其他节点回复的编解码器，回复为编码后的字节数组
+	cancel context.CancelFunc // 取消超时上下文
+	mu     sync.Mutex         // 保护stop
+	stop   func() bool        // 注销上下文结束回调
+}
+
+func newFuture(actor *Actor) *Future {
+	return &Future{actor: actor, done: make(chan struct{})}
+}
+
+// Done 收到回复、超时或请求取消时关闭
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait 等待回复，其他节点上的Actor回复的为编码后的字节数组，可通过Await解析
+// 在Actor分发协程中等待时，若目标Actor反向Ask当前Actor将导致死锁，此时应使用Then
+func (f *Future) Wait() (any, error) {
+	<-f.done
+
+	return f.reply, f.err
+}
+
+// Then 收到回复、超时或请求取消后在发送方Actor的分发协程中回调
+func (f *Future) Then(fn func(reply any, err error)) {
+	go func() {
+		<-f.done
+
+		f.actor.Invoke(func() { fn(f.reply, f.err) })
+	}()
+}
+
+// 完成请求
+func (f *Future) resolve(reply any, err error) {
+	f.complete(reply, nil, err)
+}
+
+// 以其他节点回复的编码数据完成请求
+func (f *Future) resolveRemote(reply []byte, codec encoding.Codec) {
+	f.complete(reply, codec, nil)
+}
+
+func (f *Future) complete(reply any, codec encoding.Codec, err error) {
+	f.once.Do(func() {
+		f.reply, f.codec, f.err = reply, codec, err
+		close(f.done)
+
+		f.mu.Lock()
+		stop := f.stop
+		f.stop = nil
+		f.mu.Unlock()
+
+		if stop != nil {
+			stop()
+		}
+
+		if f.cancel != nil {
+			f.cancel()
+		}
+	})
+}
+
+// 保存上下文结束回调的注销函数，请求已完成时直接注销
+func (f *Future) setStop(stop func() bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	select {
+	case <-f.done:
+		stop()
+	default:
+		f.stop = stop
+	}
+}
+
+// Await 等待回复并转换为指定类型，其他节点上的Actor回复的数据将解码为指定类型，类型不匹配时返回ErrInvalidReply
+func Await[T any](f *Future) (T, error) {
+	var zero T
+
+	reply, err := f.Wait()
+	if err != nil {
+		return zero, err
+	}
+
+	if f.codec != nil {
+		return decode[T](f.codec, reply.([]byte))
+	}
+
+	if reply == nil {
+		return zero, nil
+	}
+
+	v, ok := reply.(T)
+	if !ok {
+		return zero, errors.ErrInvalidReply
+	}
+
+	return v, nil
+}
+
+// 解码其他节点回复的数据
+func decode[T any](codec encoding.Codec, data []byte) (T, error) {
+	var v T
+
+	if t := reflect.TypeFor[T](); t.Kind() == reflect.Pointer {
+		v = reflect.New(t.Elem()).Interface().(T)
+		err := codec.Unmarshal(data, v)
+		return v, err
+	}
+
+	err := codec.Unmarshal(data, &v)
+
+	return v, err
+}
+
+// Tell 向指定PID的Actor发送单向消息，消息在目标Actor的分发协程中处理
+// PID包含其他节点ID或通过目录定位到其他节点时，消息经由节点间链接发送
+func (a *Actor) Tell(pid string, message any) error {
+	return a.send(context.Background(), pid, message, nil)
+}
+
+// Ask 向指定PID的Actor发送请求，返回目标Actor回复的异步结果
+// ctx未设置截止时间时使用节点的超时时间，超时后返回ErrDeadlineExceeded；PID的寻址方式与Tell一致
+func (a *Actor) Ask(ctx context.Context, pid string, message any) *Future {
+	f := newFuture(a)
+
+	if _, ok := ctx.Deadline(); !ok {
+		ctx, f.cancel = context.WithTimeout(ctx, a.scheduler.node.opts.timeout)
+	}
+
+	f.setStop(context.AfterFunc(ctx, func() {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			f.resolve(nil, errors.ErrDeadlineExceeded)
+		} else {
+			f.resolve(nil, ctx.Err())
+		}
+	}))
+
+	if err := a.send(ctx, pid, message, f); err != nil {
+		f.resolve(nil, err)
+	}
+
+	return f
+}
+
+// 发送消息，f为nil时为单向消息
+func (a *Actor) send(ctx context.Context, pid string, message any, f *Future) error {
+	kind, id, nid := a.scheduler.resolve(pid)
+
+	if nid != "" {
+		if f == nil {
+			return a.tellRemote(nid, NewPID(kind, id), message)
+		}
+
+		a.askRemote(ctx, nid, NewPID(kind, id), message, f)
+
+		return nil
+	}
+
+	envelope := &Envelope{sender: a.PID(), message: message}
+	if f != nil {
+		envelope.respond = f.resolve
+	}
+
+	return a.scheduler.receive(kind, id, envelope)
+}
+
+// 接收消息，在Actor分发协程中执行
+func (a *Actor) receive(envelope *Envelope) {
+	receiver, ok := a.processor.(Receiver)
+	if !ok {
+		_ = envelope.Fail(errors.ErrMissingReceiver)
+		return
+	}
+
+	done := false
+
+	defer func() {
+		if !done {
+			_ = envelope.Fail(errors.ErrActorPanic)
+		}
+	}()
+
+	receiver.Receive(envelope)
+
+	done = true
+}
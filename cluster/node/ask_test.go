@@ -0,0 +1,143 @@
+package node
+
+import (
+	"context"
+	"gatesvr/errors"
+	"testing"
+	"time"
+)
+
+// 回复消息的处理器，消息为"silent"时不回复
+type echoProcessor struct {
+	BaseProcessor
+	told chan any
+}
+
+func (p *echoProcessor) Receive(envelope *Envelope) {
+	var message string
+	if err := envelope.Parse(&message); err != nil {
+		_ = envelope.Fail(err)
+		return
+	}
+
+	switch message {
+	case "silent":
+	case "panic":
+		panic("boom")
+	default:
+		if err := envelope.Reply(message); err != nil {
+			p.told <- message
+		}
+	}
+}
+
+func spawnPair(t *testing.T) (*Actor, *Actor, chan any) {
+	s := newScheduler(nil)
+	told := make(chan any, 1)
+
+	room, err := s.spawn(func(actor *Actor, _ ...any) Processor { return &BaseProcessor{} }, WithActorKind("room"), WithActorNonWait())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player, err := s.spawn(func(actor *Actor, _ ...any) Processor { return &echoProcessor{told: told} }, WithActorKind("player"), WithActorID("1"), WithActorNonWait())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return room, player, told
+}
+
+func TestActor_Ask(t *testing.T) {
+	room, player, _ := spawnPair(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	f := room.Ask(ctx, player.PID(), "hello")
+
+	reply, err := Await[string](f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reply != "hello" {
+		t.Fatalf("unexpected reply: %s", reply)
+	}
+
+	f.mu.Lock()
+	stop := f.stop
+	f.mu.Unlock()
+
+	if stop != nil {
+		t.Fatal("context callback is not unregistered after completion")
+	}
+
+	if _, err = Await[int](room.Ask(ctx, player.PID(), "hello")); !errors.Is(err, errors.ErrInvalidReply) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err = room.Ask(ctx, player.PID(), "panic").Wait(); !errors.Is(err, errors.ErrActorPanic) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err = player.Ask(ctx, room.PID(), "hello").Wait(); !errors.Is(err, errors.ErrMissingReceiver) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err = room.Ask(ctx, "player/2", "hello").Wait(); !errors.Is(err, errors.ErrNotFoundActor) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestActor_AskTimeout(t *testing.T) {
+	room, player, _ := spawnPair(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := room.Ask(ctx, player.PID(), "silent").Wait(); !errors.Is(err, errors.ErrDeadlineExceeded) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestActor_AskThen(t *testing.T) {
+	room, player, _ := spawnPair(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	replies := make(chan any, 1)
+	room.Ask(ctx, player.PID(), "hello").Then(func(reply any, err error) {
+		if err != nil {
+			t.Error(err)
+		}
+		replies <- reply
+	})
+
+	select {
+	case reply := <-replies:
+		if reply != "hello" {
+			t.Fatalf("unexpected reply: %v", reply)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("callback timeout")
+	}
+}
+
+func TestActor_Tell(t *testing.T) {
+	room, player, told := spawnPair(t)
+
+	if err := room.Tell(player.PID(), "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-told:
+		if msg != "hello" {
+			t.Fatalf("unexpected message: %v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("tell timeout")
+	}
+}
@@ -0,0 +1,51 @@
+package node
+
+import "sync/atomic"
+
+// 调用队列的有界积压队列，调用队列已满时暂存待投递的函数，由单个协程依次阻塞转投递，避免每次投递创建协程
+type backlog struct {
+	fns      chan func()
+	draining atomic.Bool
+	post     func(fn func()) // 阻塞投递函数到调用队列
+}
+
+func newBacklog(size int, post func(fn func())) *backlog {
+	return &backlog{fns: make(chan func(), size), post: post}
+}
+
+// 暂存函数，积压队列已满时返回false
+func (b *backlog) push(fn func()) bool {
+	select {
+	case b.fns <- fn:
+	default:
+		return false
+	}
+
+	if b.draining.CompareAndSwap(false, true) {
+		go b.drain()
+	}
+
+	return true
+}
+
+// 是否存在待转投递的函数，存在时新函数应排在其后以保持投递顺序
+func (b *backlog) pending() bool {
+	return len(b.fns) > 0
+}
+
+// 依次转投递积压的函数，队列为空时退出
+func (b *backlog) drain() {
+	for {
+		select {
+		case fn := <-b.fns:
+			b.post(fn)
+		default:
+			b.draining.Store(false)
+
+			// 退出前暂存的函数可能未能启动新的转投递协程，由当前协程继续处理
+			if len(b.fns) == 0 || !b.draining.CompareAndSwap(false, true) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package node
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBacklog_Drain(t *testing.T) {
+	release := make(chan struct{})
+	posted := make(chan int, 8)
+
+	b := newBacklog(4, func(fn func()) {
+		<-release
+		fn()
+	})
+
+	// 转投递阻塞期间积压的函数数量受队列容量限制
+	pushed := 0
+	for i := 0; i < 8; i++ {
+		if b.push(func() { posted <- i }) {
+			pushed++
+		}
+	}
+
+	if pushed < 4 || pushed > 5 {
+		t.Fatalf("unexpected pushed count: %d", pushed)
+	}
+
+	close(release)
+
+	for i := 0; i < pushed; i++ {
+		select {
+		case v := <-posted:
+			if v != i {
+				t.Fatalf("unexpected post order: %d != %d", v, i)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("backlog is not drained")
+		}
+	}
+
+	if !b.push(func() { posted <- -1 }) {
+		t.Fatal("push failed after drain")
+	}
+
+	select {
+	case <-posted:
+	case <-time.After(time.Second):
+		t.Fatal("backlog is not drained after restart")
+	}
+}
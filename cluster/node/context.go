@@ -103,6 +103,8 @@ type Context interface {
 	incrVersion() int32
 	// 获取版本号
 	loadVersion() int32
+	// 回退版本号，投递失败时归还对象的所有权，版本号已变更时返回false
+	rollbackVersion(version int32) bool
 	// 比对版本号后进行回收对象
 	compareVersionRecycle(version int32)
 	// 执行defer调用栈
@@ -0,0 +1,288 @@
+package node
+
+import (
+	"context"
+	"gatesvr/cron"
+	"gatesvr/errors"
+	"gatesvr/log"
+	"gatesvr/utils/lock"
+	"gatesvr/utils/xcron"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	cronLockPrefix     = "cron:" // 定时任务锁名称前缀
+	defaultCronCatchUp = 1       // 默认补偿执行次数
+	maxCronCatchUpScan = 100000  // 补偿时最多扫描的错过次数，避免高频任务长时间停机后扫描过久
+)
+
+// CronHandler 定时任务处理器，at为本次执行的计划时间，补偿执行时为错过的计划时间
+type CronHandler func(at time.Time)
+
+type CronOption func(o *cronOptions)
+
+type cronOptions struct {
+	location *time.Location // 时区，表达式中以CRON_TZ=指定的时区优先，默认为本地时区
+	catchUp  int            // 启动时补偿执行错过的最近几次执行，0为不补偿
+}
+
+// WithCronLocation 设置定时任务的时区
+func WithCronLocation(location *time.Location) CronOption {
+	return func(o *cronOptions) { o.location = location }
+}
+
+// WithCronCatchUp 设置启动时补偿执行错过的最近几次执行，0为不补偿，默认为1
+func WithCronCatchUp(n int) CronOption {
+	return func(o *cronOptions) { o.catchUp = n }
+}
+
+// 定时任务
+type cronJob struct {
+	name     string
+	schedule *xcron.Schedule
+	handler  CronHandler
+	opts     *cronOptions
+	locker   lock.Locker
+	task     *wheelTask
+	mu       sync.Mutex
+	next     time.Time   // 下一次执行的计划时间
+	stopped  atomic.Bool // 是否已停止调度
+}
+
+// 集群定时任务表，各节点均按计划触发任务，通过锁及执行记录保证每次计划时间在集群中仅执行一次
+type crontab struct {
+	node    *Node
+	store   cron.Store
+	mu      sync.Mutex
+	jobs    map[string]*cronJob
+	started bool
+}
+
+func newCrontab(node *Node) *crontab {
+	return &crontab{node: node, store: node.opts.cronStore, jobs: make(map[string]*cronJob)}
+}
+
+// 添加定时任务，节点启动后开始调度，未设置共享的执行记录存储时无法保证集群中仅执行一次，返回错误
+func (c *crontab) add(name, spec string, handler CronHandler, opts ...CronOption) error {
+	if c.store == nil {
+		return errors.ErrMissingCronStore
+	}
+
+	o := &cronOptions{catchUp: defaultCronCatchUp}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	schedule, err := xcron.ParseInLocation(spec, o.location)
+	if err != nil {
+		return err
+	}
+
+	maker := c.node.opts.lockMaker
+	if maker == nil {
+		maker = lock.GetMaker()
+	}
+
+	if maker == nil {
+		return errors.ErrMissingLockMaker
+	}
+
+	job := &cronJob{name: name, schedule: schedule, handler: handler, opts: o, locker: maker.Make(cronLockPrefix + name)}
+	job.task = &wheelTask{fn: func() bool { return c.fire(job) }}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.jobs[name]; ok {
+		return errors.ErrCronJobExists
+	}
+
+	c.jobs[name] = job
+
+	if c.started {
+		c.node.addWait()
+		go c.begin(job)
+	}
+
+	return nil
+}
+
+// 移除定时任务
+func (c *crontab) remove(name string) bool {
+	c.mu.Lock()
+	job, ok := c.jobs[name]
+	delete(c.jobs, name)
+	c.mu.Unlock()
+
+	if ok {
+		c.halt(job)
+	}
+
+	return ok
+}
+
+// 开始调度所有定时任务
+func (c *crontab) start() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.started = true
+
+	for _, job := range c.jobs {
+		job.stopped.Store(false)
+
+		c.node.addWait()
+		go c.begin(job)
+	}
+}
+
+// 停止调度所有定时任务
+func (c *crontab) stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.started = false
+
+	for _, job := range c.jobs {
+		c.halt(job)
+	}
+}
+
+// 补偿错过的执行后开始调度任务，任务的停止标记仅在持有c.mu时设置，以免覆盖调度开始前的停止
+// 调用方需已增加等待计数，节点关闭时等待补偿执行完成
+func (c *crontab) begin(job *cronJob) {
+	defer c.node.doneWait()
+
+	c.catchUp(job)
+
+	if job.stopped.Load() {
+		return
+	}
+
+	c.schedule(job)
+}
+
+// 调度任务的下一次执行，计划无下一次执行时间时不再调度
+func (c *crontab) schedule(job *cronJob) {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+
+	if job.next = job.schedule.Next(time.Now()); !job.next.IsZero() {
+		c.node.wheel.reset(job.task, time.Until(job.next))
+	}
+}
+
+// 到达计划时间，在时间轮协程中执行，认领及执行在独立协程中进行
+func (c *crontab) fire(job *cronJob) bool {
+	if job.stopped.Load() {
+		return false
+	}
+
+	job.mu.Lock()
+	at := job.next
+	job.mu.Unlock()
+
+	c.schedule(job)
+
+	c.node.addWait()
+	go c.run(job, at)
+
+	return true
+}
+
+// 停止调度任务
+func (c *crontab) halt(job *cronJob) {
+	job.stopped.Store(true)
+
+	c.node.wheel.cancel(job.task)
+}
+
+// 补偿执行上次执行后至今错过的最近几次执行，无执行记录时不补偿
+func (c *crontab) catchUp(job *cronJob) {
+	if job.opts.catchUp <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.node.ctx, c.node.opts.timeout)
+	last, err := c.store.Load(ctx, job.name)
+	cancel()
+	if err != nil {
+		log.Warnf("load cron job record failed, name: %s err: %v", job.name, err)
+		return
+	}
+
+	if last.IsZero() {
+		return
+	}
+
+	now := time.Now()
+	missed := make([]time.Time, 0, job.opts.catchUp)
+
+	for i, at := 0, job.schedule.Next(last); i < maxCronCatchUpScan && !at.IsZero() && !at.After(now); i, at = i+1, job.schedule.Next(at) {
+		if len(missed) == job.opts.catchUp {
+			missed = append(missed[:0], missed[1:]...)
+		}
+
+		missed = append(missed, at)
+	}
+
+	for _, at := range missed {
+		if job.stopped.Load() {
+			return
+		}
+
+		log.Infof("catch up missed cron job, name: %s at: %s", job.name, at)
+
+		c.node.addWait()
+		c.run(job, at)
+	}
+}
+
+// 认领并执行任务，处理器在节点分发协程中执行
+// 调用方需已增加等待计数，使节点关闭时等待认领中的任务，认领失败或节点已销毁时释放等待计数
+func (c *crontab) run(job *cronJob, at time.Time) {
+	if !c.claim(job, at) {
+		c.node.doneWait()
+		return
+	}
+
+	c.node.post(func() { job.handler(at) })
+}
+
+// 认领计划时间的执行权，持有锁期间检查并更新执行记录，计划时间已被其他节点执行时返回false
+func (c *crontab) claim(job *cronJob, at time.Time) bool {
+	ctx, cancel := context.WithTimeout(c.node.ctx, c.node.opts.timeout)
+	defer cancel()
+
+	if err := job.locker.TryAcquire(ctx, c.node.opts.timeout); err != nil {
+		if !errors.Is(err, errors.ErrIllegalOperation) {
+			log.Warnf("acquire cron job lock failed, name: %s err: %v", job.name, err)
+		}
+		return false
+	}
+
+	defer func() {
+		if err := job.locker.Release(ctx); err != nil {
+			log.Warnf("release cron job lock failed, name: %s err: %v", job.name, err)
+		}
+	}()
+
+	last, err := c.store.Load(ctx, job.name)
+	if err != nil {
+		log.Warnf("load cron job record failed, name: %s err: %v", job.name, err)
+		return false
+	}
+
+	if !last.Before(at) {
+		return false
+	}
+
+	if err = c.store.Save(ctx, job.name, at); err != nil {
+		log.Warnf("save cron job record failed, name: %s err: %v", job.name, err)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,183 @@
+package node
+
+import (
+	"context"
+	"gatesvr/cluster"
+	"gatesvr/cron/memory"
+	"gatesvr/encoding/json"
+	"gatesvr/errors"
+	locatememory "gatesvr/locate/memory"
+	lockmemory "gatesvr/utils/lock/memory"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newCronNode(id string, store *memory.Store, maker *lockmemory.Maker) *Node {
+	n := NewNode(WithID(id), WithCodec(json.DefaultCodec), WithLocator(locatememory.NewLocator()),
+		WithLockMaker(maker), WithCronStore(store), WithTimerTick(time.Millisecond))
+
+	go n.dispatch()
+
+	return n
+}
+
+func TestCrontab_RunOnce(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		runs  = make(map[time.Time]int)
+		store = memory.NewStore()
+		maker = lockmemory.NewMaker()
+		nodes = []*Node{newCronNode("node-1", store, maker), newCronNode("node-2", store, maker)}
+	)
+
+	for _, n := range nodes {
+		if err := n.proxy.AddCronJob("flush", "* * * * * *", func(at time.Time) {
+			mu.Lock()
+			runs[at]++
+			mu.Unlock()
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		n.crontab.start()
+	}
+
+	if err := nodes[0].proxy.AddCronJob("flush", "* * * * * *", func(time.Time) {}); err == nil {
+		t.Fatal("expected duplicate job error")
+	}
+
+	time.Sleep(2500 * time.Millisecond)
+
+	for _, n := range nodes {
+		n.crontab.stop()
+		n.cancel()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(runs) < 2 {
+		t.Fatalf("unexpected run count: %d", len(runs))
+	}
+
+	for at, count := range runs {
+		if count != 1 || at.Nanosecond() != 0 {
+			t.Fatalf("job at %s runs %d times", at, count)
+		}
+	}
+}
+
+func TestCrontab_CatchUp(t *testing.T) {
+	var (
+		store = memory.NewStore()
+		now   = time.Now()
+		last  = now.Truncate(time.Hour).Add(-3 * time.Hour)
+		runs  = make(chan time.Time, 4)
+	)
+
+	if err := store.Save(context.Background(), "snapshot", last); err != nil {
+		t.Fatal(err)
+	}
+
+	n := newCronNode("node-1", store, lockmemory.NewMaker())
+	defer n.cancel()
+
+	if err := n.proxy.AddCronJob("snapshot", "0 * * * *", func(at time.Time) { runs <- at },
+		WithCronCatchUp(2), WithCronLocation(time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	n.crontab.start()
+	defer n.crontab.stop()
+
+	for _, expect := range []time.Time{last.Add(2 * time.Hour), last.Add(3 * time.Hour)} {
+		select {
+		case at := <-runs:
+			if !at.Equal(expect) {
+				t.Fatalf("unexpected catch up time: %s, expect: %s", at, expect)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("missed run is not caught up")
+		}
+	}
+
+	if at, _ := store.Load(context.Background(), "snapshot"); !at.Equal(last.Add(3 * time.Hour)) {
+		t.Fatalf("unexpected last run: %s", at)
+	}
+}
+
+func TestCrontab_MissingStore(t *testing.T) {
+	n := NewNode(WithID("node-1"), WithCodec(json.DefaultCodec), WithLocator(locatememory.NewLocator()),
+		WithLockMaker(lockmemory.NewMaker()))
+	defer n.cancel()
+
+	if err := n.proxy.AddCronJob("flush", "* * * * * *", func(time.Time) {}); !errors.Is(err, errors.ErrMissingCronStore) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCrontab_RemoveBeforeBegin(t *testing.T) {
+	n := newCronNode("node-1", memory.NewStore(), lockmemory.NewMaker())
+	defer n.cancel()
+
+	runs := make(chan time.Time, 4)
+
+	if err := n.proxy.AddCronJob("flush", "* * * * * *", func(at time.Time) { runs <- at }); err != nil {
+		t.Fatal(err)
+	}
+
+	// 调度协程开始前移除的任务不应被重新调度
+	n.crontab.start()
+	n.proxy.RemoveCronJob("flush")
+	defer n.crontab.stop()
+
+	select {
+	case at := <-runs:
+		t.Fatalf("removed job runs at %s", at)
+	case <-time.After(1500 * time.Millisecond):
+	}
+}
+
+// 加载执行记录较慢的存储，用于模拟节点关闭时认领中的任务
+type slowStore struct {
+	*memory.Store
+	loading chan struct{}
+	once    sync.Once
+}
+
+func (s *slowStore) Load(ctx context.Context, name string) (time.Time, error) {
+	s.once.Do(func() { close(s.loading) })
+	time.Sleep(200 * time.Millisecond)
+
+	return s.Store.Load(ctx, name)
+}
+
+func TestCrontab_CloseWaitsClaim(t *testing.T) {
+	store := &slowStore{Store: memory.NewStore(), loading: make(chan struct{})}
+
+	n := NewNode(WithID("node-1"), WithCodec(json.DefaultCodec), WithLocator(locatememory.NewLocator()),
+		WithLockMaker(lockmemory.NewMaker()), WithCronStore(store), WithTimerTick(time.Millisecond))
+	n.state.Store(int32(cluster.Work))
+
+	go n.dispatch()
+
+	if err := n.proxy.AddCronJob("flush", "* * * * * *", func(time.Time) {}, WithCronCatchUp(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	n.crontab.start()
+
+	select {
+	case <-store.loading:
+	case <-time.After(2 * time.Second):
+		t.Fatal("job is not claimed")
+	}
+
+	// 关闭时应等待认领中的任务，销毁后不再向调用队列投递
+	n.crontab.stop()
+	n.wg.Wait()
+	n.closeFnChan()
+
+	time.Sleep(300 * time.Millisecond)
+}
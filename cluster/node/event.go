@@ -340,6 +340,11 @@ func (e *event) loadVersion() int32 {
 	return e.version.Load()
 }
 
+// 回退版本号
+func (e *event) rollbackVersion(version int32) bool {
+	return e.version.CompareAndSwap(version, version-1)
+}
+
 // 比对版本号后进行回收对象
 func (e *event) compareVersionRecycle(version int32) {
 	if e.version.CompareAndSwap(version, 0) {
@@ -15,6 +15,7 @@ import (
 	"golang.org/x/sync/errgroup"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type HookHandler func(proxy *Proxy)
@@ -40,10 +41,15 @@ type Node struct {
 	instances   []*registry.ServiceInstance
 	linker      *node.Server
 	fnChan      chan func()
+	fnBacklog   *backlog     // 调用队列的积压队列
+	fnRw        sync.RWMutex // 读写锁，保护调用队列的关闭
+	fnClosed    bool         // 调用队列是否已关闭
 	scheduler   *Scheduler
+	wheel       *timingWheel
+	crontab     *crontab
 	transporter transport.Server
 	wg          *sync.WaitGroup
-	rw          sync.RWMutex
+	rw          sync.RWMutex // 读写锁，保护钩子及服务实例的状态、负载
 	hooks       map[cluster.Hook][]HookHandler
 }
 
@@ -60,10 +66,13 @@ func NewNode(opts ...Option) *Node {
 	n.router = newRouter(n)
 	n.trigger = newTrigger(n)
 	n.scheduler = newScheduler(n)
+	n.wheel = newTimingWheel(n.ctx, o.timerTick)
+	n.crontab = newCrontab(n)
 	n.hooks = make(map[cluster.Hook][]HookHandler)
 	n.services = make([]*serviceEntity, 0)
 	n.instances = make([]*registry.ServiceInstance, 0)
 	n.fnChan = make(chan func(), 4096)
+	n.fnBacklog = newBacklog(4096, n.post)
 	n.state.Store(int32(cluster.Shut))
 	n.wg = &sync.WaitGroup{}
 	n.evtPool = &sync.Pool{New: func() interface{} {
@@ -131,10 +140,16 @@ func (n *Node) Start() {
 
 	n.registerServiceInstances()
 
+	if n.opts.loadInterval > 0 {
+		go n.reportLoad()
+	}
+
 	n.proxy.watch()
 
 	go n.dispatch()
 
+	n.crontab.start()
+
 	n.printInfo()
 
 	n.runHookFunc(cluster.Start)
@@ -152,6 +167,12 @@ func (n *Node) Close() {
 
 	n.runHookFunc(cluster.Close)
 
+	n.crontab.stop()
+
+	n.scheduler.resign()
+
+	n.wheel.stop()
+
 	n.wg.Wait()
 }
 
@@ -173,9 +194,19 @@ func (n *Node) Destroy() {
 
 	n.trigger.close()
 
-	close(n.fnChan)
+	n.closeFnChan()
+}
 
+// 关闭调用队列，先取消上下文使阻塞投递的协程放弃投递并释放读锁
+func (n *Node) closeFnChan() {
 	n.cancel()
+
+	n.fnRw.Lock()
+	defer n.fnRw.Unlock()
+
+	n.fnClosed = true
+
+	close(n.fnChan)
 }
 
 // Proxy 获取节点代理
@@ -339,8 +370,7 @@ func (n *Node) refreshServiceInstances() {
 // 解注册服务实例
 func (n *Node) deregisterServiceInstances() {
 	eg, ctx := errgroup.WithContext(n.ctx)
-	for i := range n.instances {
-		instance := n.instances[i]
+	for _, instance := range n.snapshotServiceInstances() {
 		eg.Go(func() error {
 			ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
 			defer cancel()
@@ -357,8 +387,7 @@ func (n *Node) deregisterServiceInstances() {
 func (n *Node) doRegisterServiceInstances() error {
 	eg, ctx := errgroup.WithContext(n.ctx)
 
-	for i := range n.instances {
-		instance := n.instances[i]
+	for _, instance := range n.snapshotServiceInstances() {
 		eg.Go(func() error {
 			ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
 			defer cancel()
@@ -371,13 +400,71 @@ func (n *Node) doRegisterServiceInstances() error {
 
 // 执行刷新实例状态操作
 func (n *Node) doRefreshServiceInstances() error {
+	n.rw.Lock()
 	for _, instance := range n.instances {
 		instance.State = n.getState().String()
 	}
+	n.rw.Unlock()
 
 	return n.doRegisterServiceInstances()
 }
 
+// 复制服务实例，注册中心序列化副本，避免与状态、负载的更新并发读写
+func (n *Node) snapshotServiceInstances() []*registry.ServiceInstance {
+	n.rw.RLock()
+	defer n.rw.RUnlock()
+
+	instances := make([]*registry.ServiceInstance, 0, len(n.instances))
+	for _, instance := range n.instances {
+		ins := *instance
+		instances = append(instances, &ins)
+	}
+
+	return instances
+}
+
+// 定期上报负载
+func (n *Node) reportLoad() {
+	ticker := time.NewTicker(n.opts.loadInterval)
+	defer ticker.Stop()
+
+	var last registry.Load
+
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case <-ticker.C:
+			if state := n.getState(); state == cluster.Shut {
+				return
+			}
+
+			load := n.collectLoad()
+			if load == last {
+				continue
+			}
+			last = load
+
+			n.rw.Lock()
+			for _, instance := range n.instances {
+				instance.Load = &load
+			}
+			n.rw.Unlock()
+
+			n.refreshServiceInstances()
+		}
+	}
+}
+
+// 采集负载
+func (n *Node) collectLoad() registry.Load {
+	return registry.Load{
+		Actors:  int(n.scheduler.count.Load()),
+		Pending: n.router.pending(),
+		Latency: time.Duration(n.router.latency.Load()).Microseconds(),
+	}
+}
+
 // 获取状态
 func (n *Node) getState() cluster.State {
 	return cluster.State(n.state.Load())
@@ -454,6 +541,50 @@ func (n *Node) printInfo() {
 	info.PrintBoxInfo("Node", infos...)
 }
 
+// 在时间轮协程中投递函数到调用队列，调用方需已增加等待计数
+// 队列已满时暂存到积压队列以免阻塞时间轮，积压队列已满时丢弃函数；放弃投递时释放等待计数，节点已销毁时返回false
+func (n *Node) deliver(fn func()) bool {
+	n.fnRw.RLock()
+	defer n.fnRw.RUnlock()
+
+	if n.fnClosed {
+		n.doneWait()
+		return false
+	}
+
+	if !n.fnBacklog.pending() {
+		select {
+		case n.fnChan <- fn:
+			return true
+		default:
+		}
+	}
+
+	if !n.fnBacklog.push(fn) {
+		log.Warnf("node invoke backlog is full, function dropped")
+		n.doneWait()
+	}
+
+	return true
+}
+
+// 阻塞投递函数到调用队列，节点销毁时放弃投递并释放等待计数
+func (n *Node) post(fn func()) {
+	n.fnRw.RLock()
+	defer n.fnRw.RUnlock()
+
+	if n.fnClosed {
+		n.doneWait()
+		return
+	}
+
+	select {
+	case n.fnChan <- fn:
+	case <-n.ctx.Done():
+		n.doneWait()
+	}
+}
+
 func (n *Node) doneWait() {
 	if n.getState() != cluster.Shut {
 		n.wg.Done()
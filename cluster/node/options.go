@@ -2,32 +2,40 @@ package node
 
 import (
 	"context"
+	"gatesvr/cron"
 	"gatesvr/crypto"
 	"gatesvr/encoding"
 	"gatesvr/etc"
+	"gatesvr/internal/dispatcher"
 	"gatesvr/locate"
 	"gatesvr/registry"
 	"gatesvr/transport"
+	"gatesvr/utils/lock"
 	"gatesvr/utils/xuuid"
 
 	"time"
 )
 
 const (
-	defaultName    = "node"          // 默认节点名称
-	defaultAddr    = ":0"            // 连接器监听地址
-	defaultCodec   = "json"          // 默认编解码器名称
-	defaultTimeout = 3 * time.Second // 默认超时时间
-	defaultWeight  = 1               // 默认权重
+	defaultName    = "node"                // 默认节点名称
+	defaultAddr    = ":0"                  // 连接器监听地址
+	defaultCodec   = "json"                // 默认编解码器名称
+	defaultTimeout = 3 * time.Second       // 默认超时时间
+	defaultWeight  = 1                     // 默认权重
+	defaultTick    = 10 * time.Millisecond // 默认定时器时间轮刻度
 )
 
 const (
-	defaultIDKey      = "etc.cluster.node.id"
-	defaultNameKey    = "etc.cluster.node.name"
-	defaultAddrKey    = "etc.cluster.node.addr"
-	defaultCodecKey   = "etc.cluster.node.codec"
-	defaultTimeoutKey = "etc.cluster.node.timeout"
-	defaultWeightKey  = "etc.cluster.node.weight"
+	defaultIDKey              = "etc.cluster.node.id"
+	defaultNameKey            = "etc.cluster.node.name"
+	defaultAddrKey            = "etc.cluster.node.addr"
+	defaultCodecKey           = "etc.cluster.node.codec"
+	defaultTimeoutKey         = "etc.cluster.node.timeout"
+	defaultWeightKey          = "etc.cluster.node.weight"
+	defaultBalanceStrategyKey = "etc.cluster.node.balanceStrategy"
+	defaultLoadIntervalKey    = "etc.cluster.node.loadInterval"
+	defaultSingletonTTLKey    = "etc.cluster.node.singletonTTL"
+	defaultTimerTickKey       = "etc.cluster.node.timerTick"
 )
 
 // SchedulingModel 调度模型
@@ -36,27 +44,34 @@ type SchedulingModel string
 type Option func(o *options)
 
 type options struct {
-	ctx         context.Context       // 上下文
-	id          string                // 实例ID
-	name        string                // 实例名称；相同实例名称的节点，用户只能绑定其中一个
-	addr        string                // 监听地址
-	codec       encoding.Codec        // 编解码器
-	timeout     time.Duration         // RPC调用超时时间
-	locator     locate.Locator        // 用户定位器
-	registry    registry.Registry     // 服务注册器
-	encryptor   crypto.Encryptor      // 消息加密器
-	transporter transport.Transporter // 消息传输器
-	weight      int                   // 权重
+	ctx             context.Context            // 上下文
+	id              string                     // 实例ID
+	name            string                     // 实例名称；相同实例名称的节点，用户只能绑定其中一个
+	addr            string                     // 监听地址
+	codec           encoding.Codec             // 编解码器
+	timeout         time.Duration              // RPC调用超时时间
+	locator         locate.Locator             // 用户定位器
+	registry        registry.Registry          // 服务注册器
+	encryptor       crypto.Encryptor           // 消息加密器
+	transporter     transport.Transporter      // 消息传输器
+	weight          int                        // 权重
+	balanceStrategy dispatcher.BalanceStrategy // 无状态路由负载均衡策略
+	loadInterval    time.Duration              // 负载上报间隔，0为不上报
+	lockMaker       lock.Maker                 // 集群单例Actor选主使用的锁制造商，为空时使用全局锁制造商
+	singletonTTL    time.Duration              // 集群单例Actor的选主租约时长，0为使用选主的默认值
+	timerTick       time.Duration              // 定时器时间轮刻度，即定时器的精度
+	cronStore       cron.Store                 // 定时任务执行记录存储，为空时无法添加定时任务
 }
 
 func defaultOptions() *options {
 	opts := &options{
-		ctx:     context.Background(),
-		name:    defaultName,
-		addr:    defaultAddr,
-		codec:   encoding.Invoke(defaultCodec),
-		timeout: defaultTimeout,
-		weight:  defaultWeight,
+		ctx:       context.Background(),
+		name:      defaultName,
+		addr:      defaultAddr,
+		codec:     encoding.Invoke(defaultCodec),
+		timeout:   defaultTimeout,
+		weight:    defaultWeight,
+		timerTick: defaultTick,
 	}
 
 	if id := etc.Get(defaultIDKey).String(); id != "" {
@@ -85,6 +100,14 @@ func defaultOptions() *options {
 		opts.weight = weight
 	}
 
+	opts.balanceStrategy = dispatcher.BalanceStrategy(etc.Get(defaultBalanceStrategyKey).String())
+	opts.loadInterval = etc.Get(defaultLoadIntervalKey).Duration()
+	opts.singletonTTL = etc.Get(defaultSingletonTTLKey).Duration()
+
+	if tick := etc.Get(defaultTimerTickKey).Duration(); tick > 0 {
+		opts.timerTick = tick
+	}
+
 	return opts
 }
 
@@ -142,3 +165,38 @@ func WithTransporter(transporter transport.Transporter) Option {
 func WithWeight(weight int) Option {
 	return func(o *options) { o.weight = weight }
 }
+
+// WithBalanceStrategy 设置无状态路由负载均衡策略，默认为随机
+func WithBalanceStrategy(strategy dispatcher.BalanceStrategy) Option {
+	return func(o *options) { o.balanceStrategy = strategy }
+}
+
+// WithLoadInterval 设置负载上报间隔，节点定期将负载写入服务实例，供按负载分配的策略使用
+func WithLoadInterval(interval time.Duration) Option {
+	return func(o *options) { o.loadInterval = interval }
+}
+
+// WithLockMaker 设置集群单例Actor选主使用的锁制造商，锁须实现lock.Leaser
+func WithLockMaker(maker lock.Maker) Option {
+	return func(o *options) { o.lockMaker = maker }
+}
+
+// WithSingletonTTL 设置集群单例Actor的选主租约时长，领导者宕机后其他节点最迟在租约到期后接管
+func WithSingletonTTL(ttl time.Duration) Option {
+	return func(o *options) { o.singletonTTL = ttl }
+}
+
+// WithTimerTick 设置定时器时间轮刻度，刻度越小定时器越精确，但时间轮空转的开销越大，默认为10ms
+func WithTimerTick(tick time.Duration) Option {
+	return func(o *options) {
+		if tick > 0 {
+			o.timerTick = tick
+		}
+	}
+}
+
+// WithCronStore 设置定时任务执行记录存储，集群部署时须使用共享存储，以保证每次计划时间仅执行一次并在重启后补偿错过的执行
+// 未设置时添加定时任务将返回错误，单节点部署可使用cron/memory中的进程内存储
+func WithCronStore(store cron.Store) Option {
+	return func(o *options) { o.cronStore = store }
+}
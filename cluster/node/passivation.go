@@ -0,0 +1,250 @@
+package node
+
+import (
+	"gatesvr/errors"
+	"gatesvr/log"
+	"strings"
+	"time"
+)
+
+// Passivator 处理器钝化回调，处理器实现该接口时在Actor因空闲被钝化前回调，可用于保存状态
+type Passivator interface {
+	// OnPassivate 钝化回调
+	OnPassivate()
+}
+
+// Actor工厂，用于按需激活被钝化的Actor
+type factory struct {
+	creator Creator
+	opts    []ActorOption
+}
+
+// 记录最近一次处理消息的时间，在Actor分发协程中执行
+func (a *Actor) touch() {
+	if a.opts.idleTimeout > 0 {
+		a.active = time.Now()
+	}
+}
+
+// 启动空闲检测
+func (a *Actor) startIdleCheck() {
+	if a.opts.idleTimeout <= 0 {
+		return
+	}
+
+	a.active = time.Now()
+	a.idleTask = &wheelTask{fn: func() bool { return a.deliver(a.passivate) }}
+	a.scheduler.node.wheel.reset(a.idleTask, a.opts.idleTimeout)
+}
+
+// 钝化Actor，在Actor分发协程中执行
+// 空闲未超时或邮箱中存在待处理消息时重新计时，否则回调处理器后移除Actor并保留用户绑定关系
+func (a *Actor) passivate() {
+	if a.stopped {
+		return
+	}
+
+	if idle := time.Since(a.active); idle < a.opts.idleTimeout {
+		a.scheduler.node.wheel.reset(a.idleTask, a.opts.idleTimeout-idle)
+		return
+	}
+
+	if passivator, ok := a.processor.(Passivator); ok {
+		if !a.call(passivator.OnPassivate) {
+			return
+		}
+	}
+
+	if !a.scheduler.passivate(a) {
+		a.scheduler.node.wheel.reset(a.idleTask, a.opts.idleTimeout)
+		return
+	}
+
+	a.stopped = true
+
+	log.Debugf("actor passivated, pid: %s", a.PID())
+
+	go a.scheduler.finish(a)
+}
+
+// 添加Actor工厂
+func (s *Scheduler) addFactory(kind string, creator Creator, opts ...ActorOption) {
+	s.factories.Store(kind, &factory{creator: creator, opts: opts})
+}
+
+// 钝化Actor，将用户绑定关系转为休眠记录后移除Actor，存在正在投递或待处理的消息时放弃钝化
+// 持有Actor写锁期间完成检查及钝化标记，此后到达的消息由Actor.Next转投递到重新激活的Actor
+func (s *Scheduler) passivate(act *Actor) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if a, ok := s.load(act.Kind(), act.ID()); !ok || a != act {
+		return false
+	}
+
+	// 钝化在Actor分发协程中执行，阻塞等待写锁可能与阻塞在邮箱上的投递方互相等待
+	if !act.rw.TryLock() {
+		return false
+	}
+	defer act.rw.Unlock()
+
+	if len(act.mailbox) > 0 {
+		return false
+	}
+
+	s.rw.Lock()
+
+	uids := make([]int64, 0)
+	act.binds.Range(func(key, _ any) bool {
+		uid := key.(int64)
+
+		if relations, ok := s.relations[uid]; ok && relations[act.Kind()] == act {
+			delete(relations, act.Kind())
+
+			dormants, ok := s.dormants[uid]
+			if !ok {
+				dormants = make(map[string]string)
+				s.dormants[uid] = dormants
+			}
+
+			dormants[act.Kind()] = act.ID()
+
+			uids = append(uids, uid)
+		}
+
+		act.binds.Delete(key)
+
+		return true
+	})
+
+	if len(uids) > 0 {
+		s.sleepers[act.PID()] = uids
+	}
+
+	s.rw.Unlock()
+
+	s.actors.Delete(act.PID())
+
+	s.count.Add(-1)
+
+	act.dormant.Store(true)
+
+	return true
+}
+
+// 激活Actor，通过已添加的工厂衍生Actor并恢复钝化前的用户绑定关系
+func (s *Scheduler) activate(kind, id string) (*Actor, error) {
+	v, ok := s.factories.Load(kind)
+	if !ok {
+		return nil, errors.ErrNotFoundActor
+	}
+
+	f := v.(*factory)
+	opts := make([]ActorOption, 0, len(f.opts)+2)
+	opts = append(opts, f.opts...)
+	opts = append(opts, WithActorKind(kind), WithActorID(id))
+
+	act, err := s.spawn(f.creator, opts...)
+	if err != nil {
+		if errors.Is(err, errors.ErrActorExists) {
+			if act, ok = s.load(kind, id); ok {
+				return act, nil
+			}
+		}
+
+		return nil, err
+	}
+
+	for _, uid := range s.wake(act.PID()) {
+		if err = s.bindActor(uid, kind, id); err != nil {
+			log.Warnf("restore actor binding failed, uid: %d pid: %s err: %v", uid, act.PID(), err)
+		}
+	}
+
+	log.Debugf("actor activated, pid: %s", act.PID())
+
+	return act, nil
+}
+
+// 获取Actor，Actor未加载时通过工厂激活
+func (s *Scheduler) obtain(kind, id string) (*Actor, error) {
+	if act, ok := s.load(kind, id); ok {
+		return act, nil
+	}
+
+	return s.activate(kind, id)
+}
+
+// 移除Actor的休眠记录，返回钝化前绑定的用户
+func (s *Scheduler) wake(pid string) []int64 {
+	s.rw.Lock()
+	defer s.rw.Unlock()
+
+	uids, ok := s.sleepers[pid]
+	if !ok {
+		return nil
+	}
+
+	delete(s.sleepers, pid)
+
+	kind, id, _ := strings.Cut(pid, "/")
+
+	woken := uids[:0]
+	for _, uid := range uids {
+		if dormants, ok := s.dormants[uid]; ok && dormants[kind] == id {
+			delete(dormants, kind)
+
+			if len(dormants) == 0 {
+				delete(s.dormants, uid)
+			}
+
+			woken = append(woken, uid)
+		}
+	}
+
+	return woken
+}
+
+// 获取用户绑定的已钝化Actor的编号
+func (s *Scheduler) loadDormant(uid int64, kind string) (string, bool) {
+	s.rw.RLock()
+	defer s.rw.RUnlock()
+
+	id, ok := s.dormants[uid][kind]
+
+	return id, ok
+}
+
+// 移除用户与已钝化Actor的绑定关系，调用方需持有写锁
+func (s *Scheduler) unbindDormant(uid int64, kind string) {
+	dormants, ok := s.dormants[uid]
+	if !ok {
+		return
+	}
+
+	id, ok := dormants[kind]
+	if !ok {
+		return
+	}
+
+	delete(dormants, kind)
+
+	if len(dormants) == 0 {
+		delete(s.dormants, uid)
+	}
+
+	pid := kind + "/" + id
+	uids := s.sleepers[pid]
+	for i, v := range uids {
+		if v == uid {
+			uids = append(uids[:i], uids[i+1:]...)
+			break
+		}
+	}
+
+	if len(uids) == 0 {
+		delete(s.sleepers, pid)
+	} else {
+		s.sleepers[pid] = uids
+	}
+}
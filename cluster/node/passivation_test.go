@@ -0,0 +1,189 @@
+package node
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 投递给用户绑定Actor的请求
+type routeContext struct {
+	testContext
+	uid   int64
+	route int32
+}
+
+func (c *routeContext) UID() int64 { return c.uid }
+
+func (c *routeContext) Route() int32 { return c.route }
+
+func (c *routeContext) Kind() Kind { return Request }
+
+func (c *routeContext) compareVersionExecDefer(_ int32) {}
+
+// 记录钝化及请求的处理器
+type passiveProcessor struct {
+	BaseProcessor
+	actor       *Actor
+	passivated  chan string
+	handled     chan string
+	initialized chan string
+}
+
+func (p *passiveProcessor) Init() {
+	p.actor.AddRouteHandler(1, func(ctx Context) { p.handled <- p.actor.PID() })
+	p.initialized <- p.actor.PID()
+}
+
+func (p *passiveProcessor) OnPassivate() { p.passivated <- p.actor.PID() }
+
+// 新建带时间轮的调度器，空闲检测由节点时间轮调度
+func newIdleScheduler(t *testing.T) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	return newScheduler(&Node{wheel: newTimingWheel(ctx, time.Millisecond)})
+}
+
+func TestScheduler_PassivateAndActivate(t *testing.T) {
+	s := newIdleScheduler(t)
+
+	passivated := make(chan string, 10)
+	handled := make(chan string, 10)
+	initialized := make(chan string, 10)
+
+	s.addFactory("player", func(actor *Actor, _ ...any) Processor {
+		return &passiveProcessor{actor: actor, passivated: passivated, handled: handled, initialized: initialized}
+	}, WithActorNonWait(), WithActorIdleTimeout(20*time.Millisecond))
+
+	if err := s.bindActor(1, "player", "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if pid := <-initialized; pid != "player/1" {
+		t.Fatalf("unexpected activated actor: %s", pid)
+	}
+
+	select {
+	case pid := <-passivated:
+		if pid != "player/1" {
+			t.Fatalf("unexpected passivated actor: %s", pid)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("passivate timeout")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok := s.load("player", "1"); !ok {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("actor is not removed")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	if id, ok := s.loadDormant(1, "player"); !ok || id != "1" {
+		t.Fatal("binding is not kept after passivation")
+	}
+
+	if err := s.dispatchRequest(&routeContext{uid: 1, route: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case pid := <-handled:
+		if pid != "player/1" {
+			t.Fatalf("unexpected handled actor: %s", pid)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handle timeout")
+	}
+
+	if act, ok := s.loadActor(1, "player"); !ok || act.ID() != "1" {
+		t.Fatal("binding is not restored after activation")
+	}
+
+	if _, ok := s.loadDormant(1, "player"); ok {
+		t.Fatal("dormant binding is not cleared after activation")
+	}
+}
+
+func TestScheduler_UnbindDormant(t *testing.T) {
+	s := newScheduler(nil)
+
+	s.rw.Lock()
+	s.dormants[1] = map[string]string{"player": "1"}
+	s.dormants[2] = map[string]string{"player": "1"}
+	s.sleepers["player/1"] = []int64{1, 2}
+	s.rw.Unlock()
+
+	s.unbindActor(1, "player")
+
+	if _, ok := s.loadDormant(1, "player"); ok {
+		t.Fatal("dormant binding is not removed")
+	}
+
+	if uids := s.wake("player/1"); len(uids) != 1 || uids[0] != 2 {
+		t.Fatalf("unexpected woken users: %v", uids)
+	}
+
+	if _, err := s.obtain("player", "1"); err == nil {
+		t.Fatal("expected activation failure without factory")
+	}
+}
+
+func TestScheduler_PassivateConcurrentDelivery(t *testing.T) {
+	s := newIdleScheduler(t)
+
+	const senders, count = 4, 50
+
+	passivated := make(chan string, senders*count)
+	handled := make(chan string, senders*count)
+	initialized := make(chan string, senders*count)
+
+	s.addFactory("player", func(actor *Actor, _ ...any) Processor {
+		return &passiveProcessor{actor: actor, passivated: passivated, handled: handled, initialized: initialized}
+	}, WithActorNonWait(), WithActorIdleTimeout(time.Millisecond))
+
+	act, err := s.obtain("player", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 持有旧Actor的投递方与钝化并发进行，钝化后到达的消息不应丢失
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < count; j++ {
+				if err := act.Next(&routeContext{uid: 1, route: 1}); err != nil {
+					t.Error(err)
+					return
+				}
+
+				time.Sleep(time.Duration(j%3) * time.Millisecond)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	for i := 0; i < senders*count; i++ {
+		select {
+		case <-handled:
+		case <-time.After(time.Second):
+			t.Fatalf("message lost, handled: %d expected: %d", i, senders*count)
+		}
+	}
+
+	if len(passivated) == 0 {
+		t.Fatal("actor is never passivated")
+	}
+}
@@ -19,3 +19,9 @@ func (b *BaseProcessor) Start() {}
 
 // Destroy 销毁回调
 func (b *BaseProcessor) Destroy() {}
+
+// OnRestart 重启回调
+func (b *BaseProcessor) OnRestart(reason any) {}
+
+// OnPassivate 钝化回调
+func (b *BaseProcessor) OnPassivate() {}
@@ -53,6 +53,29 @@ func (p *provider) Deliver(ctx context.Context, gid, nid string, cid, uid int64,
 	return nil
 }
 
+// Receive 接收其他节点发送的Actor消息
+func (p *provider) Receive(ctx context.Context, nid, sender, target string, message []byte, reply func(data []byte, err error)) error {
+	codec := p.node.opts.codec
+
+	envelope := &Envelope{sender: sender, message: message, codec: codec}
+
+	if reply != nil {
+		envelope.respond = func(v any, err error) {
+			if err != nil {
+				reply(nil, err)
+				return
+			}
+
+			data, err := codec.Marshal(v)
+			reply(data, err)
+		}
+	}
+
+	kind, id, _ := ParsePID(target)
+
+	return p.node.scheduler.receive(kind, id, envelope)
+}
+
 // GetState 获取状态
 func (p *provider) GetState() (cluster.State, error) {
 	return p.node.getState(), nil
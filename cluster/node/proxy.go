@@ -9,6 +9,7 @@ import (
 	"gatesvr/session"
 	"gatesvr/transport"
 	"gatesvr/utils/xcall"
+	"gatesvr/utils/xcron"
 
 	"time"
 )
@@ -21,12 +22,13 @@ type Proxy struct {
 
 func newProxy(node *Node) *Proxy {
 	opts := &link.Options{
-		InsID:     node.opts.id,
-		InsKind:   cluster.Node,
-		Codec:     node.opts.codec,
-		Locator:   node.opts.locator,
-		Registry:  node.opts.registry,
-		Encryptor: node.opts.encryptor,
+		InsID:           node.opts.id,
+		InsKind:         cluster.Node,
+		Codec:           node.opts.codec,
+		Locator:         node.opts.locator,
+		Registry:        node.opts.registry,
+		Encryptor:       node.opts.encryptor,
+		BalanceStrategy: node.opts.balanceStrategy,
 	}
 
 	return &Proxy{
@@ -262,6 +264,21 @@ func (p *Proxy) Multicast(ctx context.Context, args *cluster.MulticastArgs) erro
 	return p.gateLinker.Multicast(ctx, args)
 }
 
+// JoinGroup 加入网关分组，连接断开时自动退出
+func (p *Proxy) JoinGroup(ctx context.Context, args *cluster.GroupArgs) error {
+	return p.gateLinker.JoinGroup(ctx, args)
+}
+
+// LeaveGroup 退出网关分组
+func (p *Proxy) LeaveGroup(ctx context.Context, args *cluster.GroupArgs) error {
+	return p.gateLinker.LeaveGroup(ctx, args)
+}
+
+// MulticastGroup 推送分组消息，由各网关推送至本地分组成员
+func (p *Proxy) MulticastGroup(ctx context.Context, args *cluster.MulticastGroupArgs) error {
+	return p.gateLinker.MulticastGroup(ctx, args)
+}
+
 // Broadcast 推送广播消息
 func (p *Proxy) Broadcast(ctx context.Context, args *cluster.BroadcastArgs) error {
 	return p.gateLinker.Broadcast(ctx, args)
@@ -291,24 +308,55 @@ func (p *Proxy) Invoke(fn func()) {
 func (p *Proxy) AfterFunc(d time.Duration, f func()) *Timer {
 	p.node.addWait()
 
-	timer := time.AfterFunc(d, func() {
-		xcall.Call(f)
+	task := p.node.wheel.afterFunc(d, func() bool {
+		go func() {
+			xcall.Call(f)
 
-		p.node.doneWait()
+			p.node.doneWait()
+		}()
+
+		return true
 	})
 
-	return &Timer{node: p.node, timer: timer}
+	return &Timer{node: p.node, wheel: p.node.wheel, task: task}
 }
 
 // AfterInvoke 延迟调用（线程安全）
 func (p *Proxy) AfterInvoke(d time.Duration, f func()) *Timer {
 	p.node.addWait()
 
-	timer := time.AfterFunc(d, func() {
-		p.node.fnChan <- f
+	task := p.node.wheel.afterFunc(d, func() bool {
+		return p.node.deliver(f)
+	})
+
+	return &Timer{node: p.node, wheel: p.node.wheel, task: task}
+}
+
+// TickInvoke 按固定间隔重复调用（线程安全），回调在节点分发协程中执行，直至定时器停止或节点销毁
+func (p *Proxy) TickInvoke(d time.Duration, f func()) *Timer {
+	task := p.node.wheel.tickFunc(d, func() bool {
+		p.node.addWait()
+
+		return p.node.deliver(f)
+	})
+
+	return &Timer{wheel: p.node.wheel, task: task}
+}
+
+// CronInvoke 按cron表达式重复调用（线程安全），回调在节点分发协程中执行，表达式格式参见xcron.Parse
+func (p *Proxy) CronInvoke(spec string, f func()) (*Timer, error) {
+	schedule, err := xcron.Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	task := p.node.wheel.cronFunc(schedule, func() bool {
+		p.node.addWait()
+
+		return p.node.deliver(f)
 	})
 
-	return &Timer{node: p.node, timer: timer}
+	return &Timer{wheel: p.node.wheel, task: task}, nil
 }
 
 // Spawn 衍生出一个新的Actor
@@ -316,6 +364,29 @@ func (p *Proxy) Spawn(creator Creator, opts ...ActorOption) (*Actor, error) {
 	return p.node.scheduler.spawn(creator, opts...)
 }
 
+// AddActorFactory 添加Actor工厂，投递到未加载的Actor的消息将通过工厂按需激活Actor
+func (p *Proxy) AddActorFactory(kind string, creator Creator, opts ...ActorOption) {
+	p.node.scheduler.addFactory(kind, creator, opts...)
+}
+
+// SpawnSingleton 衍生集群单例Actor，同一kind/id的Actor在集群中至多运行于一个节点
+// 各节点通过锁制造商竞选，仅当选的节点衍生Actor；领导者宕机或关闭后，其他节点在租约到期后接管并重新衍生
+// 单例Actor注册到Actor目录，可通过kind/id寻址，Actor.Fence可获取当选时的防护令牌
+func (p *Proxy) SpawnSingleton(creator Creator, opts ...ActorOption) error {
+	return p.node.scheduler.spawnSingleton(creator, opts...)
+}
+
+// AddCronJob 添加集群定时任务，集群中的各节点添加同名任务后，每次计划时间仅由其中一个节点执行
+// 处理器通过Invoke在节点分发协程中执行；节点启动时按执行记录补偿停机期间错过的执行，补偿次数可通过WithCronCatchUp设置
+func (p *Proxy) AddCronJob(name, spec string, handler CronHandler, opts ...CronOption) error {
+	return p.node.crontab.add(name, spec, handler, opts...)
+}
+
+// RemoveCronJob 移除集群定时任务，仅停止当前节点的调度
+func (p *Proxy) RemoveCronJob(name string) bool {
+	return p.node.crontab.remove(name)
+}
+
 // Kill 杀死存在的一个Actor
 func (p *Proxy) Kill(kind, id string) bool {
 	return p.node.scheduler.kill(kind, id)
@@ -0,0 +1,154 @@
+package node
+
+import (
+	"context"
+	"gatesvr/errors"
+	"gatesvr/locate"
+	"gatesvr/log"
+	"strings"
+)
+
+const pidNodeSeparator = "@" // PID中节点ID的分隔符
+
+// NewPID 构建Actor的唯一识别ID，格式为kind/id@nid，未指定节点ID时为本地PID
+func NewPID(kind, id string, nid ...string) string {
+	if len(nid) > 0 && nid[0] != "" {
+		return kind + "/" + id + pidNodeSeparator + nid[0]
+	}
+
+	return kind + "/" + id
+}
+
+// ParsePID 解析Actor的唯一识别ID，本地PID的节点ID为空
+func ParsePID(pid string) (kind, id, nid string) {
+	if i := strings.LastIndex(pid, pidNodeSeparator); i >= 0 {
+		pid, nid = pid[:i], pid[i+1:]
+	}
+
+	kind, id, _ = strings.Cut(pid, "/")
+
+	return
+}
+
+// GlobalPID 获取包含节点ID的全局唯一识别ID，其他节点上的Actor可通过该ID向当前Actor发送消息
+func (a *Actor) GlobalPID() string {
+	return NewPID(a.Kind(), a.ID(), a.scheduler.node.opts.id)
+}
+
+// 获取Actor定位器，定位器未实现ActorLocator时返回false
+func (s *Scheduler) actorLocator() (locate.ActorLocator, bool) {
+	if s.node == nil || s.node.opts.locator == nil {
+		return nil, false
+	}
+
+	locator, ok := s.node.opts.locator.(locate.ActorLocator)
+
+	return locator, ok
+}
+
+// 注册全局Actor到目录
+func (s *Scheduler) register(act *Actor) {
+	locator, ok := s.actorLocator()
+	if !ok {
+		log.Warnf("locator does not support actor location, pid: %s", act.PID())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(s.node.ctx, s.node.opts.timeout)
+	defer cancel()
+
+	if err := locator.BindActor(ctx, act.Kind(), act.ID(), s.node.opts.id); err != nil {
+		log.Errorf("register actor failed, pid: %s err: %v", act.PID(), err)
+	}
+}
+
+// 从目录中注销全局Actor
+func (s *Scheduler) deregister(act *Actor) {
+	locator, ok := s.actorLocator()
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(s.node.ctx, s.node.opts.timeout)
+	defer cancel()
+
+	if err := locator.UnbindActor(ctx, act.Kind(), act.ID(), s.node.opts.id); err != nil {
+		log.Errorf("deregister actor failed, pid: %s err: %v", act.PID(), err)
+	}
+}
+
+// 解析PID，返回Actor所在的节点ID，Actor位于当前节点或无法定位时返回空
+// 未指定节点ID且未在当前节点加载的Actor将通过目录定位
+func (s *Scheduler) resolve(pid string) (kind, id, nid string) {
+	kind, id, nid = ParsePID(pid)
+
+	if nid == "" {
+		if _, ok := s.load(kind, id); ok {
+			return
+		}
+
+		if locator, ok := s.actorLocator(); ok {
+			ctx, cancel := context.WithTimeout(s.node.ctx, s.node.opts.timeout)
+			defer cancel()
+
+			var err error
+			if nid, err = locator.LocateActor(ctx, kind, id); err != nil {
+				log.Warnf("locate actor failed, pid: %s err: %v", pid, err)
+			}
+		}
+	}
+
+	if s.node != nil && nid == s.node.opts.id {
+		nid = ""
+	}
+
+	return
+}
+
+// 投递消息到当前节点上的Actor，Actor未加载时通过工厂激活
+func (s *Scheduler) receive(kind, id string, envelope *Envelope) error {
+	act, err := s.obtain(kind, id)
+	if err != nil {
+		return err
+	}
+
+	if !act.post(func() { act.receive(envelope) }) {
+		return errors.ErrNotFoundActor
+	}
+
+	return nil
+}
+
+// 发送单向消息到其他节点上的Actor
+func (a *Actor) tellRemote(nid, target string, message any) error {
+	buf, err := a.scheduler.node.opts.codec.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(a.scheduler.node.ctx, a.scheduler.node.opts.timeout)
+	defer cancel()
+
+	return a.scheduler.node.proxy.nodeLinker.TellActor(ctx, nid, a.GlobalPID(), target, buf)
+}
+
+// 发送请求到其他节点上的Actor，回复到达后完成Future
+func (a *Actor) askRemote(ctx context.Context, nid, target string, message any, f *Future) {
+	codec := a.scheduler.node.opts.codec
+
+	buf, err := codec.Marshal(message)
+	if err != nil {
+		f.resolve(nil, err)
+		return
+	}
+
+	go func() {
+		reply, err := a.scheduler.node.proxy.nodeLinker.AskActor(ctx, nid, a.GlobalPID(), target, buf)
+		if err != nil {
+			f.resolve(nil, err)
+			return
+		}
+
+		f.resolveRemote(reply, codec)
+	}()
+}
@@ -0,0 +1,117 @@
+package node
+
+import (
+	"context"
+	"gatesvr/cluster"
+	"gatesvr/encoding/json"
+	"gatesvr/internal/transporter/node"
+	"gatesvr/locate/memory"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestParsePID(t *testing.T) {
+	for _, c := range []struct {
+		pid           string
+		kind, id, nid string
+	}{
+		{pid: NewPID("player", "1"), kind: "player", id: "1"},
+		{pid: NewPID("player", "1", "node-1"), kind: "player", id: "1", nid: "node-1"},
+		{pid: "guild/a@b@node-2", kind: "guild", id: "a@b", nid: "node-2"},
+	} {
+		kind, id, nid := ParsePID(c.pid)
+		if kind != c.kind || id != c.id || nid != c.nid {
+			t.Fatalf("unexpected parse result of %s: %s %s %s", c.pid, kind, id, nid)
+		}
+	}
+}
+
+func TestScheduler_Resolve(t *testing.T) {
+	locator := memory.NewLocator()
+	n := NewNode(WithID("node-1"), WithLocator(locator), WithCodec(json.DefaultCodec))
+
+	act, err := n.scheduler.spawn(func(actor *Actor, _ ...any) Processor { return &BaseProcessor{} },
+		WithActorKind("guild"), WithActorID("1"), WithActorNonWait(), WithActorGlobal())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if nid, _ := locator.LocateActor(context.Background(), "guild", "1"); nid != "node-1" {
+		t.Fatalf("global actor is not registered: %q", nid)
+	}
+
+	if err = locator.BindActor(context.Background(), "guild", "2", "node-2"); err != nil {
+		t.Fatal(err)
+	}
+
+	for pid, expect := range map[string]string{
+		"guild/1":        "",
+		"guild/1@node-1": "",
+		"guild/2":        "node-2",
+		"guild/3@node-3": "node-3",
+		"guild/3":        "",
+	} {
+		if _, _, nid := n.scheduler.resolve(pid); nid != expect {
+			t.Fatalf("unexpected node of %s: %q", pid, nid)
+		}
+	}
+
+	n.scheduler.kill(act.Kind(), act.ID())
+
+	if nid, _ := locator.LocateActor(context.Background(), "guild", "1"); nid != "" {
+		t.Fatalf("global actor is not deregistered: %q", nid)
+	}
+}
+
+func TestProvider_Receive(t *testing.T) {
+	n := NewNode(WithID("node-2"), WithCodec(json.DefaultCodec))
+
+	told := make(chan any, 1)
+	if _, err := n.scheduler.spawn(func(actor *Actor, _ ...any) Processor { return &echoProcessor{told: told} },
+		WithActorKind("player"), WithActorID("1"), WithActorNonWait()); err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	server, err := node.NewServer(addr, &provider{node: n})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Start()
+	defer server.Stop()
+
+	time.Sleep(50 * time.Millisecond)
+
+	client, err := node.NewBuilder(&node.Options{InsID: "node-1", InsKind: cluster.Node}).Build(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	message, _ := json.Marshal("hello")
+
+	reply, err := client.Ask(ctx, "room/1@node-1", "player/1", message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := newFuture(nil)
+	f.resolveRemote(reply, json.DefaultCodec)
+
+	if v, err := Await[string](f); err != nil || v != "hello" {
+		t.Fatalf("unexpected reply: %q %v", v, err)
+	}
+
+	if _, err = client.Ask(ctx, "room/1@node-1", "player/2", message); err == nil {
+		t.Fatal("expected error for missing actor")
+	}
+}
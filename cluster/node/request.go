@@ -16,6 +16,7 @@ import (
 	"gatesvr/transport"
 	"gatesvr/utils/task"
 	"gatesvr/utils/xcall"
+	"strings"
 
 	"github.com/jinzhu/copier"
 	"sync/atomic"
@@ -34,6 +35,7 @@ type request struct {
 	version atomic.Int32     // 版本号
 	chain   *chains.Chain    // 调用链
 	actor   atomic.Value     // 当前Actor
+	start   time.Time        // 路由开始处理时间，为零值时不统计处理耗时
 }
 
 // GID 获取网关ID
@@ -344,7 +346,9 @@ func (r *request) Reply(message *cluster.Message) error {
 			Message: message,
 		})
 	case r.pid != "": // 来源于Actor
-		if actor, ok := r.node.scheduler.doLoad(r.pid); ok {
+		kind, id, _ := strings.Cut(r.pid, "/")
+
+		if actor, err := r.node.scheduler.obtain(kind, id); err == nil {
 			return actor.Deliver(r.uid, message)
 		}
 
@@ -411,9 +415,19 @@ func (r *request) loadVersion() int32 {
 	return r.version.Load()
 }
 
+// 回退版本号
+func (r *request) rollbackVersion(version int32) bool {
+	return r.version.CompareAndSwap(version, version-1)
+}
+
 // 比对版本号后进行回收对象
 func (r *request) compareVersionRecycle(version int32) {
 	if r.version.CompareAndSwap(version, 0) {
+		// 请求处理完成（包括投递到Actor中的处理）后统计耗时
+		if !r.start.IsZero() {
+			r.node.router.observe(r.start)
+		}
+
 		if r.node.router.postRouteHandler != nil {
 			xcall.Call(func() { r.node.router.postRouteHandler(r) })
 		}
@@ -426,6 +440,7 @@ func (r *request) compareVersionRecycle(version int32) {
 // 重置请求对象
 func (r *request) reset() {
 	r.message.Data = nil
+	r.start = time.Time{}
 
 	r.actor.Store((*Actor)(nil))
 
@@ -4,6 +4,8 @@ import (
 	"gatesvr/cluster"
 	"gatesvr/log"
 	"gatesvr/utils/xcall"
+	"sync/atomic"
+	"time"
 )
 
 type RouteHandler func(ctx Context)
@@ -15,6 +17,7 @@ type Router struct {
 	preRouteHandler     RouteHandler
 	postRouteHandler    RouteHandler
 	defaultRouteHandler RouteHandler
+	latency             atomic.Int64 // 请求处理耗时的指数移动平均值
 }
 
 type routeEntity struct {
@@ -164,6 +167,8 @@ func (r *Router) close() {
 }
 
 func (r *Router) handle(req *request) {
+	req.start = time.Now()
+
 	version := req.incrVersion()
 
 	route, ok := r.routes[req.message.Route]
@@ -194,6 +199,23 @@ func (r *Router) handle(req *request) {
 	req.compareVersionRecycle(version)
 }
 
+// 待处理请求数
+func (r *Router) pending() int {
+	return len(r.reqChan)
+}
+
+// 记录请求处理耗时，按1/8的权重平滑，请求可能在多个Actor协程中并发完成
+func (r *Router) observe(start time.Time) {
+	elapsed := int64(time.Since(start))
+
+	for {
+		latency := r.latency.Load()
+		if r.latency.CompareAndSwap(latency, latency+(elapsed-latency)/8) {
+			return
+		}
+	}
+}
+
 type RouterGroup struct {
 	router      *Router
 	middlewares []MiddlewareHandler
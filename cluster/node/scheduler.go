@@ -4,23 +4,32 @@ import (
 	"gatesvr/cluster"
 	"gatesvr/errors"
 	"gatesvr/log"
+	"gatesvr/utils/xcall"
 	"sync"
+	"sync/atomic"
 )
 
 type Scheduler struct {
-	node      *Node
-	mu        sync.Mutex
-	actors    sync.Map
-	routes    sync.Map
-	kinds     sync.Map
-	rw        sync.RWMutex
-	relations map[int64]map[string]*Actor
+	node       *Node
+	mu         sync.Mutex
+	actors     sync.Map
+	routes     sync.Map
+	kinds      sync.Map
+	count      atomic.Int64
+	rw         sync.RWMutex
+	relations  map[int64]map[string]*Actor
+	dormants   map[int64]map[string]string // 用户绑定的已钝化Actor（uid -> kind -> id）
+	sleepers   map[string][]int64          // 已钝化Actor钝化前绑定的用户（pid -> uids）
+	factories  sync.Map                    // Actor工厂（kind -> *factory）
+	singletons sync.Map                    // 集群单例Actor的选主（pid -> *lock.Election）
 }
 
 func newScheduler(node *Node) *Scheduler {
 	return &Scheduler{
 		node:      node,
 		relations: make(map[int64]map[string]*Actor),
+		dormants:  make(map[int64]map[string]string),
+		sleepers:  make(map[string][]int64),
 	}
 }
 
@@ -37,8 +46,10 @@ func (s *Scheduler) spawn(creator Creator, opts ...ActorOption) (*Actor, error)
 	act.state.Store(started)
 	act.routes = make(map[int32]RouteHandler)
 	act.events = make(map[cluster.Event]EventHandler, 3)
-	act.mailbox = make(chan Context, 4096)
+	act.mailbox = make(chan Context, max(o.mailboxSize, 1))
 	act.fnChan = make(chan func(), 4096)
+	act.fnBacklog = newBacklog(4096, func(fn func()) { act.post(fn) })
+	act.creator = creator
 	act.processor = creator(act, o.args...)
 
 	s.mu.Lock()
@@ -66,10 +77,25 @@ func (s *Scheduler) spawn(creator Creator, opts ...ActorOption) (*Actor, error)
 
 	s.actors.Store(act.PID(), act)
 
+	s.count.Add(1)
+
+	if o.parent != nil {
+		o.parent.children.Store(act.PID(), act)
+	}
+
 	s.mu.Unlock()
 
+	// 同步执行处理器在初始化时注册的路由及事件处理器，确保激活后投递的首条消息能被处理
+	xcall.Call(act.flush)
+
 	go act.dispatch()
 
+	act.startIdleCheck()
+
+	if o.global {
+		s.register(act)
+	}
+
 	act.processor.Start()
 
 	return act, nil
@@ -79,10 +105,16 @@ func (s *Scheduler) spawn(creator Creator, opts ...ActorOption) (*Actor, error)
 func (s *Scheduler) kill(kind, id string) bool {
 	act, ok := s.remove(kind, id)
 	if !ok {
+		s.wake(kind + "/" + id)
 		return false
 	}
 
-	ok = act.destroy()
+	return s.finish(act)
+}
+
+// 销毁已移除的Actor
+func (s *Scheduler) finish(act *Actor) bool {
+	ok := act.destroy()
 
 	if act.opts.wait {
 		s.node.doneWait()
@@ -103,6 +135,8 @@ func (s *Scheduler) remove(kind, id string) (*Actor, bool) {
 
 	s.actors.Delete(act.PID())
 
+	s.count.Add(-1)
+
 	for _, relations := range s.relations {
 		if a, ok := relations[act.Kind()]; ok && a == act {
 			delete(relations, act.Kind())
@@ -132,9 +166,9 @@ func (s *Scheduler) bindActor(uid int64, kind, id string) error {
 		return errors.ErrIllegalOperation
 	}
 
-	act, ok := s.load(kind, id)
-	if !ok {
-		return errors.ErrNotFoundActor
+	act, err := s.obtain(kind, id)
+	if err != nil {
+		return err
 	}
 
 	act.bindUser(uid)
@@ -150,6 +184,8 @@ func (s *Scheduler) bindActor(uid int64, kind, id string) error {
 
 	relations[act.Kind()] = act
 
+	s.unbindDormant(uid, act.Kind())
+
 	return nil
 }
 
@@ -160,11 +196,13 @@ func (s *Scheduler) unbindActor(uid int64, kind string) {
 
 	relations, ok := s.relations[uid]
 	if !ok {
+		s.unbindDormant(uid, kind)
 		return
 	}
 
 	act, ok := relations[kind]
 	if !ok {
+		s.unbindDormant(uid, kind)
 		return
 	}
 
@@ -196,6 +234,22 @@ func (s *Scheduler) loadActor(uid int64, kind string) (*Actor, bool) {
 	return nil, false
 }
 
+// 激活用户绑定的已钝化Actor
+func (s *Scheduler) reactivate(uid int64, kind string) (*Actor, bool) {
+	id, ok := s.loadDormant(uid, kind)
+	if !ok {
+		return nil, false
+	}
+
+	act, err := s.activate(kind, id)
+	if err != nil {
+		log.Errorf("activate actor failed, uid = %v kind = %v id = %v err = %v", uid, kind, id, err)
+		return nil, false
+	}
+
+	return act, true
+}
+
 // 分发消息
 func (s *Scheduler) dispatch(ctx Context) error {
 	if ctx.Kind() == Request {
@@ -219,14 +273,16 @@ func (s *Scheduler) dispatchRequest(ctx Context) error {
 	}
 
 	act, ok := s.loadActor(uid, kind.(string))
+	if !ok {
+		act, ok = s.reactivate(uid, kind.(string))
+	}
+
 	if !ok {
 		log.Errorf("dispatch request failed, uid = %v route = %v kind = %v", uid, ctx.Route(), kind)
 		return errors.ErrNotBindActor
 	}
 
-	act.Next(ctx)
-
-	return nil
+	return act.Next(ctx)
 }
 
 // 分发事件
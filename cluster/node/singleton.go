@@ -0,0 +1,70 @@
+package node
+
+import (
+	"gatesvr/errors"
+	"gatesvr/log"
+	"gatesvr/utils/lock"
+)
+
+const singletonElectionPrefix = "singleton:" // 集群单例Actor选主名称前缀
+
+// 衍生集群单例Actor，节点参与该Actor的选主，仅在当选时衍生Actor，卸任时杀死Actor
+// 单例Actor注册到Actor目录，其他节点可通过kind/id向其发送消息
+func (s *Scheduler) spawnSingleton(creator Creator, opts ...ActorOption) error {
+	o := defaultActorOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	maker := s.node.opts.lockMaker
+	if maker == nil {
+		maker = lock.GetMaker()
+	}
+
+	pid := NewPID(o.kind, o.id)
+
+	election, err := lock.NewElection(maker, singletonElectionPrefix+pid,
+		lock.WithElectionTTL(s.node.opts.singletonTTL),
+		lock.WithElectionOnElected(func(fence int64) {
+			s.elected(creator, opts, fence)
+		}),
+		lock.WithElectionOnRevoked(func() {
+			s.kill(o.kind, o.id)
+		}),
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, loaded := s.singletons.LoadOrStore(pid, election); loaded {
+		return errors.ErrActorExists
+	}
+
+	election.Start()
+
+	return nil
+}
+
+// 当选后衍生单例Actor，单例Actor不会因空闲而钝化
+func (s *Scheduler) elected(creator Creator, opts []ActorOption, fence int64) {
+	options := make([]ActorOption, 0, len(opts)+3)
+	options = append(options, opts...)
+	options = append(options, WithActorGlobal(), WithActorIdleTimeout(0), withActorFence(fence))
+
+	act, err := s.spawn(creator, options...)
+	if err != nil {
+		log.Errorf("spawn singleton actor failed, fence: %d err: %v", fence, err)
+		return
+	}
+
+	log.Infof("singleton actor spawned, pid: %s fence: %d", act.PID(), fence)
+}
+
+// 停止所有集群单例Actor的选主，当选的单例Actor随之被杀死并释放锁，以便其他节点尽快接管
+func (s *Scheduler) resign() {
+	s.singletons.Range(func(key, value any) bool {
+		value.(*lock.Election).Stop()
+		s.singletons.Delete(key)
+		return true
+	})
+}
@@ -0,0 +1,91 @@
+package node
+
+import (
+	"context"
+	"gatesvr/encoding/json"
+	"gatesvr/errors"
+	"gatesvr/locate/memory"
+	lockmemory "gatesvr/utils/lock/memory"
+	"testing"
+	"time"
+)
+
+func TestScheduler_SpawnSingleton(t *testing.T) {
+	var (
+		locator = memory.NewLocator()
+		maker   = lockmemory.NewMaker()
+		creator = func(actor *Actor, _ ...any) Processor { return &BaseProcessor{} }
+		nodes   = make([]*Node, 0, 2)
+	)
+
+	for _, id := range []string{"node-1", "node-2"} {
+		n := NewNode(WithID(id), WithLocator(locator), WithCodec(json.DefaultCodec),
+			WithLockMaker(maker), WithSingletonTTL(90*time.Millisecond))
+
+		if err := n.proxy.SpawnSingleton(creator, WithActorKind("boss"), WithActorID("world"), WithActorNonWait()); err != nil {
+			t.Fatal(err)
+		}
+
+		nodes = append(nodes, n)
+	}
+
+	if err := nodes[0].proxy.SpawnSingleton(creator, WithActorKind("boss"), WithActorID("world")); !errors.Is(err, errors.ErrActorExists) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	leader := waitSingleton(t, nodes, "boss", "world")
+	follower := nodes[1-leader]
+
+	time.Sleep(150 * time.Millisecond)
+
+	if _, ok := follower.scheduler.load("boss", "world"); ok {
+		t.Fatal("singleton actor is running on multiple nodes")
+	}
+
+	act, _ := nodes[leader].scheduler.load("boss", "world")
+	fence := act.Fence()
+
+	if nid, _ := locator.LocateActor(context.Background(), "boss", "world"); nid != nodes[leader].opts.id {
+		t.Fatalf("singleton actor is not registered: %q", nid)
+	}
+
+	nodes[leader].scheduler.resign()
+
+	if _, ok := nodes[leader].scheduler.load("boss", "world"); ok {
+		t.Fatal("singleton actor is not killed after resign")
+	}
+
+	if i := waitSingleton(t, nodes, "boss", "world"); i != 1-leader {
+		t.Fatal("singleton actor is not respawned on the new leader")
+	}
+
+	act, _ = follower.scheduler.load("boss", "world")
+	if act.Fence() <= fence {
+		t.Fatalf("fence is not increased, old: %d new: %d", fence, act.Fence())
+	}
+
+	if nid, _ := locator.LocateActor(context.Background(), "boss", "world"); nid != follower.opts.id {
+		t.Fatalf("singleton actor is not re-registered: %q", nid)
+	}
+
+	follower.scheduler.resign()
+}
+
+// 等待单例Actor在任一节点上衍生，返回所在节点的索引
+func waitSingleton(t *testing.T, nodes []*Node, kind, id string) int {
+	deadline := time.Now().Add(time.Second)
+
+	for time.Now().Before(deadline) {
+		for i, n := range nodes {
+			if _, ok := n.scheduler.load(kind, id); ok {
+				return i
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("singleton actor is not spawned")
+
+	return -1
+}
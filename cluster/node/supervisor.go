@@ -0,0 +1,187 @@
+package node
+
+import (
+	"gatesvr/log"
+	"gatesvr/utils/xcall"
+	"runtime"
+	"time"
+)
+
+const (
+	RestartStrategy  SupervisorStrategy = iota // 重启：销毁当前处理器并通过Creator重建
+	StopStrategy                               // 停止：杀死当前Actor及其子Actor
+	EscalateStrategy                           // 上报：杀死当前Actor并将异常交由父Actor的监督策略处理，无父Actor时停止
+)
+
+// SupervisorStrategy 监督策略
+type SupervisorStrategy int
+
+func (s SupervisorStrategy) String() string {
+	switch s {
+	case RestartStrategy:
+		return "restart"
+	case EscalateStrategy:
+		return "escalate"
+	default:
+		return "stop"
+	}
+}
+
+// Supervisor Actor监督者，处理器在处理消息时发生panic后按策略处理
+type Supervisor struct {
+	Strategy    SupervisorStrategy // 监督策略
+	MaxRestarts int                // 时间窗口内的最大重启次数，超出后停止Actor，0为不限制
+	Within      time.Duration      // 重启次数的统计时间窗口
+}
+
+// Restarter 处理器重启回调，处理器实现该接口时在重启后、Start前回调
+type Restarter interface {
+	// OnRestart 重启回调，reason为导致重启的panic
+	OnRestart(reason any)
+}
+
+// 安全地调用函数，发生panic时交由监督者处理，返回false时Actor已停止
+func (a *Actor) call(fn func()) (ok bool) {
+	defer func() {
+		if reason := recover(); reason != nil {
+			ok = a.recover(reason)
+		}
+	}()
+
+	fn()
+
+	return true
+}
+
+// 处理panic，返回false时Actor已停止
+func (a *Actor) recover(reason any) bool {
+	switch err := reason.(type) {
+	case runtime.Error:
+		log.Panic(err)
+	default:
+		log.Panicf("actor panic, pid: %s err: %v", a.PID(), err)
+	}
+
+	supervisor := a.opts.supervisor
+	if supervisor == nil {
+		return true
+	}
+
+	switch supervisor.Strategy {
+	case RestartStrategy:
+		if !a.allowRestart(supervisor) {
+			log.Warnf("actor restarts too frequently, stop it, pid: %s", a.PID())
+			a.stop()
+			return false
+		}
+
+		if !a.restart(reason) {
+			a.stop()
+			return false
+		}
+
+		return true
+	case EscalateStrategy:
+		a.stop()
+
+		if parent := a.opts.parent; parent != nil {
+			parent.Invoke(func() { panic(reason) })
+		}
+
+		return false
+	default:
+		a.stop()
+		return false
+	}
+}
+
+// 检测重启频率是否超出限制
+func (a *Actor) allowRestart(supervisor *Supervisor) bool {
+	if supervisor.MaxRestarts <= 0 {
+		return true
+	}
+
+	now := time.Now()
+	restarts := a.restarts[:0]
+	for _, t := range a.restarts {
+		if supervisor.Within <= 0 || now.Sub(t) < supervisor.Within {
+			restarts = append(restarts, t)
+		}
+	}
+	a.restarts = restarts
+
+	if len(a.restarts) >= supervisor.MaxRestarts {
+		return false
+	}
+
+	a.restarts = append(a.restarts, now)
+
+	return true
+}
+
+// 重启处理器，在Actor分发协程中执行，子Actor将随之停止
+func (a *Actor) restart(reason any) (ok bool) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("actor restart failed, pid: %s err: %v", a.PID(), err)
+			ok = false
+		}
+	}()
+
+	a.processor.Destroy()
+
+	a.killChildren()
+
+	clear(a.routes)
+
+	clear(a.events)
+
+	a.processor = a.creator(a, a.opts.args...)
+
+	a.processor.Init()
+
+	// 同步执行新处理器在初始化时注册的路由及事件处理器
+	a.flush()
+
+	if restarter, ok := a.processor.(Restarter); ok {
+		restarter.OnRestart(reason)
+	}
+
+	a.processor.Start()
+
+	log.Infof("actor restarted, pid: %s", a.PID())
+
+	return true
+}
+
+// 执行调用队列中积压的函数
+func (a *Actor) flush() {
+	for {
+		select {
+		case fn, ok := <-a.fnChan:
+			if !ok {
+				return
+			}
+
+			fn()
+		default:
+			return
+		}
+	}
+}
+
+// 停止Actor，销毁在独立协程中进行，分发协程继续消费邮箱直至关闭，避免投递方阻塞
+func (a *Actor) stop() {
+	a.stopped = true
+
+	xcall.Go(func() { a.scheduler.kill(a.Kind(), a.ID()) })
+}
+
+// 杀死所有子Actor
+func (a *Actor) killChildren() {
+	a.children.Range(func(_, child any) bool {
+		act := child.(*Actor)
+		a.scheduler.kill(act.Kind(), act.ID())
+		return true
+	})
+}
@@ -0,0 +1,156 @@
+package node
+
+import (
+	"testing"
+	"time"
+)
+
+// 记录生命周期回调的处理器
+type supervisedProcessor struct {
+	BaseProcessor
+	restarted chan any
+	destroyed chan string
+	actor     *Actor
+}
+
+func (p *supervisedProcessor) OnRestart(reason any) { p.restarted <- reason }
+
+func (p *supervisedProcessor) Destroy() { p.destroyed <- p.actor.PID() }
+
+type lifecycle struct {
+	restarted chan any
+	destroyed chan string
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{restarted: make(chan any, 10), destroyed: make(chan string, 10)}
+}
+
+func (l *lifecycle) creator(actor *Actor, _ ...any) Processor {
+	return &supervisedProcessor{restarted: l.restarted, destroyed: l.destroyed, actor: actor}
+}
+
+func (l *lifecycle) waitRestart(t *testing.T) any {
+	select {
+	case reason := <-l.restarted:
+		return reason
+	case <-time.After(time.Second):
+		t.Fatal("restart timeout")
+		return nil
+	}
+}
+
+func (l *lifecycle) waitDestroy(t *testing.T) string {
+	select {
+	case pid := <-l.destroyed:
+		return pid
+	case <-time.After(time.Second):
+		t.Fatal("destroy timeout")
+		return ""
+	}
+}
+
+func waitRemoved(t *testing.T, s *Scheduler, act *Actor) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if _, ok := s.load(act.Kind(), act.ID()); !ok {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatalf("actor %s is not removed", act.PID())
+}
+
+func TestSupervisor_Restart(t *testing.T) {
+	s := newScheduler(nil)
+	l := newLifecycle()
+
+	act, err := s.spawn(l.creator, WithActorKind("test"), WithActorNonWait(), WithActorSupervisor(&Supervisor{
+		Strategy:    RestartStrategy,
+		MaxRestarts: 2,
+		Within:      time.Minute,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		act.Invoke(func() { panic("boom") })
+
+		if reason := l.waitRestart(t); reason != "boom" {
+			t.Fatalf("unexpected restart reason: %v", reason)
+		}
+		l.waitDestroy(t)
+	}
+
+	act.Invoke(func() { panic("boom") })
+
+	l.waitDestroy(t)
+	waitRemoved(t, s, act)
+
+	if len(l.restarted) != 0 {
+		t.Fatal("unexpected restart after exceeding the limit")
+	}
+}
+
+func TestSupervisor_Escalate(t *testing.T) {
+	s := newScheduler(nil)
+	pl, cl := newLifecycle(), newLifecycle()
+
+	parent, err := s.spawn(pl.creator, WithActorKind("parent"), WithActorNonWait(), WithActorSupervisor(&Supervisor{
+		Strategy: RestartStrategy,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	child, err := parent.Spawn(cl.creator, WithActorKind("child"), WithActorNonWait(), WithActorSupervisor(&Supervisor{
+		Strategy: EscalateStrategy,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if child.Parent() != parent {
+		t.Fatal("unexpected parent")
+	}
+
+	child.Invoke(func() { panic("boom") })
+
+	if pid := cl.waitDestroy(t); pid != child.PID() {
+		t.Fatalf("unexpected destroyed actor: %s", pid)
+	}
+	waitRemoved(t, s, child)
+
+	if reason := pl.waitRestart(t); reason != "boom" {
+		t.Fatalf("unexpected restart reason: %v", reason)
+	}
+}
+
+func TestActor_KillChildren(t *testing.T) {
+	s := newScheduler(nil)
+	l := newLifecycle()
+
+	parent, err := s.spawn(l.creator, WithActorKind("parent"), WithActorNonWait())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	child, err := parent.Spawn(l.creator, WithActorKind("child"), WithActorNonWait())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !s.kill(parent.Kind(), parent.ID()) {
+		t.Fatal("kill parent failed")
+	}
+
+	if _, ok := s.load(child.Kind(), child.ID()); ok {
+		t.Fatal("child is not killed with parent")
+	}
+
+	if child.state.Load() != destroyed {
+		t.Fatal("child is not destroyed")
+	}
+}
@@ -3,19 +3,34 @@ package node
 import "time"
 
 type Timer struct {
-	node  *Node
-	timer *time.Timer
+	node  *Node // 一次性的节点定时器需维护节点的等待计数，Actor定时器及重复定时器为空
+	wheel *timingWheel
+	task  *wheelTask
 }
 
-// Stop 停止定时器
+// Stop 停止定时器，返回停止前定时器是否处于待触发状态
 func (t *Timer) Stop() (ok bool) {
 	if t == nil {
 		return
 	}
 
-	if ok = t.timer.Stop(); ok && t.node != nil {
+	if ok = t.wheel.cancel(t.task); ok && t.node != nil {
 		t.node.doneWait()
 	}
 
 	return
 }
+
+// Reset 重置定时器在d后触发，返回重置前定时器是否处于待触发状态
+// 已触发或已停止的定时器重置后将再次触发；重复定时器重置后按原间隔或cron计划继续重复
+func (t *Timer) Reset(d time.Duration) (ok bool) {
+	if t == nil {
+		return
+	}
+
+	if ok = t.wheel.reset(t.task, d); !ok && t.node != nil {
+		t.node.addWait()
+	}
+
+	return
+}
@@ -0,0 +1,296 @@
+package node
+
+import (
+	"context"
+	"gatesvr/utils/xcron"
+	"sync"
+	"time"
+)
+
+const (
+	wheelBits   = 6              // 每层槽位数的位数
+	wheelSize   = 1 << wheelBits // 每层槽位数
+	wheelMask   = wheelSize - 1
+	wheelLevels = 6                                      // 层数，可容纳的最大延迟为tick*64^6
+	wheelRange  = uint64(1) << (wheelBits * wheelLevels) // 可容纳的最大刻度数
+)
+
+// 时间轮任务
+type wheelTask struct {
+	expire   uint64          // 到期刻度
+	interval time.Duration   // 重复间隔，0为不重复
+	schedule *xcron.Schedule // cron调度计划，为空时不按cron重复
+	fn       func() bool     // 到期回调，在时间轮协程中执行，不可阻塞，返回false时取消重复
+	slot     *wheelSlot      // 所在槽位，为空时未调度
+	prev     *wheelTask
+	next     *wheelTask
+}
+
+// 是否为重复任务
+func (t *wheelTask) repeating() bool {
+	return t.interval > 0 || t.schedule != nil
+}
+
+// 时间轮槽位，双向循环链表
+type wheelSlot struct {
+	head wheelTask
+}
+
+// 添加任务
+func (s *wheelSlot) push(t *wheelTask) {
+	if s.head.next == nil {
+		s.head.next, s.head.prev = &s.head, &s.head
+	}
+
+	t.slot = s
+	t.prev, t.next = s.head.prev, &s.head
+	s.head.prev.next = t
+	s.head.prev = t
+}
+
+// 移除任务
+func (s *wheelSlot) remove(t *wheelTask) {
+	t.prev.next, t.next.prev = t.next, t.prev
+	t.prev, t.next, t.slot = nil, nil, nil
+}
+
+// 取出所有任务
+func (s *wheelSlot) drain() []*wheelTask {
+	if s.head.next == nil || s.head.next == &s.head {
+		return nil
+	}
+
+	tasks := make([]*wheelTask, 0)
+	for t := s.head.next; t != &s.head; {
+		next := t.next
+		t.prev, t.next, t.slot = nil, nil, nil
+		tasks = append(tasks, t)
+		t = next
+	}
+
+	s.head.next, s.head.prev = &s.head, &s.head
+
+	return tasks
+}
+
+// 分层时间轮，所有定时任务共享一个驱动协程，避免为每个定时器创建运行时计时器
+// 最底层每个槽位为一个刻度，上层每个槽位覆盖下层一整圈，上层槽位到期时将任务降级到下层
+type timingWheel struct {
+	ctx     context.Context
+	tick    time.Duration
+	start   time.Time
+	once    sync.Once
+	mu      sync.Mutex
+	firing  sync.Mutex // 触发到期任务期间持有，停止时等待正在触发的任务完成
+	current uint64     // 当前刻度
+	stopped bool       // 是否已停止调度重复任务
+	slots   [wheelLevels][wheelSize]wheelSlot
+}
+
+func newTimingWheel(ctx context.Context, tick time.Duration) *timingWheel {
+	return &timingWheel{ctx: ctx, tick: tick, start: time.Now()}
+}
+
+// 调度一次性任务
+func (w *timingWheel) afterFunc(d time.Duration, fn func() bool) *wheelTask {
+	t := &wheelTask{fn: fn}
+	w.reset(t, d)
+
+	return t
+}
+
+// 调度重复任务
+func (w *timingWheel) tickFunc(d time.Duration, fn func() bool) *wheelTask {
+	t := &wheelTask{fn: fn, interval: max(d, w.tick)}
+	w.reset(t, t.interval)
+
+	return t
+}
+
+// 调度cron任务，计划无下一个触发时间时不调度
+func (w *timingWheel) cronFunc(schedule *xcron.Schedule, fn func() bool) *wheelTask {
+	t := &wheelTask{fn: fn, schedule: schedule}
+
+	if next := schedule.Next(time.Now()); !next.IsZero() {
+		w.reset(t, time.Until(next))
+	}
+
+	return t
+}
+
+// 重新调度任务，返回任务调度前是否处于待触发状态
+func (w *timingWheel) reset(t *wheelTask, d time.Duration) bool {
+	w.once.Do(func() { go w.run() })
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	active := t.slot != nil
+	if active {
+		t.slot.remove(t)
+	}
+
+	if w.stopped && t.repeating() {
+		return active
+	}
+
+	t.expire = max(w.ticks(time.Since(w.start)+d), w.current+1)
+
+	w.add(t)
+
+	return active
+}
+
+// 取消任务，返回任务取消前是否处于待触发状态
+func (w *timingWheel) cancel(t *wheelTask) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if t.slot == nil {
+		return false
+	}
+
+	t.slot.remove(t)
+
+	return true
+}
+
+// 停止调度所有重复任务，一次性任务仍按时触发，节点关闭时调用
+func (w *timingWheel) stop() {
+	w.firing.Lock()
+	defer w.firing.Unlock()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.stopped = true
+
+	for level := range w.slots {
+		for i := range w.slots[level] {
+			slot := &w.slots[level][i]
+
+			for _, t := range slot.drain() {
+				if !t.repeating() {
+					slot.push(t)
+				}
+			}
+		}
+	}
+}
+
+// 将时长向上取整为刻度数
+func (w *timingWheel) ticks(d time.Duration) uint64 {
+	if d <= 0 {
+		return 0
+	}
+
+	return uint64((d + w.tick - 1) / w.tick)
+}
+
+// 按到期刻度将任务放入对应层级的槽位，调用方需持有锁
+func (w *timingWheel) add(t *wheelTask) {
+	expire := t.expire
+	delta := expire - w.current
+
+	// 超出时间轮范围的任务暂存于最高层，降级时重新计算位置
+	if delta >= wheelRange {
+		expire = w.current + wheelRange - 1
+		delta = wheelRange - 1
+	}
+
+	level := 0
+	for level < wheelLevels-1 && delta >= uint64(1)<<(wheelBits*(level+1)) {
+		level++
+	}
+
+	w.slots[level][(expire>>(wheelBits*level))&wheelMask].push(t)
+}
+
+// 驱动时间轮，节点销毁后停止
+func (w *timingWheel) run() {
+	ticker := time.NewTicker(w.tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+			w.advance(uint64(time.Since(w.start) / w.tick))
+		}
+	}
+}
+
+// 推进时间轮至目标刻度，逐个刻度触发到期任务，协程调度延迟时可追赶
+func (w *timingWheel) advance(target uint64) {
+	w.firing.Lock()
+	defer w.firing.Unlock()
+
+	for {
+		w.mu.Lock()
+
+		if w.current+1 > target {
+			w.mu.Unlock()
+			return
+		}
+
+		tasks := w.step()
+
+		w.mu.Unlock()
+
+		for _, t := range tasks {
+			if !t.fn() {
+				w.cancel(t)
+			}
+		}
+	}
+}
+
+// 推进一个刻度并返回到期任务，重复任务重新调度，调用方需持有锁
+func (w *timingWheel) step() []*wheelTask {
+	w.current++
+
+	// 下层转满一圈时将上层对应槽位的任务降级
+	for level := 1; level < wheelLevels; level++ {
+		if (w.current>>(wheelBits*(level-1)))&wheelMask != 0 {
+			break
+		}
+
+		for _, t := range w.slots[level][(w.current>>(wheelBits*level))&wheelMask].drain() {
+			w.add(t)
+		}
+	}
+
+	tasks := w.slots[0][w.current&wheelMask].drain()
+
+	expired := tasks[:0]
+	for _, t := range tasks {
+		if t.expire > w.current {
+			w.add(t)
+			continue
+		}
+
+		if expire, ok := w.next(t); ok {
+			t.expire = max(expire, w.current+1)
+			w.add(t)
+		}
+
+		expired = append(expired, t)
+	}
+
+	return expired
+}
+
+// 获取重复任务的下次到期刻度，固定间隔的任务按刻度累加以避免漂移
+func (w *timingWheel) next(t *wheelTask) (uint64, bool) {
+	switch {
+	case t.interval > 0:
+		return t.expire + w.ticks(t.interval), true
+	case t.schedule != nil:
+		if next := t.schedule.Next(time.Now()); !next.IsZero() {
+			return w.ticks(next.Sub(w.start)), true
+		}
+	}
+
+	return 0, false
+}
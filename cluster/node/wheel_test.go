@@ -0,0 +1,207 @@
+package node
+
+import (
+	"context"
+	"gatesvr/cluster"
+	"gatesvr/encoding/json"
+	"gatesvr/locate/memory"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 新建由测试手动推进的时间轮
+func newManualWheel() *timingWheel {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	return newTimingWheel(ctx, time.Millisecond)
+}
+
+func TestTimingWheel_Cascade(t *testing.T) {
+	w := newManualWheel()
+
+	fired := make(map[*wheelTask]uint64)
+	tasks := make([]*wheelTask, 0)
+
+	for _, d := range []time.Duration{time.Millisecond, 63 * time.Millisecond, 64 * time.Millisecond, 65 * time.Millisecond,
+		4095 * time.Millisecond, 4096 * time.Millisecond, 5 * time.Minute} {
+		var task *wheelTask
+		task = w.afterFunc(d, func() bool {
+			fired[task] = w.current
+			return true
+		})
+		tasks = append(tasks, task)
+	}
+
+	w.advance(w.ticks(6 * time.Minute))
+
+	for _, task := range tasks {
+		if at, ok := fired[task]; !ok || at != task.expire {
+			t.Fatalf("task expired at %d fired at %d, ok: %v", task.expire, at, ok)
+		}
+	}
+}
+
+func TestTimingWheel_StopAndReset(t *testing.T) {
+	w := newManualWheel()
+
+	var count int
+	task := w.afterFunc(10*time.Millisecond, func() bool {
+		count++
+		return true
+	})
+
+	if !w.cancel(task) || w.cancel(task) {
+		t.Fatal("unexpected cancel result")
+	}
+
+	w.advance(w.ticks(20 * time.Millisecond))
+
+	if count != 0 {
+		t.Fatal("stopped task is fired")
+	}
+
+	if w.reset(task, 10*time.Millisecond) {
+		t.Fatal("stopped task is reported as active")
+	}
+
+	w.advance(w.ticks(50 * time.Millisecond))
+
+	if count != 1 {
+		t.Fatalf("unexpected fired count: %d", count)
+	}
+}
+
+func TestTimingWheel_Tick(t *testing.T) {
+	w := newManualWheel()
+
+	var every, limited int
+	w.tickFunc(10*time.Millisecond, func() bool {
+		every++
+		return true
+	})
+	w.tickFunc(10*time.Millisecond, func() bool {
+		limited++
+		return limited < 3
+	})
+
+	w.advance(w.ticks(105 * time.Millisecond))
+
+	if every != 10 || limited != 3 {
+		t.Fatalf("unexpected fired count, every: %d limited: %d", every, limited)
+	}
+}
+
+func TestTimingWheel_StopRepeating(t *testing.T) {
+	w := newManualWheel()
+
+	var ticked, once int
+	tick := w.tickFunc(10*time.Millisecond, func() bool {
+		ticked++
+		return true
+	})
+	w.afterFunc(50*time.Millisecond, func() bool {
+		once++
+		return true
+	})
+
+	w.advance(w.ticks(15 * time.Millisecond))
+
+	w.stop()
+
+	if w.reset(tick, 10*time.Millisecond) {
+		t.Fatal("stopped repeating task is reported as active")
+	}
+
+	w.advance(w.ticks(100 * time.Millisecond))
+
+	if ticked != 1 || once != 1 {
+		t.Fatalf("unexpected fired count, ticked: %d once: %d", ticked, once)
+	}
+}
+
+func TestNode_DeliverAfterDestroy(t *testing.T) {
+	n := NewNode(WithID("node-1"), WithCodec(json.DefaultCodec), WithLocator(memory.NewLocator()))
+	n.state.Store(int32(cluster.Work))
+
+	for i := 0; i < cap(n.fnChan); i++ {
+		n.fnChan <- func() {}
+	}
+
+	// 调用队列已满时暂存到积压队列，关闭队列时不应向已关闭的队列发送，放弃投递的函数需释放等待计数
+	for i := 0; i < 8; i++ {
+		n.addWait()
+
+		if !n.deliver(func() {}) {
+			t.Fatal("deliver failed before destroy")
+		}
+	}
+
+	n.closeFnChan()
+
+	n.addWait()
+
+	if n.deliver(func() {}) {
+		t.Fatal("deliver succeeded after destroy")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("wait count is leaked by dropped functions")
+	}
+}
+
+func TestProxy_TickInvoke(t *testing.T) {
+	n := NewNode(WithID("node-1"), WithCodec(json.DefaultCodec), WithLocator(memory.NewLocator()), WithTimerTick(time.Millisecond))
+	defer n.cancel()
+
+	go n.dispatch()
+
+	done := make(chan struct{})
+	n.proxy.AfterInvoke(5*time.Millisecond, func() { close(done) })
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("after invoke is not fired")
+	}
+
+	var count atomic.Int32
+	ticks := make(chan struct{}, 8)
+	timer := n.proxy.TickInvoke(2*time.Millisecond, func() {
+		if count.Add(1) <= 3 {
+			ticks <- struct{}{}
+		}
+	})
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-ticks:
+		case <-time.After(time.Second):
+			t.Fatal("tick invoke is not fired")
+		}
+	}
+
+	if !timer.Stop() {
+		t.Fatal("ticker is not active")
+	}
+
+	fired := count.Load()
+	time.Sleep(20 * time.Millisecond)
+
+	if count.Load() > fired+1 {
+		t.Fatalf("ticker is fired after stop: %d > %d", count.Load(), fired)
+	}
+
+	if _, err := n.proxy.CronInvoke("* * * *", func() {}); err == nil {
+		t.Fatal("expected invalid cron spec error")
+	}
+}
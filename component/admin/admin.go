@@ -0,0 +1,99 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"gatesvr/cluster"
+	"gatesvr/component"
+	"gatesvr/core/info"
+	xnet "gatesvr/core/net"
+	"gatesvr/log"
+	"gatesvr/session"
+	"net"
+	"net/http"
+	"time"
+)
+
+const shutdownTimeout = 3 * time.Second // 关闭超时时间
+
+var _ component.Component = &Admin{}
+
+// Provider 管理数据提供者
+type Provider interface {
+	// Session 获取会话
+	Session() *session.Session
+	// Kick 断开连接
+	Kick(kind session.Kind, target int64, force bool) error
+	// Topology 获取节点拓扑
+	Topology() string
+	// State 获取状态
+	State() cluster.State
+	// SetState 设置状态
+	SetState(state cluster.State) error
+}
+
+type Admin struct {
+	component.Base
+	opts   *options
+	server *http.Server
+}
+
+func NewAdmin(opts ...Option) *Admin {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	a := &Admin{opts: o}
+	a.server = &http.Server{Handler: a.handler()}
+
+	return a
+}
+
+func (*Admin) Name() string {
+	return "admin"
+}
+
+// Init 初始化组件
+func (a *Admin) Init() {
+	if a.opts.provider == nil {
+		log.Fatal("admin provider is not injected")
+	}
+}
+
+// Start 启动组件
+func (a *Admin) Start() {
+	listenAddr, exposeAddr, err := xnet.ParseAddr(a.opts.addr)
+	if err != nil {
+		log.Fatalf("admin addr parse failed: %v", err)
+	}
+
+	ln, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		log.Fatalf("admin server listen failed: %v", err)
+	}
+
+	if a.opts.token == "" {
+		log.Warn("admin token is not configured, mutating endpoints are disabled")
+	}
+
+	go func() {
+		if err := a.server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Errorf("admin server start failed: %v", err)
+		}
+	}()
+
+	info.PrintBoxInfo("Admin",
+		fmt.Sprintf("Url: http://%s/admin/", exposeAddr),
+	)
+}
+
+// Destroy 销毁组件
+func (a *Admin) Destroy() {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := a.server.Shutdown(ctx); err != nil {
+		log.Errorf("admin server shutdown failed: %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package admin
+
+import (
+	"encoding/json"
+	"gatesvr/cluster"
+	"gatesvr/gate"
+	"gatesvr/network"
+	"gatesvr/session"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var _ Provider = (*gate.Gate)(nil)
+
+type testConn struct {
+	network.Conn
+	id  int64
+	uid int64
+}
+
+func (c *testConn) ID() int64 { return c.id }
+
+func (c *testConn) UID() int64 { return c.uid }
+
+func (c *testConn) State() network.ConnState { return network.ConnOpened }
+
+func (c *testConn) RemoteIP() (string, error) { return "127.0.0.1", nil }
+
+type testProvider struct {
+	session *session.Session
+	state   cluster.State
+	kicked  []int64
+}
+
+func (p *testProvider) Session() *session.Session { return p.session }
+
+func (p *testProvider) Kick(kind session.Kind, target int64, force bool) error {
+	if _, err := p.session.FindConn(kind, target); err != nil {
+		return err
+	}
+	p.kicked = append(p.kicked, target)
+	return nil
+}
+
+func (p *testProvider) Topology() string { return "Routes:\n" }
+
+func (p *testProvider) State() cluster.State { return p.state }
+
+func (p *testProvider) SetState(state cluster.State) error {
+	p.state = state
+	return nil
+}
+
+func newTestAdmin(token string) (*Admin, *testProvider) {
+	provider := &testProvider{session: session.NewSession(), state: cluster.Work}
+	provider.session.AddConn(&testConn{id: 1, uid: 100})
+
+	return NewAdmin(WithProvider(provider), WithToken(token)), provider
+}
+
+func do(t *testing.T, a *Admin, method, url string, token ...string) (int, *response) {
+	req := httptest.NewRequest(method, url, nil)
+	if len(token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+token[0])
+	}
+
+	rec := httptest.NewRecorder()
+	a.server.Handler.ServeHTTP(rec, req)
+
+	res := &response{}
+	if err := json.Unmarshal(rec.Body.Bytes(), res); err != nil {
+		t.Fatalf("invalid response: %s", rec.Body.String())
+	}
+
+	return rec.Code, res
+}
+
+func TestAdmin_Sessions(t *testing.T) {
+	a, _ := newTestAdmin("")
+
+	status, res := do(t, a, http.MethodGet, "/admin/sessions")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d", status)
+	}
+
+	stat := res.Data.(map[string]any)
+	if stat["conns"].(float64) != 1 || stat["users"].(float64) != 1 {
+		t.Fatalf("unexpected stat: %v", stat)
+	}
+
+	status, res = do(t, a, http.MethodGet, "/admin/sessions/user/100")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d", status)
+	}
+
+	if conn := res.Data.(map[string]any); conn["cid"].(float64) != 1 || conn["state"] != "opened" {
+		t.Fatalf("unexpected conn: %v", conn)
+	}
+
+	if status, _ = do(t, a, http.MethodGet, "/admin/sessions/conn/2"); status != http.StatusNotFound {
+		t.Fatalf("unexpected status: %d", status)
+	}
+
+	if status, _ = do(t, a, http.MethodGet, "/admin/sessions/group/1"); status != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", status)
+	}
+}
+
+func TestAdmin_Kick(t *testing.T) {
+	a, provider := newTestAdmin("secret")
+
+	if status, _ := do(t, a, http.MethodPost, "/admin/sessions/conn/1/kick?force=true", "secret"); status != http.StatusOK {
+		t.Fatalf("unexpected status: %d", status)
+	}
+
+	if len(provider.kicked) != 1 || provider.kicked[0] != 1 {
+		t.Fatalf("unexpected kicked: %v", provider.kicked)
+	}
+
+	if status, _ := do(t, a, http.MethodPost, "/admin/sessions/user/200/kick", "secret"); status != http.StatusNotFound {
+		t.Fatalf("unexpected status: %d", status)
+	}
+}
+
+func TestAdmin_State(t *testing.T) {
+	a, provider := newTestAdmin("secret")
+
+	status, res := do(t, a, http.MethodPost, "/admin/state?state=busy", "secret")
+	if status != http.StatusOK || provider.state != cluster.Busy {
+		t.Fatalf("unexpected status: %d state: %v", status, provider.state)
+	}
+
+	if state := res.Data.(map[string]any)["state"]; state != "busy" {
+		t.Fatalf("unexpected state: %v", state)
+	}
+
+	if status, _ = do(t, a, http.MethodPost, "/admin/state?state=shut", "secret"); status != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", status)
+	}
+}
+
+func TestAdmin_Token(t *testing.T) {
+	a, _ := newTestAdmin("secret")
+
+	if status, _ := do(t, a, http.MethodGet, "/admin/routes"); status != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d", status)
+	}
+
+	if status, _ := do(t, a, http.MethodGet, "/admin/routes", "wrong"); status != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d", status)
+	}
+
+	status, res := do(t, a, http.MethodGet, "/admin/routes", "secret")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status: %d", status)
+	}
+
+	if topology := res.Data.(map[string]any)["topology"]; topology != "Routes:\n" {
+		t.Fatalf("unexpected topology: %v", topology)
+	}
+}
+
+func TestAdmin_MutableWithoutToken(t *testing.T) {
+	a, provider := newTestAdmin("")
+
+	if status, _ := do(t, a, http.MethodPost, "/admin/sessions/conn/1/kick"); status != http.StatusForbidden {
+		t.Fatalf("unexpected status: %d", status)
+	}
+
+	if status, _ := do(t, a, http.MethodPost, "/admin/state?state=hang"); status != http.StatusForbidden {
+		t.Fatalf("unexpected status: %d", status)
+	}
+
+	if len(provider.kicked) != 0 || provider.state != cluster.Work {
+		t.Fatalf("unexpected kicked: %v state: %v", provider.kicked, provider.state)
+	}
+
+	if status, _ := do(t, a, http.MethodGet, "/admin/state"); status != http.StatusOK {
+		t.Fatalf("unexpected status: %d", status)
+	}
+}
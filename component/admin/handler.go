@@ -0,0 +1,233 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"gatesvr/cluster"
+	"gatesvr/errors"
+	"gatesvr/network"
+	"gatesvr/session"
+	"gatesvr/utils/codes"
+	"net/http"
+	"strconv"
+)
+
+type response struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+type connInfo struct {
+	CID   int64  `json:"cid"`
+	UID   int64  `json:"uid"`
+	State string `json:"state"`
+	IP    string `json:"ip"`
+}
+
+type statInfo struct {
+	Conns int64 `json:"conns"`
+	Users int64 `json:"users"`
+}
+
+type stateInfo struct {
+	State string `json:"state"`
+}
+
+type topologyInfo struct {
+	Topology string `json:"topology"`
+}
+
+// 注册路由
+// GET  /admin/sessions                     统计会话总数
+// GET  /admin/sessions/{kind}/{target}      查询连接，kind为conn或user
+// POST /admin/sessions/{kind}/{target}/kick 断开连接，force=true时强制断开
+// GET  /admin/routes                       查询路由、事件、端点及节点实例
+// GET  /admin/state                        查询网关状态
+// POST /admin/state?state=work|busy|hang   设置网关状态
+// POST请求为变更接口，未配置访问令牌时拒绝访问
+func (a *Admin) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/sessions", a.stat)
+	mux.HandleFunc("GET /admin/sessions/{kind}/{target}", a.findConn)
+	mux.HandleFunc("POST /admin/sessions/{kind}/{target}/kick", a.mutable(a.kick))
+	mux.HandleFunc("GET /admin/routes", a.routes)
+	mux.HandleFunc("GET /admin/state", a.getState)
+	mux.HandleFunc("POST /admin/state", a.mutable(a.setState))
+
+	return a.auth(mux)
+}
+
+// 变更接口，未配置访问令牌时拒绝访问
+func (a *Admin) mutable(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.opts.token == "" {
+			a.failure(w, http.StatusForbidden, codes.Unauthorized.WithMessage("access token is not configured"))
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// 校验访问令牌
+func (a *Admin) auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.opts.token != "" {
+			token := r.Header.Get("Authorization")
+			if subtle.ConstantTimeCompare([]byte(token), []byte("Bearer "+a.opts.token)) != 1 {
+				a.failure(w, http.StatusUnauthorized, codes.Unauthorized)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// 统计会话总数
+func (a *Admin) stat(w http.ResponseWriter, r *http.Request) {
+	s := a.opts.provider.Session()
+
+	conns, err := s.Stat(session.Conn)
+	if err != nil {
+		a.failure(w, http.StatusInternalServerError, codes.InternalError)
+		return
+	}
+
+	users, err := s.Stat(session.User)
+	if err != nil {
+		a.failure(w, http.StatusInternalServerError, codes.InternalError)
+		return
+	}
+
+	a.success(w, &statInfo{Conns: conns, Users: users})
+}
+
+// 查询连接
+func (a *Admin) findConn(w http.ResponseWriter, r *http.Request) {
+	kind, target, ok := a.parseTarget(w, r)
+	if !ok {
+		return
+	}
+
+	conn, err := a.opts.provider.Session().FindConn(kind, target)
+	if err != nil {
+		a.failure(w, http.StatusNotFound, codes.NotFound)
+		return
+	}
+
+	ip, _ := conn.RemoteIP()
+
+	a.success(w, &connInfo{
+		CID:   conn.ID(),
+		UID:   conn.UID(),
+		State: connState(conn.State()),
+		IP:    ip,
+	})
+}
+
+// 断开连接
+func (a *Admin) kick(w http.ResponseWriter, r *http.Request) {
+	kind, target, ok := a.parseTarget(w, r)
+	if !ok {
+		return
+	}
+
+	force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+
+	if err := a.opts.provider.Kick(kind, target, force); err != nil {
+		if errors.Is(err, errors.ErrNotFoundSession) {
+			a.failure(w, http.StatusNotFound, codes.NotFound)
+		} else {
+			a.failure(w, http.StatusInternalServerError, codes.InternalError.WithMessage(err.Error()))
+		}
+		return
+	}
+
+	a.success(w, nil)
+}
+
+// 查询路由、事件、端点及节点实例
+func (a *Admin) routes(w http.ResponseWriter, r *http.Request) {
+	a.success(w, &topologyInfo{Topology: a.opts.provider.Topology()})
+}
+
+// 查询网关状态
+func (a *Admin) getState(w http.ResponseWriter, r *http.Request) {
+	a.success(w, &stateInfo{State: a.opts.provider.State().String()})
+}
+
+// 设置网关状态
+func (a *Admin) setState(w http.ResponseWriter, r *http.Request) {
+	var state cluster.State
+
+	switch r.URL.Query().Get("state") {
+	case cluster.Work.String():
+		state = cluster.Work
+	case cluster.Busy.String():
+		state = cluster.Busy
+	case cluster.Hang.String():
+		state = cluster.Hang
+	default:
+		a.failure(w, http.StatusBadRequest, codes.InvalidArgument)
+		return
+	}
+
+	if err := a.opts.provider.SetState(state); err != nil {
+		a.failure(w, http.StatusConflict, codes.StateError.WithMessage(err.Error()))
+		return
+	}
+
+	a.success(w, &stateInfo{State: a.opts.provider.State().String()})
+}
+
+// 解析会话类型及目标
+func (a *Admin) parseTarget(w http.ResponseWriter, r *http.Request) (session.Kind, int64, bool) {
+	var kind session.Kind
+
+	switch r.PathValue("kind") {
+	case session.Conn.String():
+		kind = session.Conn
+	case session.User.String():
+		kind = session.User
+	default:
+		a.failure(w, http.StatusBadRequest, codes.InvalidArgument)
+		return 0, 0, false
+	}
+
+	target, err := strconv.ParseInt(r.PathValue("target"), 10, 64)
+	if err != nil || target <= 0 {
+		a.failure(w, http.StatusBadRequest, codes.InvalidArgument)
+		return 0, 0, false
+	}
+
+	return kind, target, true
+}
+
+func (a *Admin) success(w http.ResponseWriter, data any) {
+	a.write(w, http.StatusOK, &response{Code: codes.OK.Code(), Message: codes.OK.Message(), Data: data})
+}
+
+func (a *Admin) failure(w http.ResponseWriter, status int, code *codes.Code) {
+	a.write(w, status, &response{Code: code.Code(), Message: code.Message()})
+}
+
+func (a *Admin) write(w http.ResponseWriter, status int, res *response) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+func connState(state network.ConnState) string {
+	switch state {
+	case network.ConnOpened:
+		return "opened"
+	case network.ConnHanged:
+		return "hanged"
+	case network.ConnClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
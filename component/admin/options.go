@@ -0,0 +1,51 @@
+package admin
+
+import (
+	"gatesvr/etc"
+)
+
+const (
+	defaultAddr = "127.0.0.1:0" // 监听地址，默认仅监听本地回环地址
+)
+
+const (
+	defaultAddrKey  = "etc.admin.addr"
+	defaultTokenKey = "etc.admin.token"
+)
+
+type Option func(o *options)
+
+type options struct {
+	addr     string   // 监听地址
+	token    string   // 访问令牌，为空时不校验且禁用断开连接、设置状态等变更接口
+	provider Provider // 管理数据提供者
+}
+
+func defaultOptions() *options {
+	opts := &options{
+		addr: defaultAddr,
+	}
+
+	if addr := etc.Get(defaultAddrKey).String(); addr != "" {
+		opts.addr = addr
+	}
+
+	opts.token = etc.Get(defaultTokenKey).String()
+
+	return opts
+}
+
+// WithAddr 设置监听地址
+func WithAddr(addr string) Option {
+	return func(o *options) { o.addr = addr }
+}
+
+// WithToken 设置访问令牌，请求需携带 Authorization: Bearer <token>，未设置时变更接口不可用
+func WithToken(token string) Option {
+	return func(o *options) { o.token = token }
+}
+
+// WithProvider 设置管理数据提供者，通常为网关组件
+func WithProvider(provider Provider) Option {
+	return func(o *options) { o.provider = provider }
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"gatesvr/component"
+	"gatesvr/core/info"
+	xnet "gatesvr/core/net"
+	"gatesvr/log"
+	xmetrics "gatesvr/metrics"
+	"gatesvr/metrics/prometheus"
+	"net"
+	"net/http"
+	"time"
+)
+
+const shutdownTimeout = 3 * time.Second // 关闭超时时间
+
+var _ component.Component = &Metrics{}
+
+type Metrics struct {
+	component.Base
+	opts   *options
+	server *http.Server
+}
+
+func NewMetrics(opts ...Option) *Metrics {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.metrics == nil {
+		o.metrics = prometheus.NewMetrics()
+	}
+
+	return &Metrics{opts: o}
+}
+
+func (*Metrics) Name() string {
+	return "metrics"
+}
+
+// Init 初始化组件，设置全局指标后端
+func (m *Metrics) Init() {
+	xmetrics.SetMetrics(m.opts.metrics)
+}
+
+// Start 启动组件
+func (m *Metrics) Start() {
+	listenAddr, exposeAddr, err := xnet.ParseAddr(m.opts.addr)
+	if err != nil {
+		log.Fatalf("metrics addr parse failed: %v", err)
+	}
+
+	ln, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		log.Fatalf("metrics server listen failed: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(m.opts.path, m.opts.metrics.Handler())
+	m.server = &http.Server{Handler: mux}
+
+	go func() {
+		if err := m.server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Errorf("metrics server start failed: %v", err)
+		}
+	}()
+
+	info.PrintBoxInfo("Metrics",
+		fmt.Sprintf("Url: http://%s%s", exposeAddr, m.opts.path),
+		fmt.Sprintf("Backend: %s", m.opts.metrics.Name()),
+	)
+}
+
+// Destroy 销毁组件
+func (m *Metrics) Destroy() {
+	if m.server == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := m.server.Shutdown(ctx); err != nil {
+		log.Errorf("metrics server shutdown failed: %v", err)
+	}
+}
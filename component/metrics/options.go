@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"gatesvr/etc"
+	xmetrics "gatesvr/metrics"
+)
+
+const (
+	defaultAddr = ":0"       // 监听地址
+	defaultPath = "/metrics" // 指标路径
+)
+
+const (
+	defaultAddrKey = "etc.metrics.addr"
+	defaultPathKey = "etc.metrics.path"
+)
+
+type Option func(o *options)
+
+type options struct {
+	addr    string           // 监听地址
+	path    string           // 指标路径
+	metrics xmetrics.Metrics // 指标后端
+}
+
+func defaultOptions() *options {
+	opts := &options{
+		addr: defaultAddr,
+		path: defaultPath,
+	}
+
+	if addr := etc.Get(defaultAddrKey).String(); addr != "" {
+		opts.addr = addr
+	}
+
+	if path := etc.Get(defaultPathKey).String(); path != "" {
+		opts.path = path
+	}
+
+	return opts
+}
+
+// WithAddr 设置监听地址
+func WithAddr(addr string) Option {
+	return func(o *options) { o.addr = addr }
+}
+
+// WithPath 设置指标路径
+func WithPath(path string) Option {
+	return func(o *options) { o.path = path }
+}
+
+// WithMetrics 设置指标后端，默认为Prometheus
+func WithMetrics(metrics xmetrics.Metrics) Option {
+	return func(o *options) { o.metrics = metrics }
+}
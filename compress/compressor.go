@@ -1,5 +1,13 @@
 package compress
 
+import (
+	"gatesvr/compress/lz4Compressor"
+	"gatesvr/compress/snappy"
+	"gatesvr/compress/zstd"
+	"gatesvr/errors"
+	"gatesvr/log"
+)
+
 type Compressor interface {
 	// Name 名称
 	Name() string
@@ -8,3 +16,70 @@ type Compressor interface {
 	// Decompress 解压缩
 	Decompress(data []byte) ([]byte, error)
 }
+
+// LimitDecompressor 可限制解压后长度的压缩器，解压不可信来源的数据时使用
+type LimitDecompressor interface {
+	// DecompressLimit 解压缩，解压后长度超过limit时返回错误
+	DecompressLimit(data []byte, limit int) ([]byte, error)
+}
+
+var compressors = make(map[string]Compressor)
+
+// 注册内置压缩器，各压缩器的编解码器均在首次使用时创建
+func init() {
+	RegisterCompressor(lz4Compressor.DefaultCompressor)
+	RegisterCompressor(zstd.DefaultCompressor)
+	RegisterCompressor(snappy.DefaultCompressor)
+}
+
+// RegisterCompressor 注册压缩器
+func RegisterCompressor(compressor Compressor) {
+	if compressor == nil {
+		log.Fatal("can't register a invalid compressor")
+	}
+
+	name := compressor.Name()
+
+	if name == "" {
+		log.Fatal("can't register a compressor without name")
+	}
+
+	if _, ok := compressors[name]; ok {
+		log.Warnf("the old %s compressor will be overwritten", name)
+	}
+
+	compressors[name] = compressor
+}
+
+// InvokeCompressor 调用压缩器
+func InvokeCompressor(name string) Compressor {
+	compressor, ok := compressors[name]
+	if !ok {
+		log.Fatalf("%s compressor is not registered", name)
+	}
+
+	return compressor
+}
+
+// DecompressLimit 解压缩并限制解压后的长度，limit小于等于0时不限制
+// 压缩器未实现LimitDecompressor时解压后再校验长度
+func DecompressLimit(compressor Compressor, data []byte, limit int) ([]byte, error) {
+	if limit <= 0 {
+		return compressor.Decompress(data)
+	}
+
+	if c, ok := compressor.(LimitDecompressor); ok {
+		return c.DecompressLimit(data, limit)
+	}
+
+	buf, err := compressor.Decompress(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(buf) > limit {
+		return nil, errors.ErrInvalidCompressedData
+	}
+
+	return buf, nil
+}
@@ -1,13 +1,26 @@
 package lz4Compressor
 
 import (
+	"encoding/binary"
+	"gatesvr/errors"
 	"github.com/pierrec/lz4/v4"
 )
 
+const Name = "lz4"
+
+const (
+	maxDecompressedSize = 64 << 20 // 默认解压后的最大长度
+	maxCompressionRatio = 255      // LZ4块的最大压缩比，匹配长度每255字节占用1字节
+)
+
+var DefaultCompressor = NewCompressor()
+
+// LZ4Compressor LZ4压缩器
+// 压缩格式：| 原始长度(uvarint) | LZ4块 |；数据不可压缩时LZ4块替换为原始数据
 type LZ4Compressor struct{}
 
 func (l *LZ4Compressor) Name() string {
-	return "lz4"
+	return Name
 }
 
 // 创建压缩器
@@ -15,20 +28,56 @@ func NewCompressor() *LZ4Compressor {
 	return &LZ4Compressor{}
 }
 
+// Compress 压缩
 func (l *LZ4Compressor) Compress(data []byte) ([]byte, error) {
-	buf := make([]byte, lz4.CompressBlockBound(len(data)))
-	n, err := lz4.CompressBlock(data, buf, nil)
+	buf := make([]byte, binary.MaxVarintLen64+lz4.CompressBlockBound(len(data)))
+	m := binary.PutUvarint(buf, uint64(len(data)))
+
+	n, err := lz4.CompressBlock(data, buf[m:], nil)
 	if err != nil {
 		return nil, err
 	}
-	return buf[:n], nil
+
+	// 不可压缩的数据直接存储原始数据，压缩块的长度总是小于原始长度
+	if n == 0 || n >= len(data) {
+		n = copy(buf[m:], data)
+	}
+
+	return buf[:m+n], nil
 }
 
+// Decompress 解压缩
 func (l *LZ4Compressor) Decompress(data []byte) ([]byte, error) {
-	buf := make([]byte, len(data)*10) // 假设解压后的数据不超过原始数据的10倍
+	return l.DecompressLimit(data, maxDecompressedSize)
+}
+
+// DecompressLimit 解压缩，声明的原始长度超过limit或超过压缩数据可解压出的最大长度时返回错误
+func (l *LZ4Compressor) DecompressLimit(data []byte, limit int) ([]byte, error) {
+	size, m := binary.Uvarint(data)
+	if m <= 0 || size > uint64(limit) {
+		return nil, errors.ErrInvalidCompressedData
+	}
+
+	data = data[m:]
+
+	if size > uint64(len(data))*maxCompressionRatio {
+		return nil, errors.ErrInvalidCompressedData
+	}
+
+	if uint64(len(data)) == size {
+		return append([]byte(nil), data...), nil
+	}
+
+	buf := make([]byte, size)
+
 	n, err := lz4.UncompressBlock(data, buf)
 	if err != nil {
 		return nil, err
 	}
-	return buf[:n], nil
+
+	if uint64(n) != size {
+		return nil, errors.ErrInvalidCompressedData
+	}
+
+	return buf, nil
 }
@@ -141,3 +141,64 @@ func BenchmarkLZ4Compressor_JSONDecompress(b *testing.B) {
 		_, _ = compressor.Decompress(compressed)
 	}
 }
+
+func TestLZ4Compressor_LargeRatio(t *testing.T) {
+	compressor := &LZ4Compressor{}
+	data := make([]byte, 1<<20)
+
+	compressed, err := compressor.Compress(data)
+	if err != nil {
+		t.Fatalf("compression failed: %v", err)
+	}
+
+	decompressed, err := compressor.Decompress(compressed)
+	if err != nil {
+		t.Fatalf("decompression failed: %v", err)
+	}
+
+	if !bytes.Equal(decompressed, data) {
+		t.Fatal("decompressed data does not match original")
+	}
+}
+
+func TestLZ4Compressor_Incompressible(t *testing.T) {
+	compressor := &LZ4Compressor{}
+	data := []byte("abc")
+
+	compressed, err := compressor.Compress(data)
+	if err != nil {
+		t.Fatalf("compression failed: %v", err)
+	}
+
+	decompressed, err := compressor.Decompress(compressed)
+	if err != nil {
+		t.Fatalf("decompression failed: %v", err)
+	}
+
+	if !bytes.Equal(decompressed, data) {
+		t.Fatal("decompressed data does not match original")
+	}
+
+	if _, err = compressor.Decompress([]byte{0xff}); err == nil {
+		t.Fatal("expected error for invalid data")
+	}
+}
+
+func TestLZ4Compressor_DecompressLimit(t *testing.T) {
+	compressor := &LZ4Compressor{}
+	data := bytes.Repeat([]byte("abcabcabc"), 1024)
+
+	compressed, err := compressor.Compress(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = compressor.DecompressLimit(compressed, len(data)-1); err == nil {
+		t.Fatal("expected error for data exceeding limit")
+	}
+
+	// 短数据声明超出最大压缩比的原始长度
+	if _, err = compressor.DecompressLimit([]byte{0x80, 0x80, 0x80, 0x20, 0x00}, maxDecompressedSize); err == nil {
+		t.Fatal("expected error for implausible size")
+	}
+}
@@ -0,0 +1,52 @@
+package snappy
+
+import (
+	"gatesvr/errors"
+	"github.com/golang/snappy"
+)
+
+const Name = "snappy"
+
+const (
+	maxDecompressedSize = 64 << 20 // 默认解压后的最大长度
+	maxCompressionRatio = 22       // snappy块的最大压缩比，单个复制操作以3字节表示最长64字节
+)
+
+var DefaultCompressor = NewCompressor()
+
+// Compressor snappy压缩器
+// snappy块格式自带原始长度前缀（uvarint），解压时按该长度一次性分配内存
+type Compressor struct{}
+
+func NewCompressor() *Compressor {
+	return &Compressor{}
+}
+
+// Name 名称
+func (c *Compressor) Name() string {
+	return Name
+}
+
+// Compress 压缩
+func (c *Compressor) Compress(data []byte) ([]byte, error) {
+	return snappy.Encode(nil, data), nil
+}
+
+// Decompress 解压缩
+func (c *Compressor) Decompress(data []byte) ([]byte, error) {
+	return c.DecompressLimit(data, maxDecompressedSize)
+}
+
+// DecompressLimit 解压缩，声明的原始长度超过limit或超过压缩数据可解压出的最大长度时返回错误
+func (c *Compressor) DecompressLimit(data []byte, limit int) ([]byte, error) {
+	size, err := snappy.DecodedLen(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if size > limit || size > len(data)*maxCompressionRatio {
+		return nil, errors.ErrInvalidCompressedData
+	}
+
+	return snappy.Decode(make([]byte, size), data)
+}
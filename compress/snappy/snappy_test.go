@@ -0,0 +1,56 @@
+package snappy_test
+
+import (
+	"bytes"
+	"gatesvr/compress/snappy"
+	"testing"
+)
+
+func TestCompressor(t *testing.T) {
+	cases := [][]byte{
+		{},
+		[]byte("hello world"),
+		bytes.Repeat([]byte("abcabcabc"), 1024),
+		make([]byte, 1<<20),
+	}
+
+	for _, data := range cases {
+		compressed, err := snappy.DefaultCompressor.Compress(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		decompressed, err := snappy.DefaultCompressor.Decompress(compressed)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(decompressed, data) {
+			t.Fatalf("decompressed data does not match original, len: %d", len(data))
+		}
+	}
+}
+
+func TestCompressor_InvalidData(t *testing.T) {
+	if _, err := snappy.DefaultCompressor.Decompress([]byte{0xff, 0xff, 0xff}); err == nil {
+		t.Fatal("expected error for invalid data")
+	}
+}
+
+func TestCompressor_DecompressLimit(t *testing.T) {
+	data := bytes.Repeat([]byte("abcabcabc"), 1024)
+
+	compressed, err := snappy.DefaultCompressor.Compress(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = snappy.DefaultCompressor.DecompressLimit(compressed, len(data)-1); err == nil {
+		t.Fatal("expected error for data exceeding limit")
+	}
+
+	// 短数据声明超出最大压缩比的原始长度
+	if _, err = snappy.DefaultCompressor.DecompressLimit([]byte{0x80, 0x80, 0x80, 0x20, 0x00}, 64<<20); err == nil {
+		t.Fatal("expected error for implausible size")
+	}
+}
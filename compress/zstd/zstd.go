@@ -0,0 +1,103 @@
+package zstd
+
+import (
+	"encoding/binary"
+	"gatesvr/errors"
+	"github.com/klauspost/compress/zstd"
+	"sync"
+)
+
+const Name = "zstd"
+
+// 默认解压后的最大长度
+const maxDecompressedSize = 64 << 20
+
+var DefaultCompressor = NewCompressor()
+
+// Compressor zstd压缩器
+// 压缩格式：| 原始长度(uvarint) | zstd帧 |
+type Compressor struct {
+	once    sync.Once
+	err     error
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+// NewCompressor 创建压缩器，编码器及解码器在首次使用时创建，创建失败的错误由Compress及Decompress返回
+func NewCompressor() *Compressor {
+	return &Compressor{}
+}
+
+// 创建编码器及解码器
+func (c *Compressor) init() error {
+	c.once.Do(func() {
+		if c.encoder, c.err = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1)); c.err != nil {
+			return
+		}
+
+		c.decoder, c.err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(maxDecompressedSize),
+			zstd.WithDecodeAllCapLimit(true))
+	})
+
+	return c.err
+}
+
+// Name 名称
+func (c *Compressor) Name() string {
+	return Name
+}
+
+// Compress 压缩
+func (c *Compressor) Compress(data []byte) ([]byte, error) {
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+c.encoder.MaxEncodedSize(len(data)))
+	m := binary.PutUvarint(buf, uint64(len(data)))
+
+	return c.encoder.EncodeAll(data, buf[:m]), nil
+}
+
+// Decompress 解压缩
+func (c *Compressor) Decompress(data []byte) ([]byte, error) {
+	return c.DecompressLimit(data, maxDecompressedSize)
+}
+
+// DecompressLimit 解压缩，声明的原始长度超过limit或与帧头不一致时返回错误
+// 解码输出以声明的原始长度为上限，不会按帧内容分配超出该长度的内存
+func (c *Compressor) DecompressLimit(data []byte, limit int) ([]byte, error) {
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+
+	size, m := binary.Uvarint(data)
+	if m <= 0 || size > uint64(limit) {
+		return nil, errors.ErrInvalidCompressedData
+	}
+
+	if size == 0 {
+		return []byte{}, nil
+	}
+
+	// 分配内存前校验帧头，帧头记录的内容长度须与声明一致，窗口不得超过长度上限
+	var header zstd.Header
+	if err := header.Decode(data[m:]); err != nil {
+		return nil, errors.ErrInvalidCompressedData
+	}
+
+	if header.HasFCS && header.FrameContentSize != size || header.WindowSize > max(uint64(limit), zstd.MinWindowSize) {
+		return nil, errors.ErrInvalidCompressedData
+	}
+
+	buf, err := c.decoder.DecodeAll(data[m:], make([]byte, 0, size))
+	if err != nil {
+		return nil, err
+	}
+
+	if uint64(len(buf)) != size {
+		return nil, errors.ErrInvalidCompressedData
+	}
+
+	return buf, nil
+}
@@ -0,0 +1,81 @@
+package zstd_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"gatesvr/compress/zstd"
+	"testing"
+)
+
+func TestCompressor(t *testing.T) {
+	cases := [][]byte{
+		{},
+		[]byte("hello world"),
+		bytes.Repeat([]byte("abcabcabc"), 1024),
+		make([]byte, 1<<20),
+	}
+
+	for _, data := range cases {
+		compressed, err := zstd.DefaultCompressor.Compress(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		decompressed, err := zstd.DefaultCompressor.Decompress(compressed)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(decompressed, data) {
+			t.Fatalf("decompressed data does not match original, len: %d", len(data))
+		}
+	}
+}
+
+func TestCompressor_InvalidData(t *testing.T) {
+	if _, err := zstd.DefaultCompressor.Decompress([]byte{0xff, 0xff, 0xff}); err == nil {
+		t.Fatal("expected error for invalid data")
+	}
+}
+
+func TestNewCompressor(t *testing.T) {
+	compressor := zstd.NewCompressor()
+
+	compressed, err := compressor.Compress([]byte("hello world"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decompressed, err := compressor.Decompress(compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(decompressed) != "hello world" {
+		t.Fatalf("decompressed data does not match original: %s", decompressed)
+	}
+}
+
+func TestCompressor_DecompressLimit(t *testing.T) {
+	data := bytes.Repeat([]byte("abcabcabc"), 1024)
+
+	compressed, err := zstd.DefaultCompressor.Compress(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = zstd.DefaultCompressor.DecompressLimit(compressed, len(data)-1); err == nil {
+		t.Fatal("expected error for data exceeding limit")
+	}
+
+	if _, err = zstd.DefaultCompressor.DecompressLimit(compressed, len(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 声明的原始长度与帧头不一致
+	_, m := binary.Uvarint(compressed)
+	forged := append(binary.AppendUvarint(nil, uint64(len(data)/2)), compressed[m:]...)
+	if _, err = zstd.DefaultCompressor.DecompressLimit(forged, len(data)); err == nil {
+		t.Fatal("expected error for forged size")
+	}
+}
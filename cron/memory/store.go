@@ -0,0 +1,35 @@
+package memory
+
+import (
+	"context"
+	"gatesvr/cron"
+	"sync"
+	"time"
+)
+
+var _ cron.Store = &Store{}
+
+// Store 进程内的定时任务执行记录存储，进程重启后记录丢失，适用于单节点部署及测试
+type Store struct {
+	records sync.Map
+}
+
+func NewStore() *Store {
+	return &Store{}
+}
+
+// Load 加载任务最近一次执行的计划时间
+func (s *Store) Load(_ context.Context, name string) (time.Time, error) {
+	if at, ok := s.records.Load(name); ok {
+		return at.(time.Time), nil
+	}
+
+	return time.Time{}, nil
+}
+
+// Save 保存任务最近一次执行的计划时间
+func (s *Store) Save(_ context.Context, name string, at time.Time) error {
+	s.records.Store(name, at)
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"gatesvr/cron"
+	"gatesvr/errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var _ cron.Store = &Store{}
+
+const lastRunKey = "%s:cron:%s:last" // 任务最近一次执行的计划时间（毫秒时间戳）
+
+type Store struct {
+	opts    *options
+	builtin bool
+}
+
+func NewStore(opts ...Option) *Store {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	s := &Store{}
+	s.opts = o
+
+	if o.client == nil {
+		s.builtin = true
+		o.client = redis.NewUniversalClient(&redis.UniversalOptions{
+			Addrs:      o.addrs,
+			DB:         o.db,
+			Username:   o.username,
+			Password:   o.password,
+			MaxRetries: o.maxRetries,
+		})
+	}
+
+	return s
+}
+
+// Load 加载任务最近一次执行的计划时间
+func (s *Store) Load(ctx context.Context, name string) (time.Time, error) {
+	msec, err := s.opts.client.Get(ctx, fmt.Sprintf(lastRunKey, s.opts.prefix, name)).Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return time.Time{}, nil
+		}
+
+		return time.Time{}, err
+	}
+
+	return time.UnixMilli(msec), nil
+}
+
+// Save 保存任务最近一次执行的计划时间
+func (s *Store) Save(ctx context.Context, name string, at time.Time) error {
+	return s.opts.client.Set(ctx, fmt.Sprintf(lastRunKey, s.opts.prefix, name), at.UnixMilli(), 0).Err()
+}
+
+// Close 关闭存储
+func (s *Store) Close() error {
+	if s.builtin {
+		return s.opts.client.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,14 @@
+package cron
+
+import (
+	"context"
+	"time"
+)
+
+// Store 定时任务执行记录存储，集群中的节点共享同一存储以判定任务是否已执行及补偿错过的执行
+type Store interface {
+	// Load 加载任务最近一次执行的计划时间，无执行记录时返回零值
+	Load(ctx context.Context, name string) (time.Time, error)
+	// Save 保存任务最近一次执行的计划时间
+	Save(ctx context.Context, name string, at time.Time) error
+}
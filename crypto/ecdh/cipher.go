@@ -0,0 +1,272 @@
+package ecdh
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/binary"
+	"gatesvr/errors"
+	"sync"
+)
+
+const (
+	headerSize = 12 // 密文头长度：| 密钥纪元(4) | 消息计数(8) |，同时作为AES-GCM的nonce
+	windowSize = 64 // 防重放窗口大小
+	maxSkip    = 16 // 允许一次跳过的最大密钥纪元数
+
+	rotateInfo = "gatesvr session rotate"
+)
+
+// Cipher 会话加密器
+// 密文格式：| 密钥纪元(4) | 消息计数(8) | AES-GCM密文 |
+// 消息序列号作为附加认证数据，防止密文被挪用到其他序列号；消息计数经滑动窗口校验，防止重放
+// 服务端另以滑动窗口校验客户端请求的消息序列号，同一会话内重复的序列号将被拒绝，序列号0为推送消息，不做校验
+type Cipher struct {
+	sealer *sealer
+	opener *opener
+}
+
+func newCipher(sealKey, openKey []byte, checkSeq bool, opts ...Option) (*Cipher, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	sealState, err := newEpochState(0, sealKey)
+	if err != nil {
+		return nil, err
+	}
+
+	openState, err := newEpochState(0, openKey)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cipher{
+		sealer: &sealer{state: sealState, rotation: o.rotation},
+		opener: &opener{curr: openState},
+	}
+
+	if checkSeq {
+		c.opener.seqs = &seqWindow{}
+	}
+
+	return c, nil
+}
+
+// Seal 加密
+func (c *Cipher) Seal(seq int32, data []byte) ([]byte, error) {
+	return c.sealer.seal(seq, data)
+}
+
+// Open 解密
+func (c *Cipher) Open(seq int32, data []byte) ([]byte, error) {
+	return c.opener.open(seq, data)
+}
+
+type sealer struct {
+	mu       sync.Mutex
+	state    *epochState
+	counter  uint64 // 当前密钥已加密的消息数
+	rotation uint64 // 密钥轮换间隔
+}
+
+func (s *sealer) seal(seq int32, data []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.counter >= s.rotation {
+		state, err := s.state.next()
+		if err != nil {
+			return nil, err
+		}
+
+		s.state, s.counter = state, 0
+	}
+
+	var nonce [headerSize]byte
+	binary.BigEndian.PutUint32(nonce[:4], s.state.epoch)
+	binary.BigEndian.PutUint64(nonce[4:], s.counter)
+	s.counter++
+
+	aad := additionalData(nonce, seq)
+	dst := make([]byte, headerSize, headerSize+len(data)+s.state.aead.Overhead())
+	copy(dst, nonce[:])
+
+	return s.state.aead.Seal(dst, nonce[:], data, aad[:]), nil
+}
+
+type opener struct {
+	mu   sync.Mutex
+	curr *epochState // 当前密钥
+	prev *epochState // 上一密钥，用于解密轮换前乱序到达的消息
+	seqs *seqWindow  // 消息序列号防重放窗口，仅服务端启用
+}
+
+func (o *opener) open(seq int32, data []byte) ([]byte, error) {
+	if len(data) < headerSize {
+		return nil, errors.ErrInvalidCiphertext
+	}
+
+	var nonce [headerSize]byte
+	copy(nonce[:], data[:headerSize])
+	epoch := binary.BigEndian.Uint32(nonce[:4])
+	counter := binary.BigEndian.Uint64(nonce[4:])
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var (
+		state = o.curr
+		prev  = o.prev
+	)
+
+	switch {
+	case epoch == o.curr.epoch:
+	case o.prev != nil && epoch == o.prev.epoch:
+		state = o.prev
+	case epoch > o.curr.epoch && epoch-o.curr.epoch <= maxSkip:
+		for state.epoch < epoch {
+			next, err := state.next()
+			if err != nil {
+				return nil, err
+			}
+			prev, state = state, next
+		}
+	default:
+		return nil, errors.ErrReplayedMessage
+	}
+
+	if !state.window.check(counter) {
+		return nil, errors.ErrReplayedMessage
+	}
+
+	if o.seqs != nil && !o.seqs.check(seq) {
+		return nil, errors.ErrReplayedMessage
+	}
+
+	aad := additionalData(nonce, seq)
+
+	plaintext, err := state.aead.Open(nil, nonce[:], data[headerSize:], aad[:])
+	if err != nil {
+		return nil, errors.ErrInvalidCiphertext
+	}
+
+	state.window.mark(counter)
+
+	if o.seqs != nil {
+		o.seqs.mark(seq)
+	}
+
+	if state.epoch > o.curr.epoch {
+		o.curr, o.prev = state, prev
+	}
+
+	return plaintext, nil
+}
+
+type epochState struct {
+	epoch  uint32
+	key    []byte
+	aead   cipher.AEAD
+	window replayWindow
+}
+
+func newEpochState(epoch uint32, key []byte) (*epochState, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &epochState{epoch: epoch, key: key, aead: aead}, nil
+}
+
+// 轮换出下一纪元的密钥
+func (s *epochState) next() (*epochState, error) {
+	key, err := hkdf.Expand(sha256.New, s.key, rotateInfo, keySize)
+	if err != nil {
+		return nil, err
+	}
+
+	return newEpochState(s.epoch+1, key)
+}
+
+// 滑动窗口防重放
+type replayWindow struct {
+	init   bool
+	max    uint64 // 已接收的最大消息计数
+	bitmap uint64 // 窗口内已接收的消息，第i位表示max-i
+}
+
+func (w *replayWindow) check(counter uint64) bool {
+	if !w.init || counter > w.max {
+		return true
+	}
+
+	if w.max-counter >= windowSize {
+		return false
+	}
+
+	return w.bitmap&(1<<(w.max-counter)) == 0
+}
+
+func (w *replayWindow) mark(counter uint64) {
+	switch {
+	case !w.init:
+		w.init, w.max, w.bitmap = true, counter, 1
+	case counter > w.max:
+		if shift := counter - w.max; shift >= windowSize {
+			w.bitmap = 1
+		} else {
+			w.bitmap = w.bitmap<<shift | 1
+		}
+		w.max = counter
+	default:
+		w.bitmap |= 1 << (w.max - counter)
+	}
+}
+
+// 消息序列号滑动窗口防重放
+// 客户端序列号超出编码范围后从1重新分配，窗口随之重置
+type seqWindow struct {
+	window replayWindow
+}
+
+func (w *seqWindow) check(seq int32) bool {
+	if seq == 0 || w.restarted(seq) {
+		return true
+	}
+
+	return w.window.check(uint64(uint32(seq)))
+}
+
+func (w *seqWindow) mark(seq int32) {
+	if seq == 0 {
+		return
+	}
+
+	if w.restarted(seq) {
+		w.window = replayWindow{}
+	}
+
+	w.window.mark(uint64(uint32(seq)))
+}
+
+// 序列号是否已从头重新分配
+func (w *seqWindow) restarted(seq int32) bool {
+	counter := uint64(uint32(seq))
+
+	return w.window.init && counter < windowSize && w.window.max >= counter+windowSize
+}
+
+func additionalData(nonce [headerSize]byte, seq int32) (aad [headerSize + 4]byte) {
+	copy(aad[:], nonce[:])
+	binary.BigEndian.PutUint32(aad[headerSize:], uint32(seq))
+	return
+}
@@ -0,0 +1,89 @@
+package ecdh
+
+import (
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"gatesvr/errors"
+)
+
+// PublicKeySize 公钥长度
+const PublicKeySize = 32
+
+const (
+	keySize = 32 // 会话密钥长度（AES-256）
+
+	clientInfo = "gatesvr session client to server"
+	serverInfo = "gatesvr session server to client"
+)
+
+// Role 握手角色
+type Role int
+
+const (
+	Client Role = iota // 客户端
+	Server             // 服务端
+)
+
+// KeyPair 临时密钥对，每次握手重新生成
+type KeyPair struct {
+	key *ecdh.PrivateKey
+}
+
+// GenerateKey 生成X25519临时密钥对
+func GenerateKey() (*KeyPair, error) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyPair{key: key}, nil
+}
+
+// PublicKey 获取公钥
+func (k *KeyPair) PublicKey() []byte {
+	return k.key.PublicKey().Bytes()
+}
+
+// Negotiate 与对端公钥协商出会话加密器
+// 收发方向使用不同的密钥，密钥由共享秘密及双方公钥经HKDF派生；服务端加密器另校验客户端请求序列号防重放
+func (k *KeyPair) Negotiate(role Role, peer []byte, opts ...Option) (*Cipher, error) {
+	pub, err := ecdh.X25519().NewPublicKey(peer)
+	if err != nil {
+		return nil, errors.ErrInvalidPublicKey
+	}
+
+	secret, err := k.key.ECDH(pub)
+	if err != nil {
+		return nil, errors.ErrInvalidPublicKey
+	}
+
+	var salt []byte
+	if role == Client {
+		salt = append(k.PublicKey(), peer...)
+	} else {
+		salt = append(append([]byte{}, peer...), k.PublicKey()...)
+	}
+
+	prk, err := hkdf.Extract(sha256.New, secret, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	clientKey, err := hkdf.Expand(sha256.New, prk, clientInfo, keySize)
+	if err != nil {
+		return nil, err
+	}
+
+	serverKey, err := hkdf.Expand(sha256.New, prk, serverInfo, keySize)
+	if err != nil {
+		return nil, err
+	}
+
+	if role == Client {
+		return newCipher(clientKey, serverKey, false, opts...)
+	}
+
+	return newCipher(serverKey, clientKey, true, opts...)
+}
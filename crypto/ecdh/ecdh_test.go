@@ -0,0 +1,232 @@
+package ecdh_test
+
+import (
+	"bytes"
+	"gatesvr/crypto/ecdh"
+	"gatesvr/errors"
+	"testing"
+)
+
+func negotiate(t *testing.T, opts ...ecdh.Option) (*ecdh.Cipher, *ecdh.Cipher) {
+	clientKey, err := ecdh.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverKey, err := ecdh.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := clientKey.Negotiate(ecdh.Client, serverKey.PublicKey(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server, err := serverKey.Negotiate(ecdh.Server, clientKey.PublicKey(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client, server
+}
+
+func TestCipher_SealOpen(t *testing.T) {
+	client, server := negotiate(t)
+
+	ciphertext, err := client.Seal(1, []byte("hello gate"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext, err := server.Open(1, ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(plaintext) != "hello gate" {
+		t.Fatalf("unexpected plaintext: %s", plaintext)
+	}
+
+	ciphertext, err = server.Seal(1, []byte("hello client"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext, err = client.Open(1, ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(plaintext) != "hello client" {
+		t.Fatalf("unexpected plaintext: %s", plaintext)
+	}
+}
+
+func TestCipher_Directional(t *testing.T) {
+	client, _ := negotiate(t)
+
+	ciphertext, err := client.Seal(1, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 客户端发出的密文不能被客户端自己解密
+	if _, err = client.Open(1, ciphertext); !errors.Is(err, errors.ErrInvalidCiphertext) {
+		t.Fatalf("expected ErrInvalidCiphertext, got %v", err)
+	}
+}
+
+func TestCipher_Replay(t *testing.T) {
+	client, server := negotiate(t)
+
+	ciphertext, err := client.Seal(1, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = server.Open(1, ciphertext); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = server.Open(1, ciphertext); !errors.Is(err, errors.ErrReplayedMessage) {
+		t.Fatalf("expected ErrReplayedMessage, got %v", err)
+	}
+}
+
+func TestCipher_SeqAuthenticated(t *testing.T) {
+	client, server := negotiate(t)
+
+	ciphertext, err := client.Seal(1, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = server.Open(2, ciphertext); !errors.Is(err, errors.ErrInvalidCiphertext) {
+		t.Fatalf("expected ErrInvalidCiphertext, got %v", err)
+	}
+
+	// 认证失败的消息不占用防重放窗口
+	if _, err = server.Open(1, ciphertext); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCipher_SeqReplay(t *testing.T) {
+	client, server := negotiate(t)
+
+	seal := func(seq int32) []byte {
+		ciphertext, err := client.Seal(seq, []byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ciphertext
+	}
+
+	if _, err := server.Open(1, seal(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新加密的同一序列号请求仍被视为重放
+	if _, err := server.Open(1, seal(1)); !errors.Is(err, errors.ErrReplayedMessage) {
+		t.Fatalf("expected ErrReplayedMessage, got %v", err)
+	}
+
+	// 序列号0为推送消息，不做校验
+	for i := 0; i < 2; i++ {
+		if _, err := server.Open(0, seal(0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 超出窗口的旧序列号被拒绝
+	if _, err := server.Open(200, seal(200)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := server.Open(100, seal(100)); !errors.Is(err, errors.ErrReplayedMessage) {
+		t.Fatalf("expected ErrReplayedMessage, got %v", err)
+	}
+
+	// 序列号从头分配后窗口重置
+	if _, err := server.Open(1, seal(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 客户端响应沿用请求序列号，不做校验
+	for i := 0; i < 2; i++ {
+		ciphertext, err := server.Seal(1, []byte("reply"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = client.Open(1, ciphertext); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCipher_OutOfOrder(t *testing.T) {
+	client, server := negotiate(t)
+
+	ciphertexts := make([][]byte, 10)
+	for i := range ciphertexts {
+		ciphertext, err := client.Seal(int32(i), []byte{byte(i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ciphertexts[i] = ciphertext
+	}
+
+	for i := len(ciphertexts) - 1; i >= 0; i-- {
+		plaintext, err := server.Open(int32(i), ciphertexts[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(plaintext, []byte{byte(i)}) {
+			t.Fatalf("unexpected plaintext: %v", plaintext)
+		}
+	}
+}
+
+func TestCipher_Rotation(t *testing.T) {
+	client, server := negotiate(t, ecdh.WithRotation(4))
+
+	var first, last []byte
+	for i := 0; i < 20; i++ {
+		ciphertext, err := client.Seal(int32(i), []byte("rotate"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if i == 0 {
+			first = ciphertext
+		}
+		last = ciphertext
+
+		if _, err = server.Open(int32(i), ciphertext); err != nil {
+			t.Fatalf("open message %d failed: %v", i, err)
+		}
+	}
+
+	if bytes.Equal(first[:4], last[:4]) {
+		t.Fatal("key is not rotated")
+	}
+
+	// 已轮换掉的密钥加密的消息不再被接受
+	if _, err := server.Open(0, first); !errors.Is(err, errors.ErrReplayedMessage) {
+		t.Fatalf("expected ErrReplayedMessage, got %v", err)
+	}
+}
+
+func TestKeyPair_InvalidPublicKey(t *testing.T) {
+	key, err := ecdh.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = key.Negotiate(ecdh.Client, []byte("invalid")); !errors.Is(err, errors.ErrInvalidPublicKey) {
+		t.Fatalf("expected ErrInvalidPublicKey, got %v", err)
+	}
+}
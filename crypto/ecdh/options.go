@@ -0,0 +1,22 @@
+package ecdh
+
+const defaultRotation = 1 << 16 // 默认密钥轮换间隔（消息数）
+
+type Option func(o *options)
+
+type options struct {
+	rotation uint64 // 每个密钥最多加密的消息数，达到后轮换密钥
+}
+
+func defaultOptions() *options {
+	return &options{rotation: defaultRotation}
+}
+
+// WithRotation 设置密钥轮换间隔，每个密钥加密的消息数达到该值后轮换密钥
+func WithRotation(rotation uint64) Option {
+	return func(o *options) {
+		if rotation > 0 {
+			o.rotation = rotation
+		}
+	}
+}
@@ -15,6 +15,7 @@ var (
 	ErrInvalidReader           = New("invalid reader")
 	ErrNotFoundSession         = New("not found session")
 	ErrInvalidSessionKind      = New("invalid session kind")
+	ErrInvalidGroup            = New("invalid group")
 	ErrReceiveTargetEmpty      = New("the receive target is empty")
 	ErrInvalidArgument         = New("invalid argument")
 	ErrNotFoundRoute           = New("not found route")
@@ -52,11 +53,30 @@ var (
 	ErrUnregisterRoute         = New("unregistered route")
 	ErrNotBindActor            = New("not bind actor")
 	ErrNotFoundActor           = New("not found actor")
+	ErrActorMailboxFull        = New("actor mailbox is full")
+	ErrMissingReceiver         = New("missing receiver")
+	ErrInvalidReply            = New("invalid reply")
+	ErrActorPanic              = New("actor panic")
 	ErrWriterClosing           = New("writer is closing")
 	ErrDeadlineExceeded        = New("deadline exceeded")
 	ErrMissingResolver         = New("missing resolver")
 	ErrBlackUser               = New("black user")
 	ErrServerCircuitBreaker    = New("The service is in circuit breaker state")
+	ErrNotFoundHealthyEndpoint = New("not found healthy endpoint")
+	ErrReplayPendingTimeout    = New("replay pending messages timeout")
+	ErrInvalidResumeToken      = New("invalid resume token")
+	ErrInvalidCompressedData   = New("invalid compressed data")
+	ErrMissingCompressor       = New("missing compressor")
+	ErrInvalidPublicKey        = New("invalid public key")
+	ErrInvalidCiphertext       = New("invalid ciphertext")
+	ErrReplayedMessage         = New("replayed message")
+	ErrHandshakeNotCompleted   = New("handshake not completed")
+	ErrHandshakeFailed         = New("handshake failed")
+	ErrMissingLockMaker        = New("missing lock maker")
+	ErrLeaseNotSupported       = New("lease is not supported")
+	ErrInvalidCronSpec         = New("invalid cron spec")
+	ErrCronJobExists           = New("cron job exists")
+	ErrMissingCronStore        = New("missing cron store")
 )
 
 // NewError 新建一个错误
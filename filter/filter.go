@@ -0,0 +1,275 @@
+package filter
+
+import (
+	"gatesvr/config"
+	"gatesvr/log"
+	"gatesvr/utils/xnet"
+	"gatesvr/utils/xtime"
+	"net"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type rules struct {
+	blacklist    []netip.Prefix // 黑名单
+	whitelist    []netip.Prefix // 白名单，仅豁免单IP连接数限制
+	maxConnPerIP int            // 单IP最大连接数
+}
+
+type Filter struct {
+	opts  *options
+	rules atomic.Value // 过滤规则（*rules）
+	mu    sync.Mutex
+	conns map[netip.Addr]int   // 存活连接数（IP -> 连接数）
+	bans  map[netip.Addr]int64 // 临时封禁（IP -> 过期时间，0为永久）
+}
+
+func NewFilter(opts ...Option) *Filter {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	f := &Filter{}
+	f.opts = o
+	f.conns = make(map[netip.Addr]int)
+	f.bans = make(map[netip.Addr]int64)
+	f.Reload()
+
+	if o.watch {
+		config.Watch(func(names ...string) { f.Reload() }, configName)
+	}
+
+	return f
+}
+
+// Reload 重新加载过滤规则，config配置中心的配置优先
+func (f *Filter) Reload() {
+	o := *f.opts
+	o.override()
+
+	f.rules.Store(&rules{
+		blacklist:    parsePrefixes(o.blacklist),
+		whitelist:    parsePrefixes(o.whitelist),
+		maxConnPerIP: o.maxConnPerIP,
+	})
+}
+
+// IsBlack 检测地址是否在黑名单中或处于封禁期
+func (f *Filter) IsBlack(addr net.Addr) bool {
+	ip, ok := parseAddr(addr)
+	if !ok {
+		return false
+	}
+
+	if f.isBanned(ip) {
+		return true
+	}
+
+	return contains(f.load().blacklist, ip)
+}
+
+// IsWhite 检测地址是否在白名单中，白名单仅豁免单IP连接数限制，不豁免黑名单及封禁
+func (f *Filter) IsWhite(addr net.Addr) bool {
+	ip, ok := parseAddr(addr)
+	if !ok {
+		return false
+	}
+
+	return contains(f.load().whitelist, ip)
+}
+
+// IsOverMaxConn 检测地址的存活连接数是否已达上限
+func (f *Filter) IsOverMaxConn(addr net.Addr) bool {
+	limit := f.load().maxConnPerIP
+	if limit <= 0 {
+		return false
+	}
+
+	ip, ok := parseAddr(addr)
+	if !ok {
+		return false
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.conns[ip] >= limit
+}
+
+// TryConnect 检测地址的存活连接数是否已达上限，未达上限时记录连接建立，检测与计数在同一临界区内完成
+// 白名单内的IP不受上限限制，但仍计数以便断开时对称扣减
+func (f *Filter) TryConnect(addr net.Addr) bool {
+	ip, ok := parseAddr(addr)
+	if !ok {
+		return true
+	}
+
+	r := f.load()
+	limit := r.maxConnPerIP
+	if limit > 0 && contains(r.whitelist, ip) {
+		limit = 0
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if limit > 0 && f.conns[ip] >= limit {
+		return false
+	}
+
+	f.conns[ip]++
+
+	return true
+}
+
+// Connect 记录连接建立
+func (f *Filter) Connect(addr net.Addr) {
+	ip, ok := parseAddr(addr)
+	if !ok {
+		return
+	}
+
+	f.mu.Lock()
+	f.conns[ip]++
+	f.mu.Unlock()
+}
+
+// Disconnect 记录连接断开
+func (f *Filter) Disconnect(addr net.Addr) {
+	ip, ok := parseAddr(addr)
+	if !ok {
+		return
+	}
+
+	f.mu.Lock()
+	if n := f.conns[ip]; n <= 1 {
+		delete(f.conns, ip)
+	} else {
+		f.conns[ip] = n - 1
+	}
+	f.mu.Unlock()
+}
+
+// ConnCount 获取IP的存活连接数
+func (f *Filter) ConnCount(ip string) int {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return 0
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.conns[addr.Unmap()]
+}
+
+// Ban 封禁IP，duration小于等于0时永久封禁
+func (f *Filter) Ban(ip string, duration time.Duration) error {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return err
+	}
+
+	var expire int64
+	if duration > 0 {
+		expire = xtime.Now().Add(duration).UnixNano()
+	}
+
+	f.mu.Lock()
+	f.bans[addr.Unmap()] = expire
+	f.mu.Unlock()
+
+	return nil
+}
+
+// Unban 解除封禁
+func (f *Filter) Unban(ip string) error {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	delete(f.bans, addr.Unmap())
+	f.mu.Unlock()
+
+	return nil
+}
+
+// 检测IP是否处于封禁期，过期的封禁会被移除
+func (f *Filter) isBanned(ip netip.Addr) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	expire, ok := f.bans[ip]
+	if !ok {
+		return false
+	}
+
+	if expire != 0 && expire <= xtime.Now().UnixNano() {
+		delete(f.bans, ip)
+		return false
+	}
+
+	return true
+}
+
+// 加载过滤规则
+func (f *Filter) load() *rules {
+	return f.rules.Load().(*rules)
+}
+
+// 解析IP与CIDR列表
+func parsePrefixes(items []string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(items))
+	for _, item := range items {
+		if prefix, err := netip.ParsePrefix(item); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(item)
+		if err != nil {
+			log.Warnf("invalid filter rule: %s", item)
+			continue
+		}
+
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	return prefixes
+}
+
+// 解析地址中的IP
+func parseAddr(addr net.Addr) (netip.Addr, bool) {
+	if addr == nil {
+		return netip.Addr{}, false
+	}
+
+	host, err := xnet.ExtractIP(addr)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return ip.Unmap(), true
+}
+
+// 检测IP是否命中规则
+func contains(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,113 @@
+package filter_test
+
+import (
+	"gatesvr/filter"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func addr(ip string) net.Addr {
+	return &net.TCPAddr{IP: net.ParseIP(ip), Port: 3553}
+}
+
+func TestFilter_List(t *testing.T) {
+	f := filter.NewFilter(
+		filter.WithBlacklist("10.0.0.0/8", "192.168.1.1", "invalid"),
+		filter.WithWhitelist("127.0.0.1"),
+		filter.WithWatch(false),
+	)
+
+	if !f.IsBlack(addr("10.1.2.3")) {
+		t.Fatal("expected 10.1.2.3 in blacklist")
+	}
+
+	if !f.IsBlack(addr("192.168.1.1")) || f.IsBlack(addr("192.168.1.2")) {
+		t.Fatal("unexpected blacklist result for 192.168.1.x")
+	}
+
+	if !f.IsWhite(addr("127.0.0.1")) || f.IsWhite(addr("127.0.0.2")) {
+		t.Fatal("unexpected whitelist result")
+	}
+}
+
+func TestFilter_MaxConnPerIP(t *testing.T) {
+	f := filter.NewFilter(filter.WithMaxConnPerIP(2), filter.WithWatch(false))
+
+	f.Connect(addr("1.1.1.1"))
+	if f.IsOverMaxConn(addr("1.1.1.1")) {
+		t.Fatal("expected not over max conn")
+	}
+
+	f.Connect(addr("1.1.1.1"))
+	if !f.IsOverMaxConn(addr("1.1.1.1")) {
+		t.Fatal("expected over max conn")
+	}
+
+	if f.IsOverMaxConn(addr("2.2.2.2")) {
+		t.Fatal("expected other ip not affected")
+	}
+
+	f.Disconnect(addr("1.1.1.1"))
+	if f.IsOverMaxConn(addr("1.1.1.1")) || f.ConnCount("1.1.1.1") != 1 {
+		t.Fatal("expected connection released")
+	}
+}
+
+func TestFilter_TryConnect(t *testing.T) {
+	f := filter.NewFilter(filter.WithMaxConnPerIP(5), filter.WithWhitelist("127.0.0.1"), filter.WithWatch(false))
+
+	var (
+		wg       sync.WaitGroup
+		accepted atomic.Int32
+	)
+
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if f.TryConnect(addr("5.5.5.5")) {
+				accepted.Add(1)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if n := accepted.Load(); n != 5 || f.ConnCount("5.5.5.5") != 5 {
+		t.Fatalf("expected exactly 5 connections accepted, got %d", n)
+	}
+
+	for i := 0; i < 10; i++ {
+		if !f.TryConnect(addr("127.0.0.1")) {
+			t.Fatal("expected whitelisted ip not limited")
+		}
+	}
+}
+
+func TestFilter_Ban(t *testing.T) {
+	f := filter.NewFilter(filter.WithWatch(false))
+
+	if err := f.Ban("3.3.3.3", 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	if !f.IsBlack(addr("3.3.3.3")) {
+		t.Fatal("expected 3.3.3.3 banned")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if f.IsBlack(addr("3.3.3.3")) {
+		t.Fatal("expected ban expired")
+	}
+
+	_ = f.Ban("4.4.4.4", 0)
+	_ = f.Unban("4.4.4.4")
+
+	if f.IsBlack(addr("4.4.4.4")) {
+		t.Fatal("expected 4.4.4.4 unbanned")
+	}
+}
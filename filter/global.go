@@ -0,0 +1,66 @@
+package filter
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	once         sync.Once
+	globalFilter atomic.Value // *Filter
+)
+
+// SetFilter 设置全局过滤器
+func SetFilter(f *Filter) {
+	once.Do(func() {})
+	globalFilter.Store(f)
+}
+
+// GetFilter 获取全局过滤器，未设置时按配置创建默认过滤器
+func GetFilter() *Filter {
+	once.Do(func() { globalFilter.Store(NewFilter()) })
+
+	return globalFilter.Load().(*Filter)
+}
+
+// BlackListCheck 检测地址是否在黑名单中或处于封禁期
+func BlackListCheck(addr net.Addr) bool {
+	return GetFilter().IsBlack(addr)
+}
+
+// WriteListCheck 检测地址是否在白名单中，白名单仅豁免单IP连接数限制
+func WriteListCheck(addr net.Addr) bool {
+	return GetFilter().IsWhite(addr)
+}
+
+// IpConnectCountCheck 检测地址的存活连接数是否已达上限
+func IpConnectCountCheck(addr net.Addr) bool {
+	return GetFilter().IsOverMaxConn(addr)
+}
+
+// TryConnect 检测地址的存活连接数是否已达上限，未达上限时记录连接建立
+func TryConnect(addr net.Addr) bool {
+	return GetFilter().TryConnect(addr)
+}
+
+// Connect 记录连接建立
+func Connect(addr net.Addr) {
+	GetFilter().Connect(addr)
+}
+
+// Disconnect 记录连接断开
+func Disconnect(addr net.Addr) {
+	GetFilter().Disconnect(addr)
+}
+
+// Ban 封禁IP，duration小于等于0时永久封禁
+func Ban(ip string, duration time.Duration) error {
+	return GetFilter().Ban(ip, duration)
+}
+
+// Unban 解除封禁
+func Unban(ip string) error {
+	return GetFilter().Unban(ip)
+}
@@ -1,7 +0,0 @@
-package filter
-
-import "net"
-
-func IpConnectCountCheck(addr net.Addr) bool {
-	return false
-}
@@ -0,0 +1,76 @@
+package filter
+
+import (
+	"gatesvr/config"
+	"gatesvr/etc"
+)
+
+const (
+	defaultMaxConnPerIP = 0
+)
+
+const (
+	defaultBlacklistKey    = "etc.filter.blacklist"
+	defaultWhitelistKey    = "etc.filter.whitelist"
+	defaultMaxConnPerIPKey = "etc.filter.maxConnPerIP"
+)
+
+const (
+	configName         = "filter"
+	configBlacklistKey = "filter.blacklist"
+	configWhitelistKey = "filter.whitelist"
+	configMaxConnKey   = "filter.maxConnPerIP"
+)
+
+type Option func(o *options)
+
+type options struct {
+	blacklist    []string // 黑名单，支持IP与CIDR
+	whitelist    []string // 白名单，支持IP与CIDR，白名单内的IP不受单IP连接数限制，黑名单及封禁优先于白名单
+	maxConnPerIP int      // 单IP最大连接数，0为不限制
+	watch        bool     // 是否监听config配置中心的变更
+}
+
+func defaultOptions() *options {
+	return &options{
+		blacklist:    etc.Get(defaultBlacklistKey).Strings(),
+		whitelist:    etc.Get(defaultWhitelistKey).Strings(),
+		maxConnPerIP: etc.Get(defaultMaxConnPerIPKey, defaultMaxConnPerIP).Int(),
+		watch:        true,
+	}
+}
+
+// 使用config配置中心的配置覆盖
+func (o *options) override() {
+	if config.Has(configBlacklistKey) {
+		o.blacklist = config.Get(configBlacklistKey).Strings()
+	}
+
+	if config.Has(configWhitelistKey) {
+		o.whitelist = config.Get(configWhitelistKey).Strings()
+	}
+
+	if config.Has(configMaxConnKey) {
+		o.maxConnPerIP = config.Get(configMaxConnKey).Int()
+	}
+}
+
+// WithBlacklist 设置黑名单
+func WithBlacklist(blacklist ...string) Option {
+	return func(o *options) { o.blacklist = blacklist }
+}
+
+// WithWhitelist 设置白名单
+func WithWhitelist(whitelist ...string) Option {
+	return func(o *options) { o.whitelist = whitelist }
+}
+
+// WithMaxConnPerIP 设置单IP最大连接数
+func WithMaxConnPerIP(maxConnPerIP int) Option {
+	return func(o *options) { o.maxConnPerIP = maxConnPerIP }
+}
+
+// WithWatch 设置是否监听config配置中心的变更
+func WithWatch(watch bool) Option {
+	return func(o *options) { o.watch = watch }
+}
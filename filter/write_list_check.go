@@ -1,7 +0,0 @@
-package filter
-
-import "net"
-
-func WriteListCheck(addr net.Addr) bool {
-	return true
-}
@@ -31,10 +31,14 @@ func main() {
 	locator := redis.NewLocator()
 	// 创建服务发现
 	registry := etcd.NewRegistry()
+	// 单进程部署或测试时，网关与节点可共用内存定位器与服务发现
+	//locator := memoryLocate.NewLocator()
+	//registry := memoryRegistry.NewRegistry()
 	//创建压缩器
 	//compressor := lz4Compressor.NewCompressor()
 	//创建限流器
-	//limiter := tokenbucket.NewTokenBucketRateLimtImpl(1, 1)
+	//limiter := keyed.NewLimiter(keyed.WithDimension(keyed.UID))
+	//breaker := circuitbreaker.NewGroup(3, 0.5, 3*time.Second)
 	// 创建网关组件
 	component := gate.NewGate(
 		gate.WithServer(server),
@@ -43,9 +47,14 @@ func main() {
 		gate.WithEncryptor(encryptor),
 		//gate.WithCompressor(compressor),
 		//gate.WithLimiter(limiter),
+		//gate.WithCircuitBreaker(breaker),
 	)
 	// 添加网关组件
 	gateSvr.Add(component)
+	// 添加管理组件
+	//gateSvr.Add(admin.NewAdmin(admin.WithProvider(component)))
+	// 添加指标组件
+	//gateSvr.Add(metrics.NewMetrics())
 	// 启动容器
 	gateSvr.Serve()
 }
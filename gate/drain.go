@@ -0,0 +1,184 @@
+package gate
+
+import (
+	"context"
+	"gatesvr/cluster"
+	"gatesvr/core/net"
+	"gatesvr/log"
+	"gatesvr/network"
+	"gatesvr/packet"
+	"gatesvr/session"
+	"gatesvr/utils/codes"
+	"gatesvr/utils/xcall"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	drainReportInterval = 5 * time.Second // 排空进度上报间隔
+)
+
+// 网关排空器
+// 关闭网关时停止接入新连接，通知客户端重连至其他工作中的网关，到期后强制关闭剩余连接
+type drainer struct {
+	gate     *Gate
+	draining atomic.Bool
+	notice   atomic.Pointer[[]byte] // 已打包的迁移通知
+	rejected sync.Map               // 排空期间拒绝的连接（cid -> struct{}）
+}
+
+func newDrainer(gate *Gate) *drainer {
+	return &drainer{gate: gate}
+}
+
+// 是否处于排空中
+func (d *drainer) isDraining() bool {
+	return d.draining.Load()
+}
+
+// 排空期间拒绝新连接，下发迁移通知后关闭连接，返回true时连接已被拒绝
+// 被拒绝的连接尚未握手，迁移通知直接通过连接以明文下发，不经过会话的发送检查
+func (d *drainer) reject(cid int64) bool {
+	if !d.isDraining() {
+		return false
+	}
+
+	d.rejected.Store(cid, struct{}{})
+
+	if notice := d.notice.Load(); notice != nil {
+		if conn, err := d.gate.session.FindConn(session.Conn, cid); err == nil {
+			_ = conn.Push(*notice)
+		}
+	}
+
+	xcall.Go(func() { _ = d.gate.session.Close(session.Conn, cid) })
+
+	return true
+}
+
+// 是否为被拒绝的连接
+func (d *drainer) isRejected(cid int64) bool {
+	_, ok := d.rejected.Load(cid)
+	return ok
+}
+
+// 移除被拒绝的连接，连接为被拒绝的连接时返回true
+func (d *drainer) dropRejected(cid int64) bool {
+	_, ok := d.rejected.LoadAndDelete(cid)
+	return ok
+}
+
+// 排空网关，直至所有连接关闭
+// 先暂停接入新连接再通知客户端迁移，避免等待连接关闭期间仍有新连接加入
+func (d *drainer) drain() {
+	if pauser, ok := d.gate.opts.server.(network.Pauser); ok {
+		pauser.Pause()
+	}
+
+	d.draining.Store(true)
+
+	total, _ := d.gate.session.Stat(session.Conn)
+	log.Infof("gate draining, connections: %d timeout: %v", total, d.gate.opts.drainTimeout)
+
+	if d.gate.opts.drainRoute >= 0 {
+		d.notify()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		d.gate.wg.Wait()
+		close(done)
+	}()
+
+	var deadline <-chan time.Time
+	if d.gate.opts.drainTimeout > 0 {
+		timer := time.NewTimer(d.gate.opts.drainTimeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	ticker := time.NewTicker(drainReportInterval)
+	defer ticker.Stop()
+
+	start := time.Now()
+
+	for {
+		select {
+		case <-done:
+			log.Infof("gate drained, elapsed: %v", time.Since(start))
+			return
+		case <-ticker.C:
+			remaining, _ := d.gate.session.Stat(session.Conn)
+			log.Infof("gate draining, remaining connections: %d elapsed: %v", remaining, time.Since(start))
+		case <-deadline:
+			deadline = nil
+			n := d.gate.session.CloseAll(true)
+			log.Warnf("gate drain timeout, force closed %d remaining connections", n)
+		}
+	}
+}
+
+// 通知所有客户端迁移至其他工作中的网关
+func (d *drainer) notify() {
+	addrs := d.redirects()
+
+	buffer, err := d.gate.opts.codec.Marshal(&packet.Migration{
+		Code:    codes.ServiceUnavailable.Code(),
+		Message: "gate is draining, please reconnect",
+		Addrs:   addrs,
+	})
+	if err != nil {
+		log.Errorf("marshal migration notice failed: %v", err)
+		return
+	}
+
+	notice, err := d.gate.proxy.packToClient(0, d.gate.opts.drainRoute, buffer)
+	if err != nil {
+		log.Errorf("pack migration notice failed: %v", err)
+		return
+	}
+
+	d.notice.Store(&notice)
+
+	n, _ := d.gate.session.Broadcast(session.Conn, notice)
+
+	log.Infof("gate migration notice sent, clients: %d redirects: %v", n, addrs)
+}
+
+// 获取其他工作中网关的客户端接入地址
+func (d *drainer) redirects() []string {
+	ctx, cancel := context.WithTimeout(d.gate.ctx, d.gate.opts.timeout)
+	defer cancel()
+
+	services, err := d.gate.opts.registry.Services(ctx, cluster.Gate.String())
+	if err != nil {
+		log.Warnf("fetch gate instances failed: %v", err)
+		return nil
+	}
+
+	addrs := make([]string, 0, len(services))
+	for _, ins := range services {
+		if ins.ID == d.gate.opts.id || ins.State != cluster.Work.String() || ins.Address == "" {
+			continue
+		}
+
+		addrs = append(addrs, ins.Address)
+	}
+
+	return addrs
+}
+
+// 获取客户端接入地址
+func (g *Gate) exposeAddr() string {
+	if g.opts.exposeAddr != "" {
+		return g.opts.exposeAddr
+	}
+
+	_, addr, err := net.ParseAddr(g.opts.server.Addr())
+	if err != nil {
+		return ""
+	}
+
+	return addr
+}
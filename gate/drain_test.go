@@ -0,0 +1,228 @@
+package gate
+
+import (
+	"context"
+	"gatesvr/cluster"
+	"gatesvr/errors"
+	"gatesvr/network"
+	"gatesvr/packet"
+	"gatesvr/registry"
+	"gatesvr/registry/memory"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 关闭时从会话中移除的连接
+type drainConn struct {
+	testConn
+	gate   *Gate
+	closed atomic.Bool
+}
+
+func (c *drainConn) Close(_ ...bool) error {
+	if c.closed.CompareAndSwap(false, true) {
+		c.gate.session.RemConn(c)
+		c.gate.wg.Done()
+	}
+
+	return nil
+}
+
+func newDrainGate(t *testing.T, opts ...Option) *Gate {
+	reg := memory.NewRegistry()
+
+	for _, ins := range []*registry.ServiceInstance{
+		{ID: "gate-1", Name: cluster.Gate.String(), State: cluster.Hang.String(), Address: "10.0.0.1:3553"},
+		{ID: "gate-2", Name: cluster.Gate.String(), State: cluster.Work.String(), Address: "10.0.0.2:3553"},
+		{ID: "gate-3", Name: cluster.Gate.String(), State: cluster.Busy.String(), Address: "10.0.0.3:3553"},
+	} {
+		if err := reg.Register(context.Background(), ins); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g := NewGate(append([]Option{WithID("gate-1"), WithRegistry(reg), WithDrainRoute(7)}, opts...)...)
+
+	return g
+}
+
+func addDrainConn(g *Gate, cid int64) *drainConn {
+	conn := &drainConn{testConn: testConn{id: cid}, gate: g}
+	g.wg.Add(1)
+	g.session.AddConn(conn)
+
+	return conn
+}
+
+func TestDrainer_Notify(t *testing.T) {
+	g := newDrainGate(t)
+	conn := addDrainConn(g, 1)
+
+	g.drainer.notify()
+
+	if len(conn.pushed) != 1 {
+		t.Fatalf("expected migration notice, got %d messages", len(conn.pushed))
+	}
+
+	msg, err := packet.UnpackMessage(conn.pushed[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if msg.Route != 7 {
+		t.Fatalf("unexpected route: %d", msg.Route)
+	}
+
+	migration := &packet.Migration{}
+	if err = g.opts.codec.Unmarshal(msg.Buffer, migration); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migration.Addrs) != 1 || migration.Addrs[0] != "10.0.0.2:3553" {
+		t.Fatalf("unexpected redirects: %v", migration.Addrs)
+	}
+}
+
+func TestDrainer_Reject(t *testing.T) {
+	g := newDrainGate(t)
+
+	if g.drainer.reject(1) {
+		t.Fatal("expected connection accepted before draining")
+	}
+
+	g.drainer.draining.Store(true)
+	g.drainer.notify()
+
+	conn := addDrainConn(g, 2)
+	if !g.drainer.reject(conn.id) {
+		t.Fatal("expected connection rejected while draining")
+	}
+
+	if len(conn.pushed) != 1 {
+		t.Fatalf("expected migration notice, got %d messages", len(conn.pushed))
+	}
+
+	if !g.drainer.isRejected(conn.id) || !g.drainer.dropRejected(conn.id) || g.drainer.isRejected(conn.id) {
+		t.Fatal("unexpected rejected state")
+	}
+}
+
+func TestDrainer_RejectBeforeHandshake(t *testing.T) {
+	g := newDrainGate(t, WithHandshakeRoute(1))
+
+	g.drainer.draining.Store(true)
+	g.drainer.notify()
+
+	conn := addDrainConn(g, 1)
+	if !g.drainer.reject(conn.id) {
+		t.Fatal("expected connection rejected while draining")
+	}
+
+	if len(conn.pushed) != 1 {
+		t.Fatalf("expected migration notice, got %d messages", len(conn.pushed))
+	}
+
+	if msg, err := packet.UnpackMessage(conn.pushed[0]); err != nil || msg.Route != 7 {
+		t.Fatalf("unexpected migration notice, err: %v", err)
+	}
+}
+
+// 可暂停接入新连接的服务器
+type pauseServer struct {
+	network.Server
+	paused atomic.Bool
+}
+
+func (s *pauseServer) Pause() { s.paused.Store(true) }
+
+func TestDrainer_PauseServer(t *testing.T) {
+	server := &pauseServer{}
+	g := newDrainGate(t, WithServer(server), WithDrainTimeout(50*time.Millisecond))
+
+	g.drainer.drain()
+
+	if !server.paused.Load() {
+		t.Fatal("expected server paused before draining")
+	}
+}
+
+func TestDrainer_Timeout(t *testing.T) {
+	g := newDrainGate(t, WithDrainTimeout(50*time.Millisecond))
+	conns := []*drainConn{addDrainConn(g, 1), addDrainConn(g, 2)}
+
+	done := make(chan struct{})
+	go func() {
+		g.drainer.drain()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("drain timeout")
+	}
+
+	for _, conn := range conns {
+		if !conn.closed.Load() {
+			t.Fatalf("connection %d is not closed", conn.id)
+		}
+	}
+}
+
+func TestGate_CloseOnce(t *testing.T) {
+	g := newDrainGate(t, WithDrainTimeout(50*time.Millisecond))
+	g.state.Store(int32(cluster.Work))
+
+	g.Close()
+
+	if !g.drainer.isDraining() || g.getState() != cluster.Hang {
+		t.Fatal("expected gate draining after close")
+	}
+
+	g.drainer.draining.Store(false)
+
+	g.Close()
+
+	if g.drainer.isDraining() {
+		t.Fatal("expected second close not to drain again")
+	}
+}
+
+// 注册始终失败的注册中心
+type failRegistry struct {
+	registry.Registry
+}
+
+func (r *failRegistry) Register(_ context.Context, _ *registry.ServiceInstance) error {
+	return errors.New("registry unavailable")
+}
+
+func TestGate_SetStateWhileClosing(t *testing.T) {
+	g := newDrainGate(t, WithDrainTimeout(50*time.Millisecond))
+	g.state.Store(int32(cluster.Work))
+
+	g.Close()
+
+	if err := g.SetState(cluster.Work); err != errors.ErrIllegalOperation {
+		t.Fatalf("expected illegal operation, got %v", err)
+	}
+
+	if g.getState() != cluster.Hang {
+		t.Fatalf("unexpected state: %v", g.getState())
+	}
+}
+
+func TestGate_SetStateRollback(t *testing.T) {
+	g := NewGate(WithID("gate-1"), WithRegistry(&failRegistry{}))
+	g.state.Store(int32(cluster.Work))
+	g.instance = &registry.ServiceInstance{ID: "gate-1", State: cluster.Work.String()}
+
+	if err := g.SetState(cluster.Busy); err == nil {
+		t.Fatal("expected refresh error")
+	}
+
+	if g.getState() != cluster.Work || g.instance.State != cluster.Work.String() {
+		t.Fatalf("expected state rolled back, got %v/%s", g.getState(), g.instance.State)
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"gatesvr/component"
 	"gatesvr/core/info"
 	"gatesvr/core/net"
+	"gatesvr/errors"
 	"gatesvr/internal/transporter/gate"
+	"gatesvr/limite"
 	"gatesvr/log"
 	"gatesvr/network"
 	"gatesvr/registry"
@@ -22,7 +24,12 @@ type Gate struct {
 	ctx      context.Context
 	cancel   context.CancelFunc
 	state    atomic.Int32
+	closing  atomic.Bool // 是否已开始关闭，保证排空流程仅执行一次
+	rw       sync.Mutex  // 串行化状态切换与实例状态的刷新
 	proxy    *proxy
+	resumer  *resumer
+	shaker   *handshaker
+	drainer  *drainer
 	instance *registry.ServiceInstance
 	session  *session.Session
 	linker   *gate.Server
@@ -39,7 +46,14 @@ func NewGate(opts ...Option) *Gate {
 	g.opts = o
 	g.ctx, g.cancel = context.WithCancel(o.ctx)
 	g.proxy = newProxy(g)
+	g.resumer = newResumer(g)
 	g.session = session.NewSession()
+	g.shaker = newHandshaker(g)
+	g.drainer = newDrainer(g)
+
+	if g.shaker.enabled() {
+		g.session.SetChecker(g.shaker.check)
+	}
 	g.state.Store(int32(cluster.Shut))
 	g.wg = &sync.WaitGroup{}
 
@@ -86,17 +100,38 @@ func (g *Gate) Start() {
 
 }
 
-// Close 关闭节点
+// Close 关闭节点，排空网关：停止接入新连接并通知客户端迁移，等待连接关闭或排空期限到期
 func (g *Gate) Close() {
+	if g.getState() == cluster.Shut || !g.closing.CompareAndSwap(false, true) {
+		return
+	}
+
+	if !g.hang() {
+		return
+	}
+
+	g.drainer.drain()
+
+	g.resumer.close()
+}
+
+// 挂起网关并刷新实例状态，与SetState互斥
+func (g *Gate) hang() bool {
+	g.rw.Lock()
+	defer g.rw.Unlock()
+
 	if !g.state.CompareAndSwap(int32(cluster.Work), int32(cluster.Hang)) {
 		if !g.state.CompareAndSwap(int32(cluster.Busy), int32(cluster.Hang)) {
-			return
+			// 已通过SetState挂起的网关仍需完成关闭流程
+			if g.getState() != cluster.Hang {
+				return false
+			}
 		}
 	}
 
 	g.refreshServiceInstance()
 
-	g.wg.Wait()
+	return true
 }
 
 // Destroy 销毁组件
@@ -141,6 +176,14 @@ func (g *Gate) handleConnect(conn network.Conn) {
 
 	cid, uid := conn.ID(), conn.UID()
 
+	if g.drainer.reject(cid) {
+		return
+	}
+
+	if g.resumer.deferConnect(cid) {
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(g.ctx, g.opts.timeout)
 	g.proxy.trigger(ctx, cluster.Connect, cid, uid)
 	cancel()
@@ -148,14 +191,25 @@ func (g *Gate) handleConnect(conn network.Conn) {
 
 // 处理断开连接
 func (g *Gate) handleDisconnect(conn network.Conn) {
-	g.session.RemConn(conn)
+	groups := g.session.RemConn(conn)
+
+	if evictor, ok := g.opts.limiter.(limite.Evictor); ok {
+		ip, _ := conn.RemoteIP()
+		evictor.Evict(limite.Key{CID: conn.ID(), UID: conn.UID(), IP: ip})
+	}
 	//log.Debugf("gate disconnect: %v, cid = %v, uid = %v", conn, conn.ID(), conn.UID())
 
-	if cid, uid := conn.ID(), conn.UID(); uid != 0 {
-		ctx, cancel := context.WithTimeout(g.ctx, g.opts.timeout)
-		_ = g.proxy.unbindGate(ctx, cid, uid)
-		g.proxy.trigger(ctx, cluster.Disconnect, cid, uid)
-		cancel()
+	if cid, uid := conn.ID(), conn.UID(); g.drainer.dropRejected(cid) {
+		// 排空期间拒绝的连接，未触发连接事件
+	} else if g.resumer.dropConnect(cid) {
+		// 连接事件未触发，无需触发断开事件
+	} else if uid != 0 {
+		if !g.resumer.hold(cid, uid, groups) {
+			ctx, cancel := context.WithTimeout(g.ctx, g.opts.timeout)
+			_ = g.proxy.unbindGate(ctx, cid, uid)
+			g.proxy.trigger(ctx, cluster.Disconnect, cid, uid)
+			cancel()
+		}
 	} else {
 		ctx, cancel := context.WithTimeout(g.ctx, g.opts.timeout)
 		g.proxy.trigger(ctx, cluster.Disconnect, cid, uid)
@@ -169,6 +223,10 @@ func (g *Gate) handleDisconnect(conn network.Conn) {
 func (g *Gate) handleReceive(conn network.Conn, data []byte) {
 	//
 	cid, uid := conn.ID(), conn.UID()
+	if g.drainer.isRejected(cid) {
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(g.ctx, g.opts.timeout)
 	g.proxy.deliver(ctx, cid, uid, data)
 	cancel()
@@ -199,6 +257,9 @@ func (g *Gate) stopLinkerServer() {
 }
 
 func (g *Gate) registerServiceInstance() {
+	g.rw.Lock()
+	defer g.rw.Unlock()
+
 	g.instance = &registry.ServiceInstance{
 		ID:       g.opts.id,
 		Name:     cluster.Gate.String(),
@@ -207,6 +268,7 @@ func (g *Gate) registerServiceInstance() {
 		State:    g.getState().String(),
 		Weight:   g.opts.weight,
 		Endpoint: g.linker.Endpoint().String(),
+		Address:  g.exposeAddr(),
 	}
 
 	ctx, cancel := context.WithTimeout(g.ctx, defaultTimeout)
@@ -219,8 +281,15 @@ func (g *Gate) registerServiceInstance() {
 
 // 刷新服务实例状态
 func (g *Gate) refreshServiceInstance() {
+	if err := g.tryRefreshServiceInstance(); err != nil {
+		log.Fatalf("refresh cluster instance failed: %v", err)
+	}
+}
+
+// 尝试刷新服务实例状态，调用方需持有g.rw
+func (g *Gate) tryRefreshServiceInstance() error {
 	if g.instance == nil {
-		return
+		return nil
 	}
 
 	g.instance.State = g.getState().String()
@@ -228,9 +297,7 @@ func (g *Gate) refreshServiceInstance() {
 	ctx, cancel := context.WithTimeout(g.ctx, defaultTimeout)
 	defer cancel()
 
-	if err := g.opts.registry.Register(ctx, g.instance); err != nil {
-		log.Fatalf("refresh cluster instance failed: %v", err)
-	}
+	return g.opts.registry.Register(ctx, g.instance)
 }
 
 // 解注册服务实例
@@ -248,6 +315,70 @@ func (g *Gate) getState() cluster.State {
 	return cluster.State(g.state.Load())
 }
 
+// Session 获取会话
+func (g *Gate) Session() *session.Session {
+	return g.session
+}
+
+// Kick 断开连接，主动断开的连接不允许恢复会话
+func (g *Gate) Kick(kind session.Kind, target int64, force bool) error {
+	if conn, err := g.session.FindConn(kind, target); err == nil && conn.UID() != 0 {
+		g.resumer.revoke(conn.UID())
+	}
+
+	return g.session.Close(kind, target, force)
+}
+
+// Topology 获取节点拓扑，包括路由、事件、端点及节点实例
+func (g *Gate) Topology() string {
+	return g.proxy.nodeLinker.Dispatcher().String()
+}
+
+// State 获取网关状态
+func (g *Gate) State() cluster.State {
+	return g.getState()
+}
+
+// SetState 设置网关状态，并刷新注册中心中的实例状态
+// 网关关闭或排空期间不允许设置状态；刷新失败时回滚状态并返回错误
+func (g *Gate) SetState(state cluster.State) error {
+	switch state {
+	case cluster.Work, cluster.Busy, cluster.Hang:
+	default:
+		return errors.ErrInvalidArgument
+	}
+
+	g.rw.Lock()
+	defer g.rw.Unlock()
+
+	if g.closing.Load() || g.drainer.isDraining() {
+		return errors.ErrIllegalOperation
+	}
+
+	old := g.getState()
+	if old == cluster.Shut {
+		return errors.ErrIllegalOperation
+	}
+
+	if old == state {
+		return nil
+	}
+
+	if !g.state.CompareAndSwap(int32(old), int32(state)) {
+		return errors.ErrIllegalOperation
+	}
+
+	if err := g.tryRefreshServiceInstance(); err != nil {
+		g.state.CompareAndSwap(int32(state), int32(old))
+		if g.instance != nil {
+			g.instance.State = old.String()
+		}
+		return err
+	}
+
+	return nil
+}
+
 // 打印组件信息
 func (g *Gate) printInfo() {
 	infos := make([]string, 0, 6)
@@ -0,0 +1,116 @@
+package gate
+
+import (
+	"gatesvr/crypto/ecdh"
+	"gatesvr/errors"
+	"gatesvr/log"
+	"gatesvr/network"
+	"gatesvr/packet"
+	"gatesvr/session"
+)
+
+// 连接握手器
+// 客户端与网关交换临时ECDH公钥，为每个连接协商出独立的会话密钥，握手完成前不收发业务消息
+// 握手请求：| 客户端公钥 |
+// 握手响应：| 网关公钥 | 签名（可选，对客户端公钥与网关公钥签名） |
+type handshaker struct {
+	gate *Gate
+}
+
+func newHandshaker(gate *Gate) *handshaker {
+	h := &handshaker{gate: gate}
+
+	if h.enabled() && gate.opts.encryptor != nil {
+		log.Warnf("handshake is enabled, the %s encryptor will be ignored", gate.opts.encryptor.Name())
+		gate.opts.encryptor = nil
+	}
+
+	return h
+}
+
+// 是否启用握手
+func (h *handshaker) enabled() bool {
+	return h.gate.opts.handshakeRoute >= 0
+}
+
+// 处理握手请求
+func (h *handshaker) handle(cid int64, msg *packet.Message) error {
+	conn, err := h.gate.session.FindConn(session.Conn, cid)
+	if err != nil {
+		return err
+	}
+
+	// 同一连接不允许重复握手
+	if conn.Cipher() != nil {
+		return errors.ErrHandshakeFailed
+	}
+
+	key, err := ecdh.GenerateKey()
+	if err != nil {
+		return err
+	}
+
+	cipher, err := key.Negotiate(ecdh.Server, msg.Buffer, ecdh.WithRotation(h.gate.opts.handshakeRotation))
+	if err != nil {
+		return err
+	}
+
+	buffer := key.PublicKey()
+
+	if h.gate.opts.handshakeSigner != nil {
+		signature, err := h.gate.opts.handshakeSigner.Sign(append(append([]byte{}, msg.Buffer...), buffer...))
+		if err != nil {
+			return err
+		}
+		buffer = append(buffer, signature...)
+	}
+
+	reply, err := packet.PackMessage(&packet.Message{
+		Seq:    msg.Seq,
+		Route:  msg.Route,
+		Buffer: buffer,
+	})
+	if err != nil {
+		return err
+	}
+
+	// 先以明文下发响应再设置会话密钥，响应在当前读取协程中投递，客户端收到响应后发出的消息均在设置会话密钥后读取
+	if err = conn.Push(reply); err != nil {
+		return err
+	}
+
+	conn.SetCipher(cipher)
+
+	return nil
+}
+
+// 使用会话密钥解密客户端消息
+func (h *handshaker) open(cid int64, msg *packet.Message) error {
+	conn, err := h.gate.session.FindConn(session.Conn, cid)
+	if err != nil {
+		return err
+	}
+
+	cipher := conn.Cipher()
+	if cipher == nil {
+		return errors.ErrHandshakeNotCompleted
+	}
+
+	buffer, err := cipher.Open(msg.Seq, msg.Buffer)
+	if err != nil {
+		return err
+	}
+
+	msg.Buffer = buffer
+
+	return nil
+}
+
+// 检查连接握手是否已完成，作为会话的发送检查器，消息由连接以会话密钥加密
+func (h *handshaker) check(conn network.Conn) error {
+	if conn.Cipher() == nil {
+		return errors.ErrHandshakeNotCompleted
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package gate
+
+import (
+	"gatesvr/crypto/ecdh"
+	"gatesvr/errors"
+	"gatesvr/network"
+	"gatesvr/packet"
+	"gatesvr/session"
+	"testing"
+)
+
+type testConn struct {
+	network.Conn
+	id     int64
+	pushed [][]byte
+	cipher network.Cipher
+}
+
+func (c *testConn) ID() int64 { return c.id }
+
+func (c *testConn) UID() int64 { return 0 }
+
+// Push 与真实连接一致，握手完成后以会话密钥加密消息
+func (c *testConn) Push(msg []byte) (err error) {
+	if c.cipher != nil {
+		if msg, err = packet.SealMessage(msg, c.cipher.Seal); err != nil {
+			return
+		}
+	}
+
+	c.pushed = append(c.pushed, msg)
+	return nil
+}
+
+func (c *testConn) SetCipher(cipher network.Cipher) { c.cipher = cipher }
+
+func (c *testConn) Cipher() network.Cipher { return c.cipher }
+
+func handshake(t *testing.T, g *Gate, conn *testConn) *ecdh.Cipher {
+	key, err := ecdh.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = g.shaker.handle(conn.id, &packet.Message{Route: g.opts.handshakeRoute, Buffer: key.PublicKey()}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(conn.pushed) != 1 {
+		t.Fatalf("expected handshake reply, got %d messages", len(conn.pushed))
+	}
+
+	reply, err := packet.UnpackMessage(conn.pushed[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cipher, err := key.Negotiate(ecdh.Client, reply.Buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cipher
+}
+
+func TestHandshaker_Handshake(t *testing.T) {
+	g := NewGate(WithID("gate-1"), WithHandshakeRoute(1))
+	conn := &testConn{id: 1}
+	g.session.AddConn(conn)
+
+	if err := g.session.Push(session.Conn, conn.id, []byte("plain")); !errors.Is(err, errors.ErrHandshakeNotCompleted) {
+		t.Fatalf("expected ErrHandshakeNotCompleted, got %v", err)
+	}
+
+	cipher := handshake(t, g, conn)
+
+	// 客户端 -> 网关
+	buffer, err := cipher.Seal(10, []byte("hello gate"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := &packet.Message{Seq: 10, Route: 2, Buffer: buffer}
+	if err = g.shaker.open(conn.id, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if string(msg.Buffer) != "hello gate" {
+		t.Fatalf("unexpected message: %s", msg.Buffer)
+	}
+
+	if err = g.shaker.open(conn.id, &packet.Message{Seq: 10, Route: 2, Buffer: buffer}); !errors.Is(err, errors.ErrReplayedMessage) {
+		t.Fatalf("expected ErrReplayedMessage, got %v", err)
+	}
+
+	// 网关 -> 客户端
+	data, err := packet.PackMessage(&packet.Message{Seq: 11, Route: 2, Buffer: []byte("hello client")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = g.session.Push(session.Conn, conn.id, data); err != nil {
+		t.Fatal(err)
+	}
+
+	pushed, err := packet.UnpackMessage(conn.pushed[len(conn.pushed)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext, err := cipher.Open(pushed.Seq, pushed.Buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(plaintext) != "hello client" {
+		t.Fatalf("unexpected message: %s", plaintext)
+	}
+}
+
+func TestHandshaker_Repeat(t *testing.T) {
+	g := NewGate(WithID("gate-1"), WithHandshakeRoute(1))
+	conn := &testConn{id: 1}
+	g.session.AddConn(conn)
+
+	handshake(t, g, conn)
+
+	key, err := ecdh.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = g.shaker.handle(conn.id, &packet.Message{Route: 1, Buffer: key.PublicKey()}); !errors.Is(err, errors.ErrHandshakeFailed) {
+		t.Fatalf("expected ErrHandshakeFailed, got %v", err)
+	}
+}
+
+func TestHandshaker_Disabled(t *testing.T) {
+	g := NewGate(WithID("gate-1"))
+	conn := &testConn{id: 1}
+	g.session.AddConn(conn)
+
+	if g.shaker.enabled() {
+		t.Fatal("expected handshake disabled by default")
+	}
+
+	if err := g.session.Push(session.Conn, conn.id, []byte("plain")); err != nil {
+		t.Fatal(err)
+	}
+}
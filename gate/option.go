@@ -7,6 +7,7 @@ import (
 	"gatesvr/crypto"
 	"gatesvr/encoding"
 	"gatesvr/etc"
+	"gatesvr/internal/dispatcher"
 	"gatesvr/limite"
 	"gatesvr/locate"
 	"gatesvr/locate/redis"
@@ -17,36 +18,68 @@ import (
 )
 
 const (
-	defaultName    = "gate"          // 默认名称
-	defaultAddr    = ":0"            // 连接器监听地址
-	defaultTimeout = 3 * time.Second // 默认超时时间
-	defaultWeight  = 1
-	defaultCodec   = "json" // 默认编解码器名称// 默认权重
+	defaultName              = "gate"          // 默认名称
+	defaultAddr              = ":0"            // 连接器监听地址
+	defaultTimeout           = 3 * time.Second // 默认超时时间
+	defaultWeight            = 1
+	defaultCodec             = "json"  // 默认编解码器名称// 默认权重
+	defaultResumeRoute       = -1      // 默认会话恢复路由
+	defaultCompressThreshold = 0       // 默认压缩阈值，0为全部压缩
+	defaultHandshakeRoute    = -1      // 默认握手路由
+	defaultHandshakeRotation = 1 << 16 // 默认会话密钥轮换间隔（消息数）
+	defaultDrainRoute        = -1      // 默认迁移通知路由
+	defaultResumeWait        = "1s"    // 默认会话恢复等待时间
 )
 
 const (
-	defaultIDKey      = "etc.cluster.gate.id"
-	defaultNameKey    = "etc.cluster.gate.name"
-	defaultAddrKey    = "etc.cluster.gate.addr"
-	defaultTimeoutKey = "etc.cluster.gate.timeout"
-	defaultWeightKey  = "etc.cluster.gate.weight"
+	defaultIDKey                = "etc.cluster.gate.id"
+	defaultNameKey              = "etc.cluster.gate.name"
+	defaultAddrKey              = "etc.cluster.gate.addr"
+	defaultTimeoutKey           = "etc.cluster.gate.timeout"
+	defaultWeightKey            = "etc.cluster.gate.weight"
+	defaultResumeGraceKey       = "etc.cluster.gate.resume.grace"
+	defaultResumeRouteKey       = "etc.cluster.gate.resume.route"
+	defaultResumeSecretKey      = "etc.cluster.gate.resume.secret"
+	defaultResumeWaitKey        = "etc.cluster.gate.resume.wait"
+	defaultCompressorKey        = "etc.cluster.gate.compressor"
+	defaultCompressThresholdKey = "etc.cluster.gate.compressThreshold"
+	defaultDecompressLimitKey   = "etc.cluster.gate.decompressLimit"
+	defaultHandshakeRouteKey    = "etc.cluster.gate.handshake.route"
+	defaultHandshakeRotationKey = "etc.cluster.gate.handshake.rotation"
+	defaultBalanceStrategyKey   = "etc.cluster.gate.balanceStrategy"
+	defaultExposeAddrKey        = "etc.cluster.gate.exposeAddr"
+	defaultDrainTimeoutKey      = "etc.cluster.gate.drain.timeout"
+	defaultDrainRouteKey        = "etc.cluster.gate.drain.route"
 )
 
 type options struct {
-	ctx            context.Context                // 上下文
-	id             string                         // 实例ID
-	name           string                         // 实例名称
-	addr           string                         // 监听地址
-	timeout        time.Duration                  // RPC调用超时时间
-	weight         int                            // 权重
-	server         network.Server                 // 网关服务器
-	locator        locate.Locator                 // 用户定位器
-	registry       registry.Registry              // 服务注册器
-	encryptor      crypto.Encryptor               // 消息加密器
-	compressor     compress.Compressor            // 消息压缩器
-	limiter        limite.Limiter                 // 限流器
-	circutibreaker *circuitbreaker.CircuitBreaker // 熔断器
-	codec          encoding.Codec                 // 编解码器
+	ctx               context.Context            // 上下文
+	id                string                     // 实例ID
+	name              string                     // 实例名称
+	addr              string                     // 监听地址
+	timeout           time.Duration              // RPC调用超时时间
+	weight            int                        // 权重
+	server            network.Server             // 网关服务器
+	locator           locate.Locator             // 用户定位器
+	registry          registry.Registry          // 服务注册器
+	encryptor         crypto.Encryptor           // 消息加密器
+	compressor        compress.Compressor        // 消息压缩器
+	compressThreshold int                        // 压缩阈值，消息长度小于该值时不压缩
+	decompressLimit   int                        // 客户端消息解压后的最大长度，0为使用打包器的消息体最大字节数
+	limiter           limite.Limiter             // 限流器
+	circutibreaker    *circuitbreaker.Group      // 熔断器组（按节点实例ID隔离）
+	codec             encoding.Codec             // 编解码器
+	resumeGrace       time.Duration              // 会话恢复宽限期，0为不启用
+	resumeRoute       int32                      // 会话恢复路由，用于下发与出示恢复令牌
+	resumeSecret      string                     // 恢复令牌签名秘钥，为空时随机生成
+	resumeWait        time.Duration              // 会话恢复等待时间，新连接在该时间内未出示恢复令牌时触发连接事件
+	handshakeRoute    int32                      // 握手路由，用于交换临时公钥协商会话密钥，小于0为不启用
+	handshakeRotation uint64                     // 会话密钥轮换间隔（消息数）
+	handshakeSigner   crypto.Signer              // 握手签名器，用于客户端校验网关公钥
+	balanceStrategy   dispatcher.BalanceStrategy // 无状态路由负载均衡策略
+	exposeAddr        string                     // 客户端接入地址，排空时作为重定向地址下发给其他网关的客户端
+	drainTimeout      time.Duration              // 排空期限，到期后强制关闭剩余连接，0为一直等待
+	drainRoute        int32                      // 迁移通知路由，小于0为不下发
 }
 type Option func(o *options)
 
@@ -60,6 +93,23 @@ func defaultOptions() *options {
 		codec:   encoding.Invoke(defaultCodec),
 	}
 
+	if name := etc.Get(defaultCompressorKey).String(); name != "" {
+		opts.compressor = compress.InvokeCompressor(name)
+	}
+
+	opts.compressThreshold = etc.Get(defaultCompressThresholdKey, defaultCompressThreshold).Int()
+	opts.decompressLimit = etc.Get(defaultDecompressLimitKey).Int()
+	opts.handshakeRoute = etc.Get(defaultHandshakeRouteKey, defaultHandshakeRoute).Int32()
+	opts.handshakeRotation = etc.Get(defaultHandshakeRotationKey, defaultHandshakeRotation).Uint64()
+	opts.resumeGrace = etc.Get(defaultResumeGraceKey).Duration()
+	opts.resumeRoute = etc.Get(defaultResumeRouteKey, defaultResumeRoute).Int32()
+	opts.resumeSecret = etc.Get(defaultResumeSecretKey).String()
+	opts.resumeWait = etc.Get(defaultResumeWaitKey, defaultResumeWait).Duration()
+	opts.balanceStrategy = dispatcher.BalanceStrategy(etc.Get(defaultBalanceStrategyKey).String())
+	opts.exposeAddr = etc.Get(defaultExposeAddrKey).String()
+	opts.drainTimeout = etc.Get(defaultDrainTimeoutKey).Duration()
+	opts.drainRoute = etc.Get(defaultDrainRouteKey, defaultDrainRoute).Int32()
+
 	if id := etc.Get(defaultIDKey).String(); id != "" {
 		opts.id = id
 	} else {
@@ -125,13 +175,27 @@ func WithRegistry(r registry.Registry) Option {
 	return func(o *options) { o.registry = r }
 }
 
+// WithCompressor 设置消息压缩器
 func WithCompressor(compressor compress.Compressor) Option {
 	return func(o *options) { o.compressor = compressor }
 }
+
+// WithCompressThreshold 设置压缩阈值，消息长度小于该值时不压缩
+func WithCompressThreshold(threshold int) Option {
+	return func(o *options) { o.compressThreshold = threshold }
+}
+
+// WithDecompressLimit 设置客户端消息解压后的最大长度，0为使用打包器的消息体最大字节数
+func WithDecompressLimit(limit int) Option {
+	return func(o *options) { o.decompressLimit = limit }
+}
+
 func WithLimiter(limiter limite.Limiter) Option {
 	return func(o *options) { o.limiter = limiter }
 }
-func WithCircuitBreaker(cb *circuitbreaker.CircuitBreaker) Option {
+
+// WithCircuitBreaker 设置熔断器组，每个节点实例独立熔断
+func WithCircuitBreaker(cb *circuitbreaker.Group) Option {
 	return func(o *options) { o.circutibreaker = cb }
 }
 
@@ -142,3 +206,58 @@ func WithWeight(weight int) Option {
 func WithCodec(codec encoding.Codec) Option {
 	return func(o *options) { o.codec = codec }
 }
+
+// WithResumeGrace 设置会话恢复宽限期，用户断线后在宽限期内可凭恢复令牌恢复会话
+func WithResumeGrace(grace time.Duration) Option {
+	return func(o *options) { o.resumeGrace = grace }
+}
+
+// WithResumeRoute 设置会话恢复路由
+func WithResumeRoute(route int32) Option {
+	return func(o *options) { o.resumeRoute = route }
+}
+
+// WithResumeSecret 设置恢复令牌签名秘钥
+func WithResumeSecret(secret string) Option {
+	return func(o *options) { o.resumeSecret = secret }
+}
+
+// WithResumeWait 设置会话恢复等待时间，新连接在该时间内未出示恢复令牌时触发连接事件
+func WithResumeWait(wait time.Duration) Option {
+	return func(o *options) { o.resumeWait = wait }
+}
+
+// WithHandshakeRoute 设置握手路由，启用后每个连接通过ECDH握手协商独立的会话密钥，静态加密器不再生效
+func WithHandshakeRoute(route int32) Option {
+	return func(o *options) { o.handshakeRoute = route }
+}
+
+// WithHandshakeRotation 设置会话密钥轮换间隔，每个密钥加密的消息数达到该值后轮换密钥
+func WithHandshakeRotation(rotation uint64) Option {
+	return func(o *options) { o.handshakeRotation = rotation }
+}
+
+// WithHandshakeSigner 设置握手签名器，网关对握手响应签名，客户端可据此校验网关身份
+func WithHandshakeSigner(signer crypto.Signer) Option {
+	return func(o *options) { o.handshakeSigner = signer }
+}
+
+// WithBalanceStrategy 设置无状态路由负载均衡策略，默认为随机
+func WithBalanceStrategy(strategy dispatcher.BalanceStrategy) Option {
+	return func(o *options) { o.balanceStrategy = strategy }
+}
+
+// WithExposeAddr 设置客户端接入地址，默认根据网关服务器监听地址推导
+func WithExposeAddr(addr string) Option {
+	return func(o *options) { o.exposeAddr = addr }
+}
+
+// WithDrainTimeout 设置排空期限，关闭网关时等待客户端迁移，到期后强制关闭剩余连接
+func WithDrainTimeout(timeout time.Duration) Option {
+	return func(o *options) { o.drainTimeout = timeout }
+}
+
+// WithDrainRoute 设置迁移通知路由，关闭网关时通过该路由通知客户端重连至其他网关
+func WithDrainRoute(route int32) Option {
+	return func(o *options) { o.drainRoute = route }
+}
@@ -20,6 +20,8 @@ func (p *provider) Bind(ctx context.Context, cid, uid int64) error {
 		return errors.ErrInvalidArgument
 	}
 
+	p.gate.resumer.discard(ctx, uid)
+
 	err := p.gate.session.Bind(cid, uid)
 	if err != nil {
 		return err
@@ -28,9 +30,12 @@ func (p *provider) Bind(ctx context.Context, cid, uid int64) error {
 	err = p.gate.proxy.bindGate(ctx, cid, uid)
 	if err != nil {
 		_, _ = p.gate.session.Unbind(uid)
+		return err
 	}
 
-	return err
+	p.gate.resumer.issue(cid, uid)
+
+	return nil
 }
 
 // Unbind 解绑用户与网关间的关系
@@ -39,6 +44,8 @@ func (p *provider) Unbind(ctx context.Context, uid int64) error {
 		return errors.ErrInvalidArgument
 	}
 
+	p.gate.resumer.revoke(uid)
+
 	cid, err := p.gate.session.Unbind(uid)
 	if err != nil {
 		return err
@@ -64,7 +71,7 @@ func (p *provider) Stat(ctx context.Context, kind session.Kind) (int64, error) {
 
 // Disconnect 断开连接
 func (p *provider) Disconnect(ctx context.Context, kind session.Kind, target int64, force bool) error {
-	return p.gate.session.Close(kind, target, force)
+	return p.gate.Kick(kind, target, force)
 }
 
 // Push 发送消息
@@ -77,7 +84,7 @@ func (p *provider) Push(ctx context.Context, kind session.Kind, target int64, me
 	}
 	err = p.gate.session.Push(kind, target, messageEncry)
 
-	if kind == session.User && errors.Is(err, errors.ErrNotFoundSession) {
+	if kind == session.User && errors.Is(err, errors.ErrNotFoundSession) && !p.gate.resumer.holding(target) {
 		xcall.Go(func() {
 			if err := p.gate.opts.locator.UnbindGate(ctx, target, p.gate.opts.id); err != nil {
 				log.Errorf("unbind gate failed, uid = %d gid = %s err = %v", target, p.gate.opts.id, err)
@@ -108,14 +115,34 @@ func (p *provider) Broadcast(ctx context.Context, kind session.Kind, message []b
 	return p.gate.session.Broadcast(kind, messageEncry)
 }
 
+// JoinGroup 加入分组
+func (p *provider) JoinGroup(ctx context.Context, kind session.Kind, target int64, group string) error {
+	return p.gate.session.JoinGroup(group, kind, target)
+}
+
+// LeaveGroup 退出分组
+func (p *provider) LeaveGroup(ctx context.Context, kind session.Kind, target int64, group string) error {
+	return p.gate.session.LeaveGroup(group, kind, target)
+}
+
+// MulticastGroup 推送分组消息
+func (p *provider) MulticastGroup(ctx context.Context, group string, message []byte) (int64, error) {
+	messageEncry, err := p.processMessage(message)
+	if err != nil {
+		log.Errorf("processMessage failed: %v", err)
+		return 0, err
+	}
+	return p.gate.session.MulticastGroup(group, messageEncry)
+}
+
 // GetState 获取状态
 func (p *provider) GetState() (cluster.State, error) {
-	return cluster.Work, nil
+	return p.gate.getState(), nil
 }
 
 // SetState 设置状态
 func (p *provider) SetState(state cluster.State) error {
-	return nil
+	return p.gate.SetState(state)
 }
 
 func (p *provider) processMessage(message []byte) ([]byte, error) {
@@ -129,15 +156,10 @@ func (p *provider) processMessage(message []byte) ([]byte, error) {
 	//log.Debugf("gate 收到响应消息 %d", msg.Seq)
 
 	//压缩
-	if p.gate.opts.compressor != nil {
-		msg.Buffer, err = p.gate.opts.compressor.Compress(msg.Buffer)
-		//log.Debugf("gate 对响应消息压缩后消息为: %v,长度为%d", msg.Buffer, len(msg.Buffer))
-		if err != nil {
-			log.Errorf("compress failed: %v", err)
-			return nil, err
-		}
+	if err = p.gate.proxy.compress(msg); err != nil {
+		log.Errorf("compress failed: %v", err)
+		return nil, err
 	}
-	//log.Debugf("gate 对响应消息压缩后消息为: %v,长度为%d", compressedBuffer, len(compressedBuffer))
 
 	//加密
 	if p.gate.opts.encryptor != nil {
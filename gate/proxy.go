@@ -4,11 +4,15 @@ import (
 	"context"
 	"fmt"
 	"gatesvr/cluster"
+	"gatesvr/compress"
 	"gatesvr/errors"
 	"gatesvr/internal/link"
+	"gatesvr/limite"
 	"gatesvr/log"
+	"gatesvr/metrics"
 	"gatesvr/mode"
 	"gatesvr/packet"
+	"gatesvr/session"
 	"gatesvr/utils/codes"
 )
 
@@ -19,10 +23,12 @@ type proxy struct {
 
 func newProxy(gate *Gate) *proxy {
 	return &proxy{gate: gate, nodeLinker: link.NewNodeLinker(gate.ctx, &link.Options{
-		InsID:    gate.opts.id,
-		InsKind:  cluster.Gate,
-		Locator:  gate.opts.locator,
-		Registry: gate.opts.registry,
+		InsID:           gate.opts.id,
+		InsKind:         cluster.Gate,
+		Locator:         gate.opts.locator,
+		Registry:        gate.opts.registry,
+		CircuitBreaker:  gate.opts.circutibreaker,
+		BalanceStrategy: gate.opts.balanceStrategy,
 	})}
 }
 
@@ -76,47 +82,78 @@ func (p *proxy) deliver(ctx context.Context, cid, uid int64, message []byte) {
 		return
 	}
 	if !msg.IsCritical && p.gate.opts.limiter != nil {
-		if !p.gate.opts.limiter.GetToken() {
-			//log.Errorf("token is not enough")
-			log.Debugf("token is not enough")
+		ip, _ := p.gate.session.RemoteIP(session.Conn, cid)
+
+		if !p.gate.opts.limiter.GetToken(limite.Key{CID: cid, UID: uid, IP: ip, Route: msg.Route}) {
+			log.Debugf("token is not enough, cid: %d uid: %d ip: %s route: %d", cid, uid, ip, msg.Route)
+			metrics.LimiterDenials.Add(1, p.nodeLinker.RouteLabel(msg.Route))
 			message := &packet.Notification{
 				Code:    codes.TooManyRequests.Code(),
 				Message: fmt.Sprintf("token is not enough, please try again later，seq: %d", msg.Seq),
 			}
-			p.processMessageToClient(cid, message)
+			p.processMessageToClient(cid, msg.Seq, message)
 			return
 		}
 	}
-	//调用encryptor.Decrypt解密消息
-	if p.gate.opts.encryptor != nil {
+	if p.gate.shaker.enabled() {
+		if msg.Route == p.gate.opts.handshakeRoute {
+			if err = p.gate.shaker.handle(cid, msg); err != nil {
+				log.Warnf("handshake failed, cid: %d err: %v", cid, err)
+				_ = p.gate.session.Close(session.Conn, cid, true)
+			}
+			return
+		}
+
+		//使用会话密钥解密消息
+		if err = p.gate.shaker.open(cid, msg); err != nil {
+			log.Warnf("open message failed, cid: %d uid: %d seq: %d route: %d err: %v", cid, uid, msg.Seq, msg.Route, err)
+			return
+		}
+	} else if p.gate.opts.encryptor != nil {
+		//调用encryptor.Decrypt解密消息
 		decryptMsgBuffer, err := p.gate.opts.encryptor.Decrypt(msg.Buffer)
 		if err != nil {
 			log.Errorf("decrypt message failed: %v", err)
 			return
 		}
 		msg.Buffer = decryptMsgBuffer
-		//log.Debugf("gate解密请求后消息: %v,长度为%d", message, len(message))
-		////解压缩
-		if p.gate.opts.compressor != nil {
-			compressedBuffer, err := p.gate.opts.compressor.Decompress(msg.Buffer)
-			if err != nil {
-				log.Errorf("compress message failed: %v", err)
-				return
-			}
-			msg.Buffer = compressedBuffer
-			//log.Debugf("gate解压缩请求消息: %v，长度为%d", msg.Buffer, len(msg.Buffer))
+	}
+
+	//解压缩
+	if msg.IsCompressed {
+		if p.gate.opts.compressor == nil {
+			log.Errorf("decompress message failed: %v", errors.ErrMissingCompressor)
+			return
 		}
 
+		decompressedBuffer, err := compress.DecompressLimit(p.gate.opts.compressor, msg.Buffer, p.decompressLimit())
+		if err != nil {
+			log.Errorf("decompress message failed: %v", err)
+			return
+		}
+		msg.Buffer = decompressedBuffer
+	}
+
+	if p.gate.shaker.enabled() || p.gate.opts.encryptor != nil || msg.IsCompressed {
 		messageTemp, err := packet.PackMessage(&packet.Message{
-			Seq:    msg.Seq,
-			Route:  msg.Route,
-			Buffer: msg.Buffer,
+			Seq:        msg.Seq,
+			Route:      msg.Route,
+			IsCritical: msg.IsCritical,
+			Buffer:     msg.Buffer,
 		})
 		if err != nil {
 			return
 		}
 		message = messageTemp
+	}
+
+	if p.gate.resumer.enabled() {
+		if msg.Route == p.gate.opts.resumeRoute {
+			p.gate.resumer.handle(ctx, cid, msg.Buffer)
+			return
+		}
 
+		p.gate.resumer.fireConnect(ctx, cid, uid)
 	}
 
 	if err = p.nodeLinker.Deliver(ctx, &link.DeliverArgs{
@@ -131,14 +168,21 @@ func (p *proxy) deliver(ctx context.Context, cid, uid int64, message []byte) {
 				Code:    codes.NotFound.Code(),
 				Message: fmt.Sprintf("deliver message failed, cid: %d uid: %d seq: %d route: %d err: %v", cid, uid, msg.Seq, msg.Route, err),
 			}
-			p.processMessageToClient(cid, message)
+			p.processMessageToClient(cid, msg.Seq, message)
+			log.Warnf("deliver message failed, cid: %d uid: %d seq: %d route: %d err: %v", cid, uid, msg.Seq, msg.Route, err)
+		case errors.Is(err, errors.ErrServerCircuitBreaker), errors.Is(err, errors.ErrNotFoundHealthyEndpoint):
+			message := &packet.Notification{
+				Code:    codes.ServiceUnavailable.Code(),
+				Message: fmt.Sprintf("deliver message failed, cid: %d uid: %d seq: %d route: %d err: %v", cid, uid, msg.Seq, msg.Route, err),
+			}
+			p.processMessageToClient(cid, msg.Seq, message)
 			log.Warnf("deliver message failed, cid: %d uid: %d seq: %d route: %d err: %v", cid, uid, msg.Seq, msg.Route, err)
 		case errors.Is(err, errors.ErrNotFoundUserLocation):
 			message := &packet.Notification{
 				Code:    codes.StateError.Code(),
 				Message: fmt.Sprintf("deliver message failed, cid: %d uid: %d seq: %d route: %d err: %v", cid, uid, msg.Seq, msg.Route, err),
 			}
-			p.processMessageToClient(cid, message)
+			p.processMessageToClient(cid, msg.Seq, message)
 			log.Warnf("deliver message failed, cid: %d uid: %d seq: %d route: %d err: %v", cid, uid, msg.Seq, msg.Route, err)
 		default:
 			log.Errorf("deliver message failed, cid: %d uid: %d seq: %d route: %d err: %v", cid, uid, msg.Seq, msg.Route, err)
@@ -146,39 +190,80 @@ func (p *proxy) deliver(ctx context.Context, cid, uid int64, message []byte) {
 	}
 }
 
+// 客户端消息解压后的最大长度，未设置时与打包器的消息体最大字节数一致
+func (p *proxy) decompressLimit() int {
+	if p.gate.opts.decompressLimit > 0 {
+		return p.gate.opts.decompressLimit
+	}
+
+	return packet.BufferBytes()
+}
+
 // 开始监听
 func (p *proxy) watch() {
 	p.nodeLinker.WatchUserLocate()
 
 	p.nodeLinker.WatchClusterInstance()
 }
-func (p *proxy) processMessageToClient(cid int64, message *packet.Notification) {
+
+// 推送通知给客户端，序列号沿用触发通知的请求，便于客户端匹配请求
+func (p *proxy) processMessageToClient(cid int64, seq int32, message *packet.Notification) {
 	buffer, err := p.gate.opts.codec.Marshal(message)
-	res := &packet.Message{
-		Route:  0,
-		Buffer: buffer,
-	}
 	if err != nil {
 		log.Errorf("marshal message failed: %v", err)
 		return
 	}
-	if p.gate.opts.compressor != nil {
-		res.Buffer, err = p.gate.opts.compressor.Compress(res.Buffer)
-		if err != nil {
-			return
-		}
 
+	p.pushToClient(cid, seq, 0, buffer)
+}
+
+// 压缩、加密并推送消息给客户端
+func (p *proxy) pushToClient(cid int64, seq, route int32, buffer []byte) {
+	messageEncry, err := p.packToClient(seq, route, buffer)
+	if err != nil {
+		return
+	}
+
+	p.gate.session.Push(1, cid, messageEncry)
+}
+
+// 压缩、加密并打包下发给客户端的消息
+func (p *proxy) packToClient(seq, route int32, buffer []byte) ([]byte, error) {
+	res := &packet.Message{
+		Seq:    seq,
+		Route:  route,
+		Buffer: buffer,
+	}
+
+	if err := p.compress(res); err != nil {
+		return nil, err
 	}
 
 	//加密
 	if p.gate.opts.encryptor != nil {
 		encryptMsgBuffer, err := p.gate.opts.encryptor.Encrypt(res.Buffer)
 		if err != nil {
-			return
+			return nil, err
 		}
 		res.Buffer = encryptMsgBuffer
 	}
-	messageEncry, err := packet.PackMessage(res)
 
-	p.gate.session.Push(1, cid, messageEncry)
+	return packet.PackMessage(res)
+}
+
+// 压缩消息，未设置压缩器或消息长度小于压缩阈值时不压缩
+func (p *proxy) compress(msg *packet.Message) error {
+	if p.gate.opts.compressor == nil || len(msg.Buffer) < p.gate.opts.compressThreshold {
+		return nil
+	}
+
+	buffer, err := p.gate.opts.compressor.Compress(msg.Buffer)
+	if err != nil {
+		return err
+	}
+
+	msg.Buffer = buffer
+	msg.IsCompressed = true
+
+	return nil
 }
@@ -0,0 +1,291 @@
+package gate
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"gatesvr/cluster"
+	"gatesvr/errors"
+	"gatesvr/log"
+	"gatesvr/packet"
+	"gatesvr/session"
+	"gatesvr/utils/codes"
+	"sync"
+	"time"
+)
+
+const (
+	resumeHeaderBytes = 16 // 令牌头长度（用户ID + 随机数）
+)
+
+type hold struct {
+	cid    int64       // 断线前的连接ID
+	token  string      // 恢复令牌
+	timer  *time.Timer // 宽限期定时器
+	groups []string    // 断线前加入的分组，恢复后在新连接上重新加入
+}
+
+// 会话恢复器
+// 用户绑定后签发恢复令牌；断线后在宽限期内保留绑定关系并推迟断开事件，
+// 客户端在新连接上出示令牌即可恢复至原用户，节点只会收到一次重连事件
+// 分组按连接ID保存，恢复后新连接重新加入断线前的分组；宽限期内推送的分组消息不会缓存
+type resumer struct {
+	gate     *Gate
+	secret   []byte
+	connects sync.Map // 尚未触发连接事件的连接（cid -> *time.Timer）
+	mu       sync.Mutex
+	tokens   map[int64]string // 已签发的令牌（uid -> token）
+	holds    map[int64]*hold  // 宽限期内的会话（uid -> hold）
+}
+
+func newResumer(gate *Gate) *resumer {
+	r := &resumer{}
+	r.gate = gate
+	r.tokens = make(map[int64]string)
+	r.holds = make(map[int64]*hold)
+
+	if gate.opts.resumeSecret != "" {
+		r.secret = []byte(gate.opts.resumeSecret)
+	} else {
+		r.secret = make([]byte, 32)
+		_, _ = rand.Read(r.secret)
+	}
+
+	return r
+}
+
+// 是否启用会话恢复
+func (r *resumer) enabled() bool {
+	return r.gate.opts.resumeGrace > 0
+}
+
+// 推迟连接事件至收到首个消息或等待时间到期，以便检测新连接是否为会话恢复，启用会话恢复时返回true
+// 等待时间到期后才出示恢复令牌的连接，节点会先后收到连接事件及重连事件
+func (r *resumer) deferConnect(cid int64) bool {
+	if !r.enabled() {
+		return false
+	}
+
+	timer := time.AfterFunc(r.gate.opts.resumeWait, func() {
+		ctx, cancel := context.WithTimeout(r.gate.ctx, r.gate.opts.timeout)
+		r.fireConnect(ctx, cid, 0)
+		cancel()
+	})
+
+	r.connects.Store(cid, timer)
+
+	return true
+}
+
+// 触发被推迟的连接事件
+func (r *resumer) fireConnect(ctx context.Context, cid, uid int64) {
+	if r.dropConnect(cid) {
+		r.gate.proxy.trigger(ctx, cluster.Connect, cid, uid)
+	}
+}
+
+// 丢弃被推迟的连接事件，连接事件未触发时返回true
+func (r *resumer) dropConnect(cid int64) bool {
+	timer, ok := r.connects.LoadAndDelete(cid)
+	if ok {
+		timer.(*time.Timer).Stop()
+	}
+
+	return ok
+}
+
+// 签发恢复令牌并推送给客户端
+func (r *resumer) issue(cid, uid int64) {
+	if !r.enabled() {
+		return
+	}
+
+	token := r.sign(uid)
+
+	r.mu.Lock()
+	r.tokens[uid] = token
+	r.mu.Unlock()
+
+	r.gate.proxy.pushToClient(cid, 0, r.gate.opts.resumeRoute, []byte(token))
+}
+
+// 吊销恢复令牌
+func (r *resumer) revoke(uid int64) {
+	r.mu.Lock()
+	delete(r.tokens, uid)
+	r.mu.Unlock()
+}
+
+// 保留断线用户的会话，返回true时由宽限期结束后负责解绑用户并触发断开事件
+func (r *resumer) hold(cid, uid int64, groups []string) bool {
+	if !r.enabled() {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[uid]
+	if !ok {
+		return false
+	}
+
+	delete(r.tokens, uid)
+
+	h := &hold{cid: cid, token: token, groups: groups}
+	h.timer = time.AfterFunc(r.gate.opts.resumeGrace, func() { r.expire(uid, h) })
+	r.holds[uid] = h
+
+	return true
+}
+
+// 是否处于宽限期
+func (r *resumer) holding(uid int64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.holds[uid]
+	return ok
+}
+
+// 恢复会话
+func (r *resumer) resume(ctx context.Context, cid int64, token string) error {
+	uid, ok := r.verify(token)
+	if !ok {
+		return errors.ErrInvalidResumeToken
+	}
+
+	r.mu.Lock()
+	h, ok := r.holds[uid]
+	if !ok || !hmac.Equal([]byte(h.token), []byte(token)) || !h.timer.Stop() {
+		r.mu.Unlock()
+		return errors.ErrInvalidResumeToken
+	}
+	delete(r.holds, uid)
+	r.mu.Unlock()
+
+	if err := r.gate.session.Bind(cid, uid); err != nil {
+		r.finish(ctx, h.cid, uid, true)
+		return err
+	}
+
+	for _, group := range h.groups {
+		if err := r.gate.session.JoinGroup(group, session.Conn, cid); err != nil {
+			log.Warnf("rejoin group failed, cid: %d uid: %d group: %s err: %v", cid, uid, group, err)
+		}
+	}
+
+	r.dropConnect(cid)
+
+	r.issue(cid, uid)
+
+	r.gate.proxy.trigger(ctx, cluster.Reconnect, cid, uid)
+
+	return nil
+}
+
+// 放弃用户的宽限期会话，用户在新连接上重新登录时触发原连接的断开事件
+func (r *resumer) discard(ctx context.Context, uid int64) {
+	r.mu.Lock()
+	h, ok := r.holds[uid]
+	if ok && h.timer.Stop() {
+		delete(r.holds, uid)
+	} else {
+		ok = false
+	}
+	r.mu.Unlock()
+
+	if ok {
+		r.finish(ctx, h.cid, uid, false)
+	}
+}
+
+// 宽限期结束
+func (r *resumer) expire(uid int64, h *hold) {
+	r.mu.Lock()
+	if r.holds[uid] != h {
+		r.mu.Unlock()
+		return
+	}
+	delete(r.holds, uid)
+	r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(r.gate.ctx, r.gate.opts.timeout)
+	r.finish(ctx, h.cid, uid, true)
+	cancel()
+}
+
+// 结束所有宽限期会话
+func (r *resumer) close() {
+	r.mu.Lock()
+	holds := r.holds
+	r.holds = make(map[int64]*hold)
+	r.mu.Unlock()
+
+	for uid, h := range holds {
+		if !h.timer.Stop() {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(r.gate.ctx, r.gate.opts.timeout)
+		r.finish(ctx, h.cid, uid, true)
+		cancel()
+	}
+}
+
+// 解绑用户并触发断开事件
+func (r *resumer) finish(ctx context.Context, cid, uid int64, unbind bool) {
+	if unbind {
+		_ = r.gate.proxy.unbindGate(ctx, cid, uid)
+	}
+
+	r.gate.proxy.trigger(ctx, cluster.Disconnect, cid, uid)
+}
+
+// 处理客户端的恢复请求
+func (r *resumer) handle(ctx context.Context, cid int64, token []byte) {
+	if err := r.resume(ctx, cid, string(token)); err != nil {
+		log.Warnf("resume session failed, cid: %d err: %v", cid, err)
+
+		r.gate.proxy.processMessageToClient(cid, 0, &packet.Notification{
+			Code:    codes.Unauthorized.Code(),
+			Message: fmt.Sprintf("resume session failed, cid: %d err: %v", cid, err),
+		})
+	}
+}
+
+// 签名令牌
+func (r *resumer) sign(uid int64) string {
+	buf := make([]byte, resumeHeaderBytes, resumeHeaderBytes+sha256.Size)
+	binary.BigEndian.PutUint64(buf, uint64(uid))
+	_, _ = rand.Read(buf[8:resumeHeaderBytes])
+
+	return base64.RawURLEncoding.EncodeToString(append(buf, r.mac(buf)...))
+}
+
+// 校验令牌
+func (r *resumer) verify(token string) (int64, bool) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) != resumeHeaderBytes+sha256.Size {
+		return 0, false
+	}
+
+	if !hmac.Equal(data[resumeHeaderBytes:], r.mac(data[:resumeHeaderBytes])) {
+		return 0, false
+	}
+
+	return int64(binary.BigEndian.Uint64(data)), true
+}
+
+// 计算消息签名，签名中包含网关实例ID，令牌仅能在签发网关上使用
+func (r *resumer) mac(data []byte) []byte {
+	h := hmac.New(sha256.New, r.secret)
+	h.Write([]byte(r.gate.opts.id))
+	h.Write(data)
+
+	return h.Sum(nil)
+}
@@ -0,0 +1,182 @@
+package gate
+
+import (
+	"context"
+	"gatesvr/locate"
+	"gatesvr/session"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testLocator struct {
+	locate.Locator
+	unbinds atomic.Int32
+}
+
+func (l *testLocator) UnbindGate(ctx context.Context, uid int64, gid string) error {
+	l.unbinds.Add(1)
+	return nil
+}
+
+func newTestGate(grace time.Duration) (*Gate, *testLocator) {
+	locator := &testLocator{}
+	g := NewGate(WithID("gate-1"), WithResumeGrace(grace), WithResumeSecret("secret"))
+	g.opts.locator = locator
+
+	return g, locator
+}
+
+func TestResumer_Token(t *testing.T) {
+	g, _ := newTestGate(time.Second)
+
+	token := g.resumer.sign(123)
+
+	if uid, ok := g.resumer.verify(token); !ok || uid != 123 {
+		t.Fatalf("verify token failed, uid: %d ok: %v", uid, ok)
+	}
+
+	if _, ok := g.resumer.verify(token[:len(token)-2] + "AA"); ok {
+		t.Fatal("expected tampered token rejected")
+	}
+
+	other, _ := newTestGate(time.Second)
+	other.opts.id = "gate-2"
+
+	if _, ok := other.resumer.verify(token); ok {
+		t.Fatal("expected token rejected by other gate")
+	}
+}
+
+func TestResumer_Hold(t *testing.T) {
+	g, locator := newTestGate(50 * time.Millisecond)
+
+	if g.resumer.hold(1, 123, nil) {
+		t.Fatal("expected no hold without issued token")
+	}
+
+	g.resumer.tokens[123] = g.resumer.sign(123)
+
+	if !g.resumer.hold(1, 123, nil) || !g.resumer.holding(123) {
+		t.Fatal("expected user held")
+	}
+
+	if locator.unbinds.Load() != 0 {
+		t.Fatal("expected unbind deferred during grace period")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if g.resumer.holding(123) {
+		t.Fatal("expected hold expired")
+	}
+
+	if locator.unbinds.Load() != 1 {
+		t.Fatalf("expected unbind after grace period, got %d", locator.unbinds.Load())
+	}
+}
+
+func TestResumer_Resume(t *testing.T) {
+	g, locator := newTestGate(time.Second)
+
+	token := g.resumer.sign(123)
+	g.resumer.tokens[123] = token
+	g.resumer.hold(1, 123, nil)
+
+	if err := g.resumer.resume(context.Background(), 2, g.resumer.sign(123)); err == nil {
+		t.Fatal("expected mismatched token rejected")
+	}
+
+	// 新连接不存在，会话恢复失败后立即解绑
+	if err := g.resumer.resume(context.Background(), 2, token); err == nil {
+		t.Fatal("expected resume failed without connection")
+	}
+
+	if g.resumer.holding(123) || locator.unbinds.Load() != 1 {
+		t.Fatal("expected hold finished after failed resume")
+	}
+
+	if err := g.resumer.resume(context.Background(), 2, token); err == nil {
+		t.Fatal("expected token single use")
+	}
+}
+
+func TestResumer_Disabled(t *testing.T) {
+	g, _ := newTestGate(0)
+
+	if g.resumer.deferConnect(1) {
+		t.Fatal("expected connect not deferred")
+	}
+
+	g.resumer.tokens[123] = g.resumer.sign(123)
+
+	if g.resumer.hold(1, 123, nil) {
+		t.Fatal("expected no hold when disabled")
+	}
+}
+
+func TestResumer_DeferConnectTimeout(t *testing.T) {
+	g, _ := newTestGate(time.Second)
+	g.opts.resumeWait = 20 * time.Millisecond
+
+	if !g.resumer.deferConnect(1) || !g.resumer.deferConnect(2) {
+		t.Fatal("expected connect deferred")
+	}
+
+	// 收到首个消息后立即触发连接事件
+	g.resumer.fireConnect(context.Background(), 1, 0)
+
+	if g.resumer.dropConnect(1) {
+		t.Fatal("expected connect fired on first message")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if g.resumer.dropConnect(2) {
+		t.Fatal("expected connect fired after resume wait")
+	}
+}
+
+// 可绑定用户的连接
+type resumeConn struct {
+	testConn
+	uid int64
+}
+
+func (c *resumeConn) UID() int64 { return c.uid }
+
+func (c *resumeConn) Bind(uid int64) { c.uid = uid }
+
+func (c *resumeConn) CheckAndSendPendingMessages() error { return nil }
+
+func TestResumer_ResumeGroups(t *testing.T) {
+	g, _ := newTestGate(time.Second)
+
+	old := &resumeConn{testConn: testConn{id: 1}, uid: 123}
+	g.session.AddConn(old)
+
+	if err := g.session.JoinGroup("room", session.Conn, old.id); err != nil {
+		t.Fatal(err)
+	}
+
+	token := g.resumer.sign(123)
+	g.resumer.tokens[123] = token
+
+	if !g.resumer.hold(old.id, 123, g.session.RemConn(old)) {
+		t.Fatal("expected user held")
+	}
+
+	if n := g.session.GroupStat("room"); n != 0 {
+		t.Fatalf("expected group left after disconnect, got %d members", n)
+	}
+
+	g.session.AddConn(&resumeConn{testConn: testConn{id: 2}})
+
+	if err := g.resumer.resume(context.Background(), 2, token); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := g.session.GroupStat("room"); n != 1 {
+		t.Fatalf("expected group rejoined after resume, got %d members", n)
+	}
+}
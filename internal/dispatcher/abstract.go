@@ -4,15 +4,34 @@ import (
 	"gatesvr/cluster"
 	"gatesvr/core/endpoint"
 	"gatesvr/errors"
+	"gatesvr/registry"
 	"math/rand/v2"
 	"sync"
 	"sync/atomic"
 )
 
 type serviceEndpoint struct {
-	insID    string
-	state    string
-	endpoint *endpoint.Endpoint
+	insID      string
+	state      string
+	weight     int            // 权重
+	load       *registry.Load // 负载快照，未上报负载时为空
+	dispatched atomic.Int64   // 负载快照后本地分配到该实例的请求数
+	endpoint   *endpoint.Endpoint
+}
+
+// 更新服务端点，构建路由时保存实例权重及负载的快照，分配时无需访问分发器的实例表
+func (se *serviceEndpoint) update(service *registry.ServiceInstance, endpoint *endpoint.Endpoint) {
+	se.state = service.State
+	se.weight = service.Weight
+	se.endpoint = endpoint
+	se.dispatched.Store(0)
+
+	if service.Load != nil {
+		load := *service.Load
+		se.load = &load
+	} else {
+		se.load = nil
+	}
 }
 
 type abstract struct {
@@ -27,6 +46,8 @@ type abstract struct {
 	nextQueue    *wrrQueue  // 下一个队列
 	step         int        // GCD步长
 	wrrMu        sync.Mutex // 加权轮询锁
+	// 一致性哈希相关字段
+	ring *hashRing // 哈希环
 }
 
 // 加权轮询队列节点
@@ -43,22 +64,62 @@ type wrrQueue struct {
 	tail *wrrEntry
 }
 
-// FindEndpoint 查询路由服务端点
-func (a *abstract) FindEndpoint(insID ...string) (*endpoint.Endpoint, error) {
+// FindEndpoint 查询路由服务端点，未指定实例时按负载均衡策略分配，一致性哈希策略下按用户ID分配
+func (a *abstract) FindEndpoint(uid int64, insID ...string) (*endpoint.Endpoint, error) {
 	if len(insID) == 0 || insID[0] == "" {
-		switch a.dispatcher.strategy {
-		case RoundRobin:
-			return a.roundRobinDispatch()
-		case WeightRoundRobin:
-			return a.weightRoundRobinDispatch()
-		default:
-			return a.randomDispatch()
+		se, err := a.strategyDispatch(uid)
+		if err != nil {
+			return nil, err
 		}
+
+		return se.endpoint, nil
 	}
 
 	return a.directDispatch(insID[0])
 }
 
+// FindAvailableEndpoint 按负载均衡策略查询可用的路由服务端点
+// 策略选中的实例不可用时，依次尝试其他work、busy状态的实例；一致性哈希策略下沿哈希环顺时针查找
+func (a *abstract) FindAvailableEndpoint(uid int64, available func(insID string) bool) (string, *endpoint.Endpoint, error) {
+	if a.dispatcher.strategy == ConsistentHash && uid != 0 && !a.ring.isEmpty() {
+		var found *serviceEndpoint
+
+		a.ring.iterate(uid, func(se *serviceEndpoint) bool {
+			if available(se.insID) {
+				found = se
+				return false
+			}
+			return true
+		})
+
+		if found == nil {
+			return "", nil, errors.ErrNotFoundHealthyEndpoint
+		}
+
+		return found.insID, found.endpoint, nil
+	}
+
+	se, err := a.strategyDispatch(uid)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if available(se.insID) {
+		return se.insID, se.endpoint, nil
+	}
+
+	if n := len(a.endpoints3); n > 0 {
+		offset := rand.IntN(n)
+		for i := 0; i < n; i++ {
+			if item := a.endpoints3[(offset+i)%n]; item.insID != se.insID && available(item.insID) {
+				return item.insID, item.endpoint, nil
+			}
+		}
+	}
+
+	return "", nil, errors.ErrNotFoundHealthyEndpoint
+}
+
 // IterateEndpoint 迭代服务端口
 func (a *abstract) IterateEndpoint(fn func(insID string, ep *endpoint.Endpoint) bool) {
 	for _, se := range a.endpoints1 {
@@ -69,26 +130,26 @@ func (a *abstract) IterateEndpoint(fn func(insID string, ep *endpoint.Endpoint)
 }
 
 // 添加服务端点
-func (a *abstract) addEndpoint(insID string, state string, endpoint *endpoint.Endpoint) {
-	if se, ok := a.endpoints2[insID]; ok {
-		se.state = state
-		se.endpoint = endpoint
-	} else {
-		se = &serviceEndpoint{insID: insID, state: state, endpoint: endpoint}
+func (a *abstract) addEndpoint(service *registry.ServiceInstance, endpoint *endpoint.Endpoint) {
+	insID := service.ID
+
+	se, ok := a.endpoints2[insID]
+	if !ok {
+		se = &serviceEndpoint{insID: insID}
 		a.endpoints1 = append(a.endpoints1, se)
 		a.endpoints2[insID] = se
 	}
+	se.update(service, endpoint)
 
-	switch state {
+	switch service.State {
 	case cluster.Work.String(), cluster.Busy.String():
-		if se, ok := a.endpoints4[insID]; ok {
-			se.state = state
-			se.endpoint = endpoint
-		} else {
-			se = &serviceEndpoint{insID: insID, state: state, endpoint: endpoint}
+		se, ok := a.endpoints4[insID]
+		if !ok {
+			se = &serviceEndpoint{insID: insID}
 			a.endpoints3 = append(a.endpoints3, se)
 			a.endpoints4[insID] = se
 		}
+		se.update(service, endpoint)
 	case cluster.Hang.String():
 		if _, ok := a.endpoints4[insID]; ok {
 			delete(a.endpoints4, insID)
@@ -113,28 +174,46 @@ func (a *abstract) directDispatch(insID string) (*endpoint.Endpoint, error) {
 	return sep.endpoint, nil
 }
 
+// 按负载均衡策略分配
+func (a *abstract) strategyDispatch(uid int64) (*serviceEndpoint, error) {
+	switch a.dispatcher.strategy {
+	case RoundRobin:
+		return a.roundRobinDispatch()
+	case WeightRoundRobin:
+		return a.weightRoundRobinDispatch()
+	case ConsistentHash:
+		return a.consistentHashDispatch(uid)
+	case LeastLoaded:
+		return a.leastLoadedDispatch()
+	case PowerOfTwo:
+		return a.powerOfTwoDispatch()
+	default:
+		return a.randomDispatch()
+	}
+}
+
 // 随机分配
-func (a *abstract) randomDispatch() (*endpoint.Endpoint, error) {
+func (a *abstract) randomDispatch() (*serviceEndpoint, error) {
 	if n := len(a.endpoints3); n > 0 {
-		return a.endpoints3[rand.IntN(n)].endpoint, nil
+		return a.endpoints3[rand.IntN(n)], nil
 	}
 
 	return nil, errors.ErrNotFoundEndpoint
 }
 
 // 轮询分配
-func (a *abstract) roundRobinDispatch() (*endpoint.Endpoint, error) {
+func (a *abstract) roundRobinDispatch() (*serviceEndpoint, error) {
 	if len(a.endpoints3) == 0 {
 		return nil, errors.ErrNotFoundEndpoint
 	}
 
 	index := int(a.counter.Add(1) % uint64(len(a.endpoints3)))
 
-	return a.endpoints3[index].endpoint, nil
+	return a.endpoints3[index], nil
 }
 
 // 加权轮询分配
-func (a *abstract) weightRoundRobinDispatch() (*endpoint.Endpoint, error) {
+func (a *abstract) weightRoundRobinDispatch() (*serviceEndpoint, error) {
 	a.wrrMu.Lock()
 	defer a.wrrMu.Unlock()
 
@@ -161,7 +240,92 @@ func (a *abstract) weightRoundRobinDispatch() (*endpoint.Endpoint, error) {
 		a.nextQueue.push(entry)
 	}
 
-	return entry.endpoint.endpoint, nil
+	return entry.endpoint, nil
+}
+
+// 最小负载分配，负载相同时随机选取
+// 负载快照仅在实例上报时更新，评分计入快照后本地已分配的请求数，避免上报间隔内所有请求集中到同一实例
+func (a *abstract) leastLoadedDispatch() (*serviceEndpoint, error) {
+	n := len(a.endpoints3)
+	if n == 0 {
+		return nil, errors.ErrNotFoundEndpoint
+	}
+
+	var (
+		best   *serviceEndpoint
+		min    float64
+		offset = rand.IntN(n)
+	)
+
+	for i := 0; i < n; i++ {
+		se := a.endpoints3[(offset+i)%n]
+		if score := se.loadScore(); best == nil || score < min {
+			best, min = se, score
+		}
+	}
+
+	best.dispatched.Add(1)
+
+	return best, nil
+}
+
+// 随机选取两个实例，分配给负载较小者
+func (a *abstract) powerOfTwoDispatch() (*serviceEndpoint, error) {
+	n := len(a.endpoints3)
+	switch n {
+	case 0:
+		return nil, errors.ErrNotFoundEndpoint
+	case 1:
+		return a.endpoints3[0], nil
+	}
+
+	i := rand.IntN(n)
+	j := rand.IntN(n - 1)
+	if j >= i {
+		j++
+	}
+
+	se := a.endpoints3[i]
+	if a.endpoints3[j].loadScore() < se.loadScore() {
+		se = a.endpoints3[j]
+	}
+
+	se.dispatched.Add(1)
+
+	return se, nil
+}
+
+// 计算实例负载评分，值越小负载越低
+// 以待处理请求数（含快照后本地已分配的请求）与平均耗时估算排队时间，再按权重折算；未上报负载的实例仅计本地已分配的请求
+func (se *serviceEndpoint) loadScore() float64 {
+	dispatched := se.dispatched.Load()
+	score := float64(dispatched)
+
+	if se.load != nil {
+		score = float64(int64(se.load.Pending)+dispatched+1)*float64(se.load.Latency+1) + float64(se.load.Actors)
+	}
+
+	if se.weight > 1 {
+		score /= float64(se.weight)
+	}
+
+	return score
+}
+
+// 一致性哈希分配，未指定用户时随机分配
+func (a *abstract) consistentHashDispatch(uid int64) (*serviceEndpoint, error) {
+	if uid == 0 || a.ring.isEmpty() {
+		return a.randomDispatch()
+	}
+
+	return a.ring.lookup(uid), nil
+}
+
+// 初始化一致性哈希环
+func (a *abstract) initHashRing() {
+	a.ring = newHashRing(a.endpoints3, func(insID string) int {
+		return a.endpoints4[insID].weight
+	})
 }
 
 // 初始化 WRR 队列
@@ -172,7 +336,7 @@ func (a *abstract) initWRRQueue() {
 	// 计算最大公约数作为步长
 	a.step = 0
 	for _, sep := range a.endpoints4 {
-		weight := a.dispatcher.instances[sep.insID].Weight
+		weight := sep.weight
 		if a.step == 0 {
 			a.step = weight
 		} else {
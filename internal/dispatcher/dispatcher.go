@@ -16,6 +16,9 @@ const (
 	Random           BalanceStrategy = "random" // 随机
 	RoundRobin       BalanceStrategy = "rr"     // 轮询
 	WeightRoundRobin BalanceStrategy = "wrr"    // 加权轮询
+	ConsistentHash   BalanceStrategy = "chash"  // 一致性哈希（按用户ID）
+	LeastLoaded      BalanceStrategy = "least"  // 最小负载
+	PowerOfTwo       BalanceStrategy = "p2c"    // 随机选取两个实例中负载较小者
 )
 
 type Dispatcher struct {
@@ -107,7 +110,7 @@ func (d *Dispatcher) ReplaceServices(services ...*registry.ServiceInstance) {
 				route = newRoute(d, item.ID, service.Alias, item.Stateful, item.Internal)
 				routes[item.ID] = route
 			}
-			route.addEndpoint(service.ID, service.State, ep)
+			route.addEndpoint(service, ep)
 		}
 
 		for _, evt := range service.Events {
@@ -116,7 +119,7 @@ func (d *Dispatcher) ReplaceServices(services ...*registry.ServiceInstance) {
 				event = newEvent(d, evt)
 				events[evt] = event
 			}
-			event.addEndpoint(service.ID, service.State, ep)
+			event.addEndpoint(service, ep)
 		}
 	}
 
@@ -126,13 +129,21 @@ func (d *Dispatcher) ReplaceServices(services ...*registry.ServiceInstance) {
 	d.endpoints = endpoints
 	d.instances = instances
 
-	if d.strategy == WeightRoundRobin {
+	switch d.strategy {
+	case WeightRoundRobin:
 		for _, route := range routes {
 			route.initWRRQueue()
 		}
 		for _, event := range events {
 			event.initWRRQueue()
 		}
+	case ConsistentHash:
+		for _, route := range routes {
+			route.initHashRing()
+		}
+		for _, event := range events {
+			event.initHashRing()
+		}
 	}
 	d.rw.Unlock()
 }
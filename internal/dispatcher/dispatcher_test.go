@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"gatesvr/cluster"
 	"gatesvr/core/endpoint"
+	"gatesvr/errors"
 	"gatesvr/internal/dispatcher"
 	"gatesvr/registry"
 	"math"
@@ -77,14 +78,14 @@ func TestDispatcher_ReplaceServices(t *testing.T) {
 	if err != nil {
 		t.Errorf("find event failed: %v", err)
 	} else {
-		t.Log(route.FindEndpoint())
+		t.Log(route.FindEndpoint(0))
 	}
 
 	//event, err := d.FindEvent(int(cluster.Disconnect))
 	//if err != nil {
 	//	t.Errorf("find event failed: %v", err)
 	//} else {
-	//	t.Log(event.FindEndpoint())
+	//	t.Log(event.FindEndpoint(0))
 	//}
 }
 
@@ -148,7 +149,7 @@ func TestDispatcher_WeightRoundRobin(t *testing.T) {
 			return
 		}
 
-		ep, err := route.FindEndpoint()
+		ep, err := route.FindEndpoint(0)
 		if err != nil {
 			t.Errorf("find endpoint failed: %v", err)
 			return
@@ -195,6 +196,160 @@ func TestDispatcher_WeightRoundRobin(t *testing.T) {
 	}
 }
 
+func TestDispatcher_FindAvailableEndpoint(t *testing.T) {
+	var (
+		instance1 = &registry.ServiceInstance{
+			ID:       "xa",
+			Name:     "node-1",
+			Kind:     cluster.Node.String(),
+			Alias:    "node-1",
+			State:    cluster.Work.String(),
+			Endpoint: endpoint.NewEndpoint("grpc", "127.0.0.1:8001", false).String(),
+			Routes: []registry.Route{{
+				ID:       1,
+				Stateful: false,
+			}},
+		}
+		instance2 = &registry.ServiceInstance{
+			ID:       "xb",
+			Name:     "node-2",
+			Kind:     cluster.Node.String(),
+			Alias:    "node-2",
+			State:    cluster.Work.String(),
+			Endpoint: endpoint.NewEndpoint("grpc", "127.0.0.1:8002", false).String(),
+			Routes: []registry.Route{{
+				ID:       1,
+				Stateful: false,
+			}},
+		}
+	)
+
+	d := dispatcher.NewDispatcher(dispatcher.RoundRobin)
+	d.ReplaceServices(instance1, instance2)
+
+	route, err := d.FindRoute(1)
+	if err != nil {
+		t.Fatalf("find route failed: %v", err)
+	}
+
+	// 实例xa不可用时，应当故障转移至实例xb
+	for i := 0; i < 10; i++ {
+		insID, ep, err := route.FindAvailableEndpoint(0, func(insID string) bool { return insID != "xa" })
+		if err != nil {
+			t.Fatalf("find available endpoint failed: %v", err)
+		}
+
+		if insID != "xb" || ep.Address() != "127.0.0.1:8002" {
+			t.Fatalf("expected instance xb, got %s(%s)", insID, ep.Address())
+		}
+	}
+
+	// 所有实例均不可用
+	if _, _, err = route.FindAvailableEndpoint(0, func(string) bool { return false }); err != errors.ErrNotFoundHealthyEndpoint {
+		t.Fatalf("expected %v, got %v", errors.ErrNotFoundHealthyEndpoint, err)
+	}
+}
+
+func TestDispatcher_ConsistentHash(t *testing.T) {
+	newInstance := func(id string, weight int) *registry.ServiceInstance {
+		return &registry.ServiceInstance{
+			ID:       id,
+			Name:     "node",
+			Kind:     cluster.Node.String(),
+			Alias:    "node",
+			State:    cluster.Work.String(),
+			Endpoint: endpoint.NewEndpoint("grpc", id+":8000", false).String(),
+			Weight:   weight,
+			Routes:   []registry.Route{{ID: 1}},
+		}
+	}
+
+	find := func(d *dispatcher.Dispatcher, uid int64) string {
+		route, err := d.FindRoute(1)
+		if err != nil {
+			t.Fatalf("find route failed: %v", err)
+		}
+
+		insID, _, err := route.FindAvailableEndpoint(uid, func(string) bool { return true })
+		if err != nil {
+			t.Fatalf("find available endpoint failed: %v", err)
+		}
+
+		// 未指定实例时同样按用户ID分配
+		ep, err := route.FindEndpoint(uid)
+		if err != nil {
+			t.Fatalf("find endpoint failed: %v", err)
+		}
+
+		if ep.Address() != insID+":8000" {
+			t.Fatalf("uid %d routed to %s and %s", uid, insID, ep.Address())
+		}
+
+		return insID
+	}
+
+	const users = 10000
+
+	d := dispatcher.NewDispatcher(dispatcher.ConsistentHash)
+	d.ReplaceServices(newInstance("xa", 1), newInstance("xb", 1), newInstance("xc", 1))
+
+	before := make(map[int64]string, users)
+	for uid := int64(1); uid <= users; uid++ {
+		before[uid] = find(d, uid)
+
+		// 同一用户总是路由至同一实例
+		if insID := find(d, uid); insID != before[uid] {
+			t.Fatalf("uid %d routed to %s and %s", uid, before[uid], insID)
+		}
+	}
+
+	// 新增实例后，仅部分用户迁移至新实例
+	d.ReplaceServices(newInstance("xa", 1), newInstance("xb", 1), newInstance("xc", 1), newInstance("xd", 1))
+
+	moved := 0
+	for uid := int64(1); uid <= users; uid++ {
+		if insID := find(d, uid); insID != before[uid] {
+			if insID != "xd" {
+				t.Fatalf("uid %d moved from %s to %s", uid, before[uid], insID)
+			}
+			moved++
+		}
+	}
+
+	if ratio := float64(moved) / users; ratio < 0.15 || ratio > 0.35 {
+		t.Fatalf("expected about 1/4 users remapped, got %.2f", ratio)
+	}
+
+	// 权重越大，分配的用户越多
+	d.ReplaceServices(newInstance("xa", 1), newInstance("xb", 3))
+
+	counts := make(map[string]int)
+	for uid := int64(1); uid <= users; uid++ {
+		counts[find(d, uid)]++
+	}
+
+	if ratio := float64(counts["xb"]) / users; ratio < 0.65 || ratio > 0.85 {
+		t.Fatalf("expected about 3/4 users on xb, got %.2f", ratio)
+	}
+
+	// 实例不可用时，沿哈希环故障转移至下一个实例
+	route, err := d.FindRoute(1)
+	if err != nil {
+		t.Fatalf("find route failed: %v", err)
+	}
+
+	for uid := int64(1); uid <= 100; uid++ {
+		insID, _, err := route.FindAvailableEndpoint(uid, func(insID string) bool { return insID != "xb" })
+		if err != nil || insID != "xa" {
+			t.Fatalf("expected instance xa, got %s, err: %v", insID, err)
+		}
+	}
+
+	if _, _, err = route.FindAvailableEndpoint(1, func(string) bool { return false }); err != errors.ErrNotFoundHealthyEndpoint {
+		t.Fatalf("expected %v, got %v", errors.ErrNotFoundHealthyEndpoint, err)
+	}
+}
+
 func BenchmarkDispatcher_WeightRoundRobin(b *testing.B) {
 	var (
 		// 创建测试服务实例
@@ -292,7 +447,7 @@ func BenchmarkDispatcher_WeightRoundRobin(b *testing.B) {
 					if err != nil {
 						b.Fatal(err)
 					}
-					_, err = route.FindEndpoint()
+					_, err = route.FindEndpoint(0)
 					if err != nil {
 						b.Fatal(err)
 					}
@@ -304,3 +459,95 @@ func BenchmarkDispatcher_WeightRoundRobin(b *testing.B) {
 		})
 	}
 }
+
+func TestDispatcher_LeastLoadedConcurrentReplace(t *testing.T) {
+	newInstance := func(id string, pending int) *registry.ServiceInstance {
+		return &registry.ServiceInstance{
+			ID:       id,
+			Name:     id,
+			Kind:     cluster.Node.String(),
+			Alias:    id,
+			State:    cluster.Work.String(),
+			Endpoint: endpoint.NewEndpoint("grpc", "127.0.0.1:8001", false).String(),
+			Routes:   []registry.Route{{ID: 1}},
+			Weight:   1,
+			Load:     &registry.Load{Pending: pending},
+		}
+	}
+
+	for _, strategy := range []dispatcher.BalanceStrategy{dispatcher.LeastLoaded, dispatcher.PowerOfTwo} {
+		d := dispatcher.NewDispatcher(strategy)
+		d.ReplaceServices(newInstance("xa", 0), newInstance("xb", 100))
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+
+			// 注册中心更新与负载均衡分配并发进行
+			for i := 0; i < 1000; i++ {
+				d.ReplaceServices(newInstance("xa", i%10), newInstance("xb", 100))
+			}
+		}()
+
+		for i := 0; i < 1000; i++ {
+			route, err := d.FindRoute(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ep, err := route.FindEndpoint(0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ep.Address() != "127.0.0.1:8001" {
+				t.Fatalf("unexpected endpoint: %s", ep.Address())
+			}
+		}
+
+		<-done
+
+		route, _ := d.FindRoute(1)
+		if insID, _, err := route.FindAvailableEndpoint(0, func(string) bool { return true }); err != nil || insID != "xa" {
+			t.Fatalf("%s: expected least loaded instance xa, got %s err: %v", strategy, insID, err)
+		}
+	}
+}
+
+func TestDispatcher_LeastLoadedSpread(t *testing.T) {
+	newInstance := func(id string, pending int) *registry.ServiceInstance {
+		return &registry.ServiceInstance{
+			ID:       id,
+			Name:     id,
+			Kind:     cluster.Node.String(),
+			Alias:    id,
+			State:    cluster.Work.String(),
+			Endpoint: endpoint.NewEndpoint("grpc", "127.0.0.1:8001", false).String(),
+			Routes:   []registry.Route{{ID: 1}},
+			Weight:   1,
+			Load:     &registry.Load{Pending: pending},
+		}
+	}
+
+	d := dispatcher.NewDispatcher(dispatcher.LeastLoaded)
+	d.ReplaceServices(newInstance("xa", 0), newInstance("xb", 10))
+
+	route, err := d.FindRoute(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 下一次负载上报前，本地已分配的请求计入评分，请求不应全部集中到快照中负载最小的实例
+	counts := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		insID, _, err := route.FindAvailableEndpoint(0, func(string) bool { return true })
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[insID]++
+	}
+
+	if counts["xa"] < 50 || counts["xb"] < 40 {
+		t.Fatalf("unexpected distribution: %v", counts)
+	}
+}
@@ -0,0 +1,126 @@
+package dispatcher
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+const virtualNodes = 160 // 每单位权重的虚拟节点数
+
+// 一致性哈希环
+type hashRing struct {
+	points    []uint64           // 虚拟节点哈希值（升序）
+	endpoints []*serviceEndpoint // 虚拟节点对应的服务端点
+	distinct  int                // 服务端点数
+}
+
+// 构建一致性哈希环
+// 虚拟节点位置仅由实例ID与序号决定，实例增减时仅影响相邻区间的映射
+func newHashRing(endpoints []*serviceEndpoint, weight func(insID string) int) *hashRing {
+	type point struct {
+		hash uint64
+		se   *serviceEndpoint
+	}
+
+	points := make([]point, 0, len(endpoints)*virtualNodes)
+	for _, se := range endpoints {
+		w := weight(se.insID)
+		if w <= 0 {
+			w = 1
+		}
+
+		for i := 0; i < w*virtualNodes; i++ {
+			points = append(points, point{hash: hashString(se.insID + "#" + strconv.Itoa(i)), se: se})
+		}
+	}
+
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].hash == points[j].hash {
+			return points[i].se.insID < points[j].se.insID
+		}
+		return points[i].hash < points[j].hash
+	})
+
+	r := &hashRing{
+		points:    make([]uint64, len(points)),
+		endpoints: make([]*serviceEndpoint, len(points)),
+		distinct:  len(endpoints),
+	}
+
+	for i, p := range points {
+		r.points[i] = p.hash
+		r.endpoints[i] = p.se
+	}
+
+	return r
+}
+
+// 判断哈希环是否为空
+func (r *hashRing) isEmpty() bool {
+	return r == nil || len(r.points) == 0
+}
+
+// 按顺时针方向迭代uid映射到的服务端点，每个服务端点仅迭代一次
+func (r *hashRing) iterate(uid int64, fn func(se *serviceEndpoint) bool) {
+	if r.isEmpty() {
+		return
+	}
+
+	key := hashUID(uid)
+	start := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= key })
+	visited := make(map[string]struct{}, r.distinct)
+
+	for i := 0; i < len(r.points) && len(visited) < r.distinct; i++ {
+		se := r.endpoints[(start+i)%len(r.points)]
+		if _, ok := visited[se.insID]; ok {
+			continue
+		}
+		visited[se.insID] = struct{}{}
+
+		if !fn(se) {
+			return
+		}
+	}
+}
+
+// 查找uid映射到的服务端点
+func (r *hashRing) lookup(uid int64) *serviceEndpoint {
+	if r.isEmpty() {
+		return nil
+	}
+
+	key := hashUID(uid)
+	index := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= key })
+
+	return r.endpoints[index%len(r.points)]
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+
+	return mix(h.Sum64())
+}
+
+func hashUID(uid int64) uint64 {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(uid))
+
+	h := fnv.New64a()
+	_, _ = h.Write(buf[:])
+
+	return mix(h.Sum64())
+}
+
+// 打散哈希值，改善FNV对相近输入的分布
+func mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+
+	return h
+}
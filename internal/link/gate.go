@@ -11,6 +11,7 @@ import (
 	"gatesvr/log"
 	"gatesvr/packet"
 	"gatesvr/registry"
+	"math"
 
 	"gatesvr/internal/dispatcher"
 	"gatesvr/session"
@@ -426,6 +427,103 @@ func (l *GateLinker) doIndirectMulticast(ctx context.Context, args *MulticastArg
 	return eg.Wait()
 }
 
+// JoinGroup 加入分组
+func (l *GateLinker) JoinGroup(ctx context.Context, args *GroupArgs) error {
+	return l.doGroup(ctx, args, func(client *gate.Client, kind session.Kind, target int64) (bool, error) {
+		return client.JoinGroup(ctx, kind, target, args.Group)
+	})
+}
+
+// LeaveGroup 退出分组
+func (l *GateLinker) LeaveGroup(ctx context.Context, args *GroupArgs) error {
+	return l.doGroup(ctx, args, func(client *gate.Client, kind session.Kind, target int64) (bool, error) {
+		return client.LeaveGroup(ctx, kind, target, args.Group)
+	})
+}
+
+// 执行分组成员操作
+func (l *GateLinker) doGroup(ctx context.Context, args *GroupArgs, fn func(client *gate.Client, kind session.Kind, target int64) (bool, error)) error {
+	if args.Group == "" {
+		return errors.ErrInvalidGroup
+	}
+
+	switch args.Kind {
+	case session.Conn:
+	case session.User:
+		if args.GID == "" {
+			_, err := l.doRPC(ctx, args.Target, func(client *gate.Client) (bool, interface{}, error) {
+				miss, err := fn(client, args.Kind, args.Target)
+				return miss, nil, err
+			})
+			return err
+		}
+	default:
+		return errors.ErrInvalidSessionKind
+	}
+
+	client, err := l.doBuildClient(args.GID)
+	if err != nil {
+		return err
+	}
+
+	_, err = fn(client, args.Kind, args.Target)
+
+	return err
+}
+
+// MulticastGroup 推送分组消息，未指定网关时推送至所有网关
+func (l *GateLinker) MulticastGroup(ctx context.Context, args *MulticastGroupArgs) error {
+	// 协议中分组名长度为16位，超长的分组名无法投递
+	if args.Group == "" || len(args.Group) > math.MaxUint16 {
+		return errors.ErrInvalidGroup
+	}
+
+	if args.GID != "" {
+		message, err := l.PackMessage(args.Message, true)
+		if err != nil {
+			return err
+		}
+
+		client, err := l.doBuildClient(args.GID)
+		if err != nil {
+			return err
+		}
+
+		return client.MulticastGroup(ctx, args.Group, message)
+	}
+
+	buf, err := l.PackBuffer(args.Message.Data, true)
+	if err != nil {
+		return err
+	}
+
+	eg, ctx := errgroup.WithContext(ctx)
+
+	l.dispatcher.IterateEndpoint(func(_ string, ep *endpoint.Endpoint) bool {
+		eg.Go(func() error {
+			message, err := packet.PackBuffer(&packet.Message{
+				Seq:    args.Message.Seq,
+				Route:  args.Message.Route,
+				Buffer: buf,
+			})
+			if err != nil {
+				return err
+			}
+
+			client, err := l.builder.Build(ep.Address())
+			if err != nil {
+				return err
+			}
+
+			return client.MulticastGroup(ctx, args.Group, message)
+		})
+
+		return true
+	})
+
+	return eg.Wait()
+}
+
 // Broadcast 推送广播消息
 func (l *GateLinker) Broadcast(ctx context.Context, args *BroadcastArgs) error {
 	buf, err := l.PackBuffer(args.Message.Data, true)
@@ -9,14 +9,18 @@ import (
 	"gatesvr/internal/transporter/node"
 	"gatesvr/locate"
 	"gatesvr/log"
+	"gatesvr/metrics"
 	"gatesvr/packet"
 	"gatesvr/registry"
+	"strconv"
 
 	"golang.org/x/sync/errgroup"
 	"sync"
 	"time"
 )
 
+const unknownRouteLabel = "unknown" // 分发器未知路由的指标标签
+
 type NodeLinker struct {
 	ctx        context.Context             // 上下文
 	opts       *Options                    // 参数项
@@ -62,6 +66,11 @@ func (l *NodeLinker) Ask(ctx context.Context, uid int64, name, nid string) (stri
 	return insID, insID == nid, nil
 }
 
+// Dispatcher 获取分发器
+func (l *NodeLinker) Dispatcher() *dispatcher.Dispatcher {
+	return l.dispatcher
+}
+
 // Has 检测是否存在某个节点
 func (l *NodeLinker) Has(nid string) bool {
 	_, err := l.dispatcher.FindEndpoint(nid)
@@ -129,8 +138,32 @@ func (l *NodeLinker) Unbind(ctx context.Context, uid int64, name, nid string) er
 	return nil
 }
 
+// RouteLabel 获取路由的指标标签，分发器未知的路由统一为unknown，避免客户端构造的路由产生无限的指标序列
+func (l *NodeLinker) RouteLabel(route int32) string {
+	if _, err := l.dispatcher.FindRoute(route); err != nil {
+		return unknownRouteLabel
+	}
+
+	return strconv.Itoa(int(route))
+}
+
 // Deliver 投递消息给节点处理
 func (l *NodeLinker) Deliver(ctx context.Context, args *DeliverArgs) error {
+	start := time.Now()
+	route := l.RouteLabel(args.Route)
+
+	err := l.doDeliver(ctx, args)
+	if err != nil {
+		metrics.DeliverErrors.Add(1, route)
+	}
+
+	metrics.DeliverDuration.Observe(time.Since(start).Seconds(), route)
+
+	return err
+}
+
+// 投递消息
+func (l *NodeLinker) doDeliver(ctx context.Context, args *DeliverArgs) error {
 	var message []byte
 
 	switch msg := args.Message.(type) {
@@ -147,12 +180,19 @@ func (l *NodeLinker) Deliver(ctx context.Context, args *DeliverArgs) error {
 	}
 
 	if args.NID != "" {
+		if !l.doAllowRequest(args.NID) {
+			return errors.ErrServerCircuitBreaker
+		}
+
 		client, err := l.doBuildClient(args.NID)
 		if err != nil {
 			return err
 		}
 
-		return client.Deliver(ctx, args.CID, args.UID, message)
+		err = client.Deliver(ctx, args.CID, args.UID, message)
+		l.doRecordResult(args.NID, err)
+
+		return err
 	} else {
 		_, err := l.doRPC(ctx, args.Route, args.UID, func(ctx context.Context, client *node.Client) (bool, interface{}, error) {
 			return false, nil, client.Deliver(ctx, args.CID, args.UID, message)
@@ -175,14 +215,19 @@ func (l *NodeLinker) Trigger(ctx context.Context, args *TriggerArgs) error {
 
 	eg, ctx := errgroup.WithContext(ctx)
 
-	event.IterateEndpoint(func(_ string, ep *endpoint.Endpoint) bool {
+	// 连接、断开等事件须送达所有节点，不受熔断器拦截，调用结果仍计入熔断统计
+	event.IterateEndpoint(func(insID string, ep *endpoint.Endpoint) bool {
 		eg.Go(func() error {
 			client, err := l.builder.Build(ep.Address())
 			if err != nil {
+				l.doRecordResult(insID, err)
 				return err
 			}
 
-			return client.Trigger(ctx, args.Event, args.CID, args.UID)
+			err = client.Trigger(ctx, args.Event, args.CID, args.UID)
+			l.doRecordResult(insID, err)
+
+			return err
 		})
 
 		return true
@@ -237,6 +282,47 @@ func (l *NodeLinker) SetState(ctx context.Context, nid string, state cluster.Sta
 	return client.SetState(ctx, state)
 }
 
+// TellActor 向节点上的Actor发送单向消息
+func (l *NodeLinker) TellActor(ctx context.Context, nid, sender, target string, message []byte) error {
+	if !l.doAllowRequest(nid) {
+		return errors.ErrServerCircuitBreaker
+	}
+
+	client, err := l.doBuildClient(nid)
+	if err != nil {
+		return err
+	}
+
+	err = client.Tell(ctx, sender, target, message)
+	l.doRecordResult(nid, err)
+
+	return err
+}
+
+// AskActor 向节点上的Actor发送请求并等待回复
+func (l *NodeLinker) AskActor(ctx context.Context, nid, sender, target string, message []byte) ([]byte, error) {
+	if !l.doAllowRequest(nid) {
+		return nil, errors.ErrServerCircuitBreaker
+	}
+
+	client, err := l.doBuildClient(nid)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := client.Ask(ctx, sender, target, message)
+
+	// Actor的业务错误不计入熔断
+	switch {
+	case errors.Is(err, errors.ErrNotFoundActor), errors.Is(err, errors.ErrMissingReceiver), errors.Is(err, errors.ErrActorPanic):
+		l.doRecordResult(nid, nil)
+	default:
+		l.doRecordResult(nid, err)
+	}
+
+	return reply, err
+}
+
 // 执行节点RPC调用
 func (l *NodeLinker) doRPC(ctx context.Context, routeID int32, uid int64, fn func(ctx context.Context, client *node.Client) (bool, interface{}, error)) (interface{}, error) {
 	var (
@@ -268,10 +354,17 @@ func (l *NodeLinker) doRPC(ctx context.Context, routeID int32, uid int64, fn fun
 				return reply, err
 			}
 			prev = nid
-		}
 
-		ep, err = route.FindEndpoint(nid)
-		//log.Debugf("endpoint: %s", ep)
+			// 有状态路由无法故障转移，熔断期间直接拒绝
+			if !l.doAllowRequest(nid) {
+				return nil, errors.ErrServerCircuitBreaker
+			}
+
+			ep, err = route.FindEndpoint(uid, nid)
+		} else {
+			// 无状态路由跳过处于熔断状态的节点
+			nid, ep, err = route.FindAvailableEndpoint(uid, l.doAllowRequest)
+		}
 
 		if err != nil {
 			log.Errorf("find endpoint error: %s", err)
@@ -280,10 +373,12 @@ func (l *NodeLinker) doRPC(ctx context.Context, routeID int32, uid int64, fn fun
 
 		client, err = l.builder.Build(ep.Address())
 		if err != nil {
+			l.doRecordResult(nid, err)
 			return nil, err
 		}
 
 		continued, reply, err = fn(ctx, client)
+		l.doRecordResult(nid, err)
 		if continued {
 			if route.Stateful() {
 				l.doDeleteSource(uid, route.Group(), prev)
@@ -297,6 +392,28 @@ func (l *NodeLinker) doRPC(ctx context.Context, routeID int32, uid int64, fn fun
 	return reply, err
 }
 
+// 检测节点是否允许请求
+func (l *NodeLinker) doAllowRequest(nid string) bool {
+	if l.opts.CircuitBreaker == nil {
+		return true
+	}
+
+	return l.opts.CircuitBreaker.AllowRequest(nid)
+}
+
+// 记录节点请求结果
+func (l *NodeLinker) doRecordResult(nid string, err error) {
+	if l.opts.CircuitBreaker == nil || nid == "" {
+		return
+	}
+
+	if err != nil {
+		l.opts.CircuitBreaker.RecordFail(nid)
+	} else {
+		l.opts.CircuitBreaker.RecordSuccess(nid)
+	}
+}
+
 // 构建节点客户端
 func (l *NodeLinker) doBuildClient(nid string) (*node.Client, error) {
 	if nid == "" {
@@ -468,6 +585,14 @@ func (l *NodeLinker) WatchClusterInstance() {
 			}
 
 			l.dispatcher.ReplaceServices(services...)
+
+			if l.opts.CircuitBreaker != nil {
+				ids := make([]string, 0, len(services))
+				for _, service := range services {
+					ids = append(ids, service.ID)
+				}
+				l.opts.CircuitBreaker.Retain(ids...)
+			}
 		}
 	}()
 }
@@ -1,6 +1,7 @@
 package link
 
 import (
+	"gatesvr/circuitbreaker"
 	"gatesvr/cluster"
 	"gatesvr/crypto"
 	"gatesvr/encoding"
@@ -17,4 +18,5 @@ type Options struct {
 	Registry        registry.Registry          // 注册器
 	Encryptor       crypto.Encryptor           // 加密器
 	BalanceStrategy dispatcher.BalanceStrategy // 负载均衡策略
+	CircuitBreaker  *circuitbreaker.Group      // 熔断器组（按节点实例ID隔离）
 }
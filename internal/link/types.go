@@ -5,13 +5,15 @@ import (
 )
 
 type (
-	Message        = cluster.Message
-	GetIPArgs      = cluster.GetIPArgs
-	IsOnlineArgs   = cluster.IsOnlineArgs
-	DisconnectArgs = cluster.DisconnectArgs
-	PushArgs       = cluster.PushArgs
-	MulticastArgs  = cluster.MulticastArgs
-	BroadcastArgs  = cluster.BroadcastArgs
+	Message            = cluster.Message
+	GetIPArgs          = cluster.GetIPArgs
+	IsOnlineArgs       = cluster.IsOnlineArgs
+	DisconnectArgs     = cluster.DisconnectArgs
+	PushArgs           = cluster.PushArgs
+	MulticastArgs      = cluster.MulticastArgs
+	BroadcastArgs      = cluster.BroadcastArgs
+	GroupArgs          = cluster.GroupArgs
+	MulticastGroupArgs = cluster.MulticastGroupArgs
 )
 
 type DeliverArgs struct {
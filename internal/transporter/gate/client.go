@@ -137,6 +137,49 @@ func (c *Client) Broadcast(ctx context.Context, seq uint64, kind session.Kind, m
 	return c.cli.Send(ctx, protocol.EncodeBroadcastReq(seq, kind, message))
 }
 
+// JoinGroup 加入分组
+func (c *Client) JoinGroup(ctx context.Context, kind session.Kind, target int64, group string) (bool, error) {
+	seq := c.doGenSequence()
+
+	buf := protocol.EncodeJoinGroupReq(seq, kind, target, group)
+
+	res, err := c.cli.Call(ctx, seq, buf)
+	if err != nil {
+		return false, err
+	}
+
+	code, err := protocol.DecodeJoinGroupRes(res)
+	if err != nil {
+		return false, err
+	}
+
+	return code == codes.NotFoundSession, codes.CodeToError(code)
+}
+
+// LeaveGroup 退出分组
+func (c *Client) LeaveGroup(ctx context.Context, kind session.Kind, target int64, group string) (bool, error) {
+	seq := c.doGenSequence()
+
+	buf := protocol.EncodeLeaveGroupReq(seq, kind, target, group)
+
+	res, err := c.cli.Call(ctx, seq, buf)
+	if err != nil {
+		return false, err
+	}
+
+	code, err := protocol.DecodeLeaveGroupRes(res)
+	if err != nil {
+		return false, err
+	}
+
+	return code == codes.NotFoundSession, codes.CodeToError(code)
+}
+
+// MulticastGroup 推送分组消息
+func (c *Client) MulticastGroup(ctx context.Context, group string, message buffer.Buffer) error {
+	return c.cli.Send(ctx, protocol.EncodeMulticastGroupReq(0, group, message))
+}
+
 // GetState 获取状态
 func (c *Client) GetState(ctx context.Context) (cluster.State, error) {
 	seq := c.doGenSequence()
@@ -25,6 +25,12 @@ type Provider interface {
 	Multicast(ctx context.Context, kind session.Kind, targets []int64, message []byte) (total int64, err error)
 	// Broadcast 推送广播消息
 	Broadcast(ctx context.Context, kind session.Kind, message []byte) (total int64, err error)
+	// JoinGroup 加入分组
+	JoinGroup(ctx context.Context, kind session.Kind, target int64, group string) error
+	// LeaveGroup 退出分组
+	LeaveGroup(ctx context.Context, kind session.Kind, target int64, group string) error
+	// MulticastGroup 推送分组消息
+	MulticastGroup(ctx context.Context, group string, message []byte) (total int64, err error)
 	// GetState 获取状态
 	GetState() (cluster.State, error)
 	// SetState 设置状态
@@ -37,6 +37,9 @@ func (s *Server) init() {
 	s.RegisterHandler(route.Multicast, s.multicast)
 	s.RegisterHandler(route.Broadcast, s.broadcast)
 	s.RegisterHandler(route.IsOnline, s.isOnline)
+	s.RegisterHandler(route.JoinGroup, s.joinGroup)
+	s.RegisterHandler(route.LeaveGroup, s.leaveGroup)
+	s.RegisterHandler(route.MulticastGroup, s.multicastGroup)
 }
 
 // 绑定用户
@@ -172,6 +175,48 @@ func (s *Server) broadcast(conn *server.Conn, data []byte) error {
 	}
 }
 
+// 加入分组
+func (s *Server) joinGroup(conn *server.Conn, data []byte) error {
+	seq, kind, target, group, err := protocol.DecodeJoinGroupReq(data)
+	if err != nil {
+		return err
+	}
+
+	if err = s.provider.JoinGroup(context.Background(), kind, target, group); seq == 0 {
+		return err
+	} else {
+		return conn.Send(protocol.EncodeJoinGroupRes(seq, codes.ErrorToCode(err)))
+	}
+}
+
+// 退出分组
+func (s *Server) leaveGroup(conn *server.Conn, data []byte) error {
+	seq, kind, target, group, err := protocol.DecodeLeaveGroupReq(data)
+	if err != nil {
+		return err
+	}
+
+	if err = s.provider.LeaveGroup(context.Background(), kind, target, group); seq == 0 {
+		return err
+	} else {
+		return conn.Send(protocol.EncodeLeaveGroupRes(seq, codes.ErrorToCode(err)))
+	}
+}
+
+// 推送分组消息
+func (s *Server) multicastGroup(conn *server.Conn, data []byte) error {
+	seq, group, message, err := protocol.DecodeMulticastGroupReq(data)
+	if err != nil {
+		return err
+	}
+
+	if total, err := s.provider.MulticastGroup(context.Background(), group, message); seq == 0 {
+		return err
+	} else {
+		return conn.Send(protocol.EncodeMulticastGroupRes(seq, codes.ErrorToCode(err), uint64(total)))
+	}
+}
+
 // 获取状态
 func (s *Server) getState(conn *server.Conn, data []byte) error {
 	seq, err := protocol.DecodeGetStateReq(data)
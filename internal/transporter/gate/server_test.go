@@ -66,6 +66,21 @@ func (p *provider) Broadcast(ctx context.Context, kind session.Kind, message []b
 	return
 }
 
+// JoinGroup 加入分组
+func (p *provider) JoinGroup(ctx context.Context, kind session.Kind, target int64, group string) error {
+	return nil
+}
+
+// LeaveGroup 退出分组
+func (p *provider) LeaveGroup(ctx context.Context, kind session.Kind, target int64, group string) error {
+	return nil
+}
+
+// MulticastGroup 推送分组消息（异步）
+func (p *provider) MulticastGroup(ctx context.Context, group string, message []byte) (total int64, err error) {
+	return
+}
+
 // Stat 统计会话总数
 func (p *provider) Stat(ctx context.Context, kind session.Kind) (total int64, err error) {
 	return
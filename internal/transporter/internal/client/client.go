@@ -2,10 +2,10 @@ package client
 
 import (
 	"context"
-	"gatesvr/circuitbreaker"
 	"gatesvr/core/buffer"
 	"gatesvr/errors"
 	"gatesvr/log"
+	"gatesvr/metrics"
 
 	"sync"
 	"sync/atomic"
@@ -29,12 +29,11 @@ type chWrite struct {
 }
 
 type Client struct {
-	opts           *Options       // 配置
-	chWrite        chan *chWrite  // 写入队列
-	connections    []*Conn        // 连接
-	wg             sync.WaitGroup // 等待组
-	closed         atomic.Bool    // 已关闭
-	circuitbreaker *circuitbreaker.CircuitBreaker
+	opts        *Options       // 配置
+	chWrite     chan *chWrite  // 写入队列
+	connections []*Conn        // 连接
+	wg          sync.WaitGroup // 等待组
+	closed      atomic.Bool    // 已关闭
 }
 
 func NewClient(opts *Options) *Client {
@@ -42,7 +41,6 @@ func NewClient(opts *Options) *Client {
 	c.opts = opts
 	c.chWrite = make(chan *chWrite, 10240)
 	c.connections = make([]*Conn, 0, ordered+unordered)
-	c.circuitbreaker = circuitbreaker.NewCircuitBreaker(3, 0.5, 3*time.Second)
 	c.init()
 
 	return c
@@ -50,6 +48,16 @@ func NewClient(opts *Options) *Client {
 
 // Call 调用
 func (c *Client) Call(ctx context.Context, seq uint64, buf buffer.Buffer, idx ...int64) ([]byte, error) {
+	start := time.Now()
+
+	data, err := c.doCall(ctx, seq, buf, idx...)
+	c.observe("call", start, err)
+
+	return data, err
+}
+
+// 调用
+func (c *Client) doCall(ctx context.Context, seq uint64, buf buffer.Buffer, idx ...int64) ([]byte, error) {
 	if c.closed.Load() {
 		return nil, errors.ErrClientClosed
 	}
@@ -85,25 +93,36 @@ func (c *Client) Call(ctx context.Context, seq uint64, buf buffer.Buffer, idx ..
 
 // Send 发送
 func (c *Client) Send(ctx context.Context, buf buffer.Buffer, idx ...int64) error {
+	start := time.Now()
+
+	err := c.doSend(ctx, buf, idx...)
+	c.observe("send", start, err)
+
+	return err
+}
+
+// 发送
+func (c *Client) doSend(ctx context.Context, buf buffer.Buffer, idx ...int64) error {
 	if c.closed.Load() {
 		return errors.ErrClientClosed
 	}
 
 	conn := c.load(idx...)
-	if !c.circuitbreaker.AllowRequest() {
-		return errors.ErrServerCircuitBreaker
-	}
-	if err := conn.send(&chWrite{
+
+	return conn.send(&chWrite{
 		ctx:  ctx,
 		buf:  buf,
 		call: nil,
-	}); err != nil {
-		c.circuitbreaker.RecordFail()
-		return err
-	} else {
-		c.circuitbreaker.RecordSuccess()
+	})
+}
+
+// 记录调用指标
+func (c *Client) observe(method string, start time.Time, err error) {
+	if err != nil {
+		metrics.TransporterErrors.Add(1, c.opts.Addr, method)
 	}
-	return nil
+
+	metrics.TransporterDuration.Observe(time.Since(start).Seconds(), c.opts.Addr, method)
 }
 
 // 获取连接
@@ -8,6 +8,9 @@ const (
 	OK              uint16 = iota // 成功
 	NotFoundSession               // 未找到会话连接
 	InternalError                 // 内部错误
+	NotFoundActor                 // 未找到Actor
+	MissingReceiver               // Actor未实现消息接收器
+	ActorPanic                    // Actor处理消息时发生panic
 )
 
 // ErrorToCode 错误转错误码
@@ -17,6 +20,12 @@ func ErrorToCode(err error) uint16 {
 		return OK
 	case errors.Is(err, errors.ErrNotFoundSession):
 		return NotFoundSession
+	case errors.Is(err, errors.ErrNotFoundActor):
+		return NotFoundActor
+	case errors.Is(err, errors.ErrMissingReceiver):
+		return MissingReceiver
+	case errors.Is(err, errors.ErrActorPanic):
+		return ActorPanic
 	default:
 		return InternalError
 	}
@@ -28,6 +37,12 @@ func CodeToError(code uint16) error {
 		return nil
 	case NotFoundSession:
 		return errors.ErrNotFoundSession
+	case NotFoundActor:
+		return errors.ErrNotFoundActor
+	case MissingReceiver:
+		return errors.ErrMissingReceiver
+	case ActorPanic:
+		return errors.ErrActorPanic
 	default:
 		return errors.ErrUnknownError
 	}
@@ -0,0 +1,125 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"gatesvr/core/buffer"
+	"gatesvr/errors"
+	"gatesvr/internal/transporter/internal/codes"
+	"gatesvr/internal/transporter/internal/route"
+	"io"
+	"math"
+)
+
+const (
+	actorReqBytes = defaultSizeBytes + defaultHeaderBytes + defaultRouteBytes + defaultSeqBytes + b16 + b16
+	actorResBytes = defaultSizeBytes + defaultHeaderBytes + defaultRouteBytes + defaultSeqBytes + defaultCodeBytes
+)
+
+// EncodeActorReq 编码Actor消息请求（PID最长65535字节），seq为0时无需响应
+// 协议：size + header + route + seq + sender len + sender + target len + target + <message>
+func EncodeActorReq(seq uint64, sender, target string, message []byte) buffer.Buffer {
+	if len(sender) > math.MaxUint16 {
+		sender = sender[:math.MaxUint16]
+	}
+
+	if len(target) > math.MaxUint16 {
+		target = target[:math.MaxUint16]
+	}
+
+	size := actorReqBytes + len(sender) + len(target)
+	buf := buffer.NewNocopyBuffer()
+	writer := buf.Malloc(size)
+	writer.WriteUint32s(binary.BigEndian, uint32(size-defaultSizeBytes+len(message)))
+	writer.WriteUint8s(dataBit)
+	writer.WriteUint8s(route.Actor)
+	writer.WriteUint64s(binary.BigEndian, seq)
+	writer.WriteUint16s(binary.BigEndian, uint16(len(sender)))
+	writer.WriteString(sender)
+	writer.WriteUint16s(binary.BigEndian, uint16(len(target)))
+	writer.WriteString(target)
+	buf.Mount(message)
+
+	return buf
+}
+
+// DecodeActorReq 解码Actor消息请求
+// 协议：size + header + route + seq + sender len + sender + target len + target + <message>
+func DecodeActorReq(data []byte) (seq uint64, sender, target string, message []byte, err error) {
+	reader := buffer.NewReader(data)
+
+	if _, err = reader.Seek(defaultSizeBytes+defaultHeaderBytes+defaultRouteBytes, io.SeekStart); err != nil {
+		return
+	}
+
+	if seq, err = reader.ReadUint64(binary.BigEndian); err != nil {
+		return
+	}
+
+	n, err := reader.ReadUint16(binary.BigEndian)
+	if err != nil {
+		return
+	}
+
+	if sender, err = reader.ReadString(int(n)); err != nil {
+		return
+	}
+
+	m, err := reader.ReadUint16(binary.BigEndian)
+	if err != nil {
+		return
+	}
+
+	if target, err = reader.ReadString(int(m)); err != nil {
+		return
+	}
+
+	message = data[actorReqBytes+int(n)+int(m):]
+
+	return
+}
+
+// EncodeActorRes 编码Actor消息响应
+// 协议：size + header + route + seq + code + [reply]
+func EncodeActorRes(seq uint64, code uint16, reply ...[]byte) buffer.Buffer {
+	size := actorResBytes - defaultSizeBytes
+	if code == codes.OK && len(reply) > 0 {
+		size += len(reply[0])
+	}
+
+	buf := buffer.NewNocopyBuffer()
+	writer := buf.Malloc(actorResBytes)
+	writer.WriteUint32s(binary.BigEndian, uint32(size))
+	writer.WriteUint8s(dataBit)
+	writer.WriteUint8s(route.Actor)
+	writer.WriteUint64s(binary.BigEndian, seq)
+	writer.WriteUint16s(binary.BigEndian, code)
+
+	if code == codes.OK && len(reply) > 0 {
+		buf.Mount(reply[0])
+	}
+
+	return buf
+}
+
+// DecodeActorRes 解码Actor消息响应
+// 协议：size + header + route + seq + code + [reply]
+func DecodeActorRes(data []byte) (code uint16, reply []byte, err error) {
+	if len(data) < actorResBytes {
+		err = errors.ErrInvalidMessage
+		return
+	}
+
+	reader := buffer.NewReader(data)
+
+	if _, err = reader.Seek(defaultSizeBytes+defaultHeaderBytes+defaultRouteBytes+defaultSeqBytes, io.SeekStart); err != nil {
+		return
+	}
+
+	if code, err = reader.ReadUint16(binary.BigEndian); err != nil {
+		return
+	}
+
+	reply = data[actorResBytes:]
+
+	return
+}
@@ -0,0 +1,43 @@
+package protocol_test
+
+import (
+	"gatesvr/internal/transporter/internal/codes"
+	"gatesvr/internal/transporter/internal/protocol"
+	"testing"
+)
+
+func TestActorReq(t *testing.T) {
+	buf := protocol.EncodeActorReq(1, "guild/1@node-1", "player/2", []byte("hello"))
+
+	seq, sender, target, message, err := protocol.DecodeActorReq(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if seq != 1 || sender != "guild/1@node-1" || target != "player/2" || string(message) != "hello" {
+		t.Fatalf("unexpected request: %d %q %q %q", seq, sender, target, message)
+	}
+}
+
+func TestActorRes(t *testing.T) {
+	buf := protocol.EncodeActorRes(1, codes.OK, []byte("world"))
+
+	code, reply, err := protocol.DecodeActorRes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code != codes.OK || string(reply) != "world" {
+		t.Fatalf("unexpected response: %d %q", code, reply)
+	}
+
+	buf = protocol.EncodeActorRes(1, codes.NotFoundActor, []byte("world"))
+
+	if code, reply, err = protocol.DecodeActorRes(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	if code != codes.NotFoundActor || len(reply) != 0 {
+		t.Fatalf("unexpected response: %d %q", code, reply)
+	}
+}
@@ -0,0 +1,243 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"gatesvr/core/buffer"
+	"gatesvr/errors"
+	"gatesvr/internal/transporter/internal/codes"
+	"gatesvr/internal/transporter/internal/route"
+	"gatesvr/session"
+	"io"
+	"math"
+)
+
+const (
+	groupMemberReqBytes    = defaultSizeBytes + defaultHeaderBytes + defaultRouteBytes + defaultSeqBytes + b8 + b64
+	groupMemberResBytes    = defaultSizeBytes + defaultHeaderBytes + defaultRouteBytes + defaultSeqBytes + defaultCodeBytes
+	multicastGroupReqBytes = defaultSizeBytes + defaultHeaderBytes + defaultRouteBytes + defaultSeqBytes + b16
+	multicastGroupResBytes = defaultSizeBytes + defaultHeaderBytes + defaultRouteBytes + defaultSeqBytes + defaultCodeBytes + b64
+)
+
+// EncodeJoinGroupReq 编码加入分组请求
+// 协议：size + header + route + seq + session kind + target + group
+func EncodeJoinGroupReq(seq uint64, kind session.Kind, target int64, group string) buffer.Buffer {
+	return encodeGroupMemberReq(route.JoinGroup, seq, kind, target, group)
+}
+
+// DecodeJoinGroupReq 解码加入分组请求
+// 协议：size + header + route + seq + session kind + target + group
+func DecodeJoinGroupReq(data []byte) (seq uint64, kind session.Kind, target int64, group string, err error) {
+	return decodeGroupMemberReq(data)
+}
+
+// EncodeJoinGroupRes 编码加入分组响应
+// 协议：size + header + route + seq + code
+func EncodeJoinGroupRes(seq uint64, code uint16) buffer.Buffer {
+	return encodeGroupMemberRes(route.JoinGroup, seq, code)
+}
+
+// DecodeJoinGroupRes 解码加入分组响应
+// 协议：size + header + route + seq + code
+func DecodeJoinGroupRes(data []byte) (code uint16, err error) {
+	return decodeGroupMemberRes(data)
+}
+
+// EncodeLeaveGroupReq 编码退出分组请求
+// 协议：size + header + route + seq + session kind + target + group
+func EncodeLeaveGroupReq(seq uint64, kind session.Kind, target int64, group string) buffer.Buffer {
+	return encodeGroupMemberReq(route.LeaveGroup, seq, kind, target, group)
+}
+
+// DecodeLeaveGroupReq 解码退出分组请求
+// 协议：size + header + route + seq + session kind + target + group
+func DecodeLeaveGroupReq(data []byte) (seq uint64, kind session.Kind, target int64, group string, err error) {
+	return decodeGroupMemberReq(data)
+}
+
+// EncodeLeaveGroupRes 编码退出分组响应
+// 协议：size + header + route + seq + code
+func EncodeLeaveGroupRes(seq uint64, code uint16) buffer.Buffer {
+	return encodeGroupMemberRes(route.LeaveGroup, seq, code)
+}
+
+// DecodeLeaveGroupRes 解码退出分组响应
+// 协议：size + header + route + seq + code
+func DecodeLeaveGroupRes(data []byte) (code uint16, err error) {
+	return decodeGroupMemberRes(data)
+}
+
+// EncodeMulticastGroupReq 编码分组推送请求（分组名最长65535字节，由调用方校验，超出部分被截断）
+// 协议：size + header + route + seq + group len + group + <message packet>
+func EncodeMulticastGroupReq(seq uint64, group string, message buffer.Buffer) buffer.Buffer {
+	if len(group) > math.MaxUint16 {
+		group = group[:math.MaxUint16]
+	}
+
+	size := multicastGroupReqBytes + len(group)
+	buf := buffer.NewNocopyBuffer()
+	writer := buf.Malloc(size)
+	writer.WriteUint32s(binary.BigEndian, uint32(size-defaultSizeBytes+message.Len()))
+	writer.WriteUint8s(dataBit)
+	writer.WriteUint8s(route.MulticastGroup)
+	writer.WriteUint64s(binary.BigEndian, seq)
+	writer.WriteUint16s(binary.BigEndian, uint16(len(group)))
+	writer.WriteString(group)
+	buf.Mount(message)
+
+	return buf
+}
+
+// DecodeMulticastGroupReq 解码分组推送请求
+// 协议：size + header + route + seq + group len + group + <message packet>
+func DecodeMulticastGroupReq(data []byte) (seq uint64, group string, message []byte, err error) {
+	reader := buffer.NewReader(data)
+
+	if _, err = reader.Seek(defaultSizeBytes+defaultHeaderBytes+defaultRouteBytes, io.SeekStart); err != nil {
+		return
+	}
+
+	if seq, err = reader.ReadUint64(binary.BigEndian); err != nil {
+		return
+	}
+
+	n, err := reader.ReadUint16(binary.BigEndian)
+	if err != nil {
+		return
+	}
+
+	if group, err = reader.ReadString(int(n)); err != nil {
+		return
+	}
+
+	message = data[multicastGroupReqBytes+int(n):]
+
+	return
+}
+
+// EncodeMulticastGroupRes 编码分组推送响应
+// 协议：size + header + route + seq + code + [total]
+func EncodeMulticastGroupRes(seq uint64, code uint16, total ...uint64) buffer.Buffer {
+	size := multicastGroupResBytes - defaultSizeBytes
+	if code != codes.OK || len(total) == 0 || total[0] == 0 {
+		size -= b64
+	}
+
+	buf := buffer.NewNocopyBuffer()
+	writer := buf.Malloc(size + defaultSizeBytes)
+	writer.WriteUint32s(binary.BigEndian, uint32(size))
+	writer.WriteUint8s(dataBit)
+	writer.WriteUint8s(route.MulticastGroup)
+	writer.WriteUint64s(binary.BigEndian, seq)
+	writer.WriteUint16s(binary.BigEndian, code)
+
+	if code == codes.OK && len(total) > 0 && total[0] != 0 {
+		writer.WriteUint64s(binary.BigEndian, total[0])
+	}
+
+	return buf
+}
+
+// DecodeMulticastGroupRes 解码分组推送响应
+// 协议：size + header + route + seq + code + [total]
+func DecodeMulticastGroupRes(data []byte) (code uint16, total uint64, err error) {
+	if len(data) != multicastGroupResBytes && len(data) != multicastGroupResBytes-b64 {
+		err = errors.ErrInvalidMessage
+		return
+	}
+
+	reader := buffer.NewReader(data)
+
+	if _, err = reader.Seek(defaultSizeBytes+defaultHeaderBytes+defaultRouteBytes+defaultSeqBytes, io.SeekStart); err != nil {
+		return
+	}
+
+	if code, err = reader.ReadUint16(binary.BigEndian); err != nil {
+		return
+	}
+
+	if code == codes.OK && len(data) == multicastGroupResBytes {
+		total, err = reader.ReadUint64(binary.BigEndian)
+	}
+
+	return
+}
+
+// 编码分组成员请求
+func encodeGroupMemberReq(r uint8, seq uint64, kind session.Kind, target int64, group string) buffer.Buffer {
+	size := groupMemberReqBytes + len(group)
+	buf := buffer.NewNocopyBuffer()
+	writer := buf.Malloc(size)
+	writer.WriteUint32s(binary.BigEndian, uint32(size-defaultSizeBytes))
+	writer.WriteUint8s(dataBit)
+	writer.WriteUint8s(r)
+	writer.WriteUint64s(binary.BigEndian, seq)
+	writer.WriteUint8s(uint8(kind))
+	writer.WriteInt64s(binary.BigEndian, target)
+	writer.WriteString(group)
+
+	return buf
+}
+
+// 解码分组成员请求
+func decodeGroupMemberReq(data []byte) (seq uint64, kind session.Kind, target int64, group string, err error) {
+	if len(data) < groupMemberReqBytes {
+		err = errors.ErrInvalidMessage
+		return
+	}
+
+	reader := buffer.NewReader(data)
+
+	if _, err = reader.Seek(defaultSizeBytes+defaultHeaderBytes+defaultRouteBytes, io.SeekStart); err != nil {
+		return
+	}
+
+	if seq, err = reader.ReadUint64(binary.BigEndian); err != nil {
+		return
+	}
+
+	var k uint8
+	if k, err = reader.ReadUint8(); err != nil {
+		return
+	} else {
+		kind = session.Kind(k)
+	}
+
+	if target, err = reader.ReadInt64(binary.BigEndian); err != nil {
+		return
+	}
+
+	group = string(data[groupMemberReqBytes:])
+
+	return
+}
+
+// 编码分组成员响应
+func encodeGroupMemberRes(r uint8, seq uint64, code uint16) buffer.Buffer {
+	buf := buffer.NewNocopyBuffer()
+	writer := buf.Malloc(groupMemberResBytes)
+	writer.WriteUint32s(binary.BigEndian, uint32(groupMemberResBytes-defaultSizeBytes))
+	writer.WriteUint8s(dataBit)
+	writer.WriteUint8s(r)
+	writer.WriteUint64s(binary.BigEndian, seq)
+	writer.WriteUint16s(binary.BigEndian, code)
+
+	return buf
+}
+
+// 解码分组成员响应
+func decodeGroupMemberRes(data []byte) (code uint16, err error) {
+	if len(data) != groupMemberResBytes {
+		err = errors.ErrInvalidMessage
+		return
+	}
+
+	reader := buffer.NewReader(data)
+
+	if _, err = reader.Seek(-defaultCodeBytes, io.SeekEnd); err != nil {
+		return
+	}
+
+	code, err = reader.ReadUint16(binary.BigEndian)
+
+	return
+}
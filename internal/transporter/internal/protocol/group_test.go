@@ -0,0 +1,77 @@
+package protocol_test
+
+import (
+	"gatesvr/core/buffer"
+	"gatesvr/internal/transporter/internal/codes"
+	"gatesvr/internal/transporter/internal/protocol"
+	"gatesvr/packet"
+	"gatesvr/session"
+	"testing"
+)
+
+func TestJoinGroupReq(t *testing.T) {
+	buf := protocol.EncodeJoinGroupReq(1, session.User, 3, "room:1")
+
+	seq, kind, target, group, err := protocol.DecodeJoinGroupReq(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if seq != 1 || kind != session.User || target != 3 || group != "room:1" {
+		t.Fatalf("unexpected request: %d %v %d %q", seq, kind, target, group)
+	}
+}
+
+func TestLeaveGroupRes(t *testing.T) {
+	buf := protocol.EncodeLeaveGroupRes(1, codes.NotFoundSession)
+
+	code, err := protocol.DecodeLeaveGroupRes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code != codes.NotFoundSession {
+		t.Fatalf("code = %d, want %d", code, codes.NotFoundSession)
+	}
+}
+
+func TestMulticastGroupReq(t *testing.T) {
+	message, err := packet.PackMessage(&packet.Message{
+		Route:  1,
+		Seq:    2,
+		Buffer: []byte("hello world"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := protocol.EncodeMulticastGroupReq(1, "guild:7", buffer.NewNocopyBuffer(message))
+
+	seq, group, msg, err := protocol.DecodeMulticastGroupReq(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if seq != 1 || group != "guild:7" || string(msg) != string(message) {
+		t.Fatalf("unexpected request: %d %q %v", seq, group, msg)
+	}
+}
+
+func TestMulticastGroupRes(t *testing.T) {
+	buf := protocol.EncodeMulticastGroupRes(1, codes.OK, 20)
+
+	code, total, err := protocol.DecodeMulticastGroupRes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code != codes.OK || total != 20 {
+		t.Fatalf("unexpected response: %d %d", code, total)
+	}
+
+	buf = protocol.EncodeMulticastGroupRes(1, codes.OK)
+
+	if _, total, err = protocol.DecodeMulticastGroupRes(buf.Bytes()); err != nil || total != 0 {
+		t.Fatalf("unexpected response: %d %v", total, err)
+	}
+}
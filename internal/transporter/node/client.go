@@ -31,6 +31,30 @@ func (c *Client) Deliver(ctx context.Context, cid, uid int64, message []byte) er
 	return c.cli.Send(ctx, protocol.EncodeDeliverReq(0, cid, uid, message), cid)
 }
 
+// Tell 发送单向Actor消息
+func (c *Client) Tell(ctx context.Context, sender, target string, message []byte) error {
+	return c.cli.Send(ctx, protocol.EncodeActorReq(0, sender, target, message))
+}
+
+// Ask 发送Actor请求并等待回复
+func (c *Client) Ask(ctx context.Context, sender, target string, message []byte) ([]byte, error) {
+	seq := c.doGenSequence()
+
+	buf := protocol.EncodeActorReq(seq, sender, target, message)
+
+	res, err := c.cli.Call(ctx, seq, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	code, reply, err := protocol.DecodeActorRes(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return reply, codes.CodeToError(code)
+}
+
 // GetState 获取状态
 func (c *Client) GetState(ctx context.Context) (cluster.State, error) {
 	seq := c.doGenSequence()
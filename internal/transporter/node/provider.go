@@ -14,4 +14,6 @@ type Provider interface {
 	GetState() (cluster.State, error)
 	// SetState 设置状态
 	SetState(state cluster.State) error
+	// Receive 接收Actor消息，reply为nil时无需回复
+	Receive(ctx context.Context, nid, sender, target string, message []byte, reply func(data []byte, err error)) error
 }
@@ -32,6 +32,7 @@ func (s *Server) init() {
 	s.RegisterHandler(route.Deliver, s.deliver)
 	s.RegisterHandler(route.GetState, s.getState)
 	s.RegisterHandler(route.SetState, s.setState)
+	s.RegisterHandler(route.Actor, s.receive)
 }
 
 // 触发事件
@@ -105,3 +106,29 @@ func (s *Server) setState(conn *server.Conn, data []byte) error {
 
 	return conn.Send(protocol.EncodeSetStateRes(seq, codes.ErrorToCode(err)))
 }
+
+// 接收Actor消息
+func (s *Server) receive(conn *server.Conn, data []byte) error {
+	seq, sender, target, message, err := protocol.DecodeActorReq(data)
+	if err != nil {
+		return err
+	}
+
+	if conn.InsKind != cluster.Node {
+		return errors.ErrIllegalRequest
+	}
+
+	if seq == 0 {
+		return s.provider.Receive(context.Background(), conn.InsID, sender, target, message, nil)
+	}
+
+	reply := func(data []byte, err error) {
+		_ = conn.Send(protocol.EncodeActorRes(seq, codes.ErrorToCode(err), data))
+	}
+
+	if err = s.provider.Receive(context.Background(), conn.InsID, sender, target, message, reply); err != nil {
+		return conn.Send(protocol.EncodeActorRes(seq, codes.ErrorToCode(err)))
+	}
+
+	return nil
+}
@@ -55,6 +55,11 @@ func TestTimeout(t *testing.T) {
 	}
 }
 
+// Receive 接收Actor消息
+func (p *provider) Receive(ctx context.Context, nid, sender, target string, message []byte, reply func(data []byte, err error)) error {
+	return nil
+}
+
 // GetState 获取状态
 func (p *provider) GetState() (cluster.State, error) {
 	return cluster.Work, nil
@@ -0,0 +1,238 @@
+package keyed
+
+import (
+	"gatesvr/limite"
+	"gatesvr/limite/tokenbucket"
+	"gatesvr/log"
+	"gatesvr/utils/xcall"
+	"gatesvr/utils/xtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const shardCount = 32
+
+type bucketKey struct {
+	id    int64  // 连接ID或用户ID
+	ip    string // 远端IP
+	route int32  // 路由ID，为0时表示全局桶
+}
+
+type entry struct {
+	bucket     *tokenbucket.TokenBucketRateLimtImpl
+	lastAccess int64
+}
+
+type shard struct {
+	rw      sync.RWMutex
+	entries map[bucketKey]*entry
+	index   map[int64]map[bucketKey]struct{} // 按连接ID或用户ID索引的令牌桶，IP维度不建立索引
+}
+
+// 添加令牌桶，调用方需持有写锁
+func (s *shard) add(k bucketKey, e *entry) {
+	s.entries[k] = e
+
+	if k.ip != "" {
+		return
+	}
+
+	keys, ok := s.index[k.id]
+	if !ok {
+		keys = make(map[bucketKey]struct{})
+		s.index[k.id] = keys
+	}
+
+	keys[k] = struct{}{}
+}
+
+// 移除令牌桶，调用方需持有写锁
+func (s *shard) remove(k bucketKey) {
+	delete(s.entries, k)
+
+	if keys, ok := s.index[k.id]; ok {
+		delete(keys, k)
+
+		if len(keys) == 0 {
+			delete(s.index, k.id)
+		}
+	}
+}
+
+type Limiter struct {
+	opts    *options
+	shards  []*shard
+	closeCh chan struct{}
+	once    sync.Once
+}
+
+var (
+	_ limite.Limiter = &Limiter{}
+	_ limite.Evictor = &Limiter{}
+)
+
+func NewLimiter(opts ...Option) *Limiter {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.capacity <= 0 || o.rate <= 0 {
+		log.Fatalf("the capacity and rate of keyed limiter must be greater than 0, and give %d、%d", o.capacity, o.rate)
+	}
+
+	for route, quota := range o.routes {
+		if quota.Capacity <= 0 || quota.Rate <= 0 {
+			log.Fatalf("the capacity and rate of route %d quota must be greater than 0, and give %d、%d", route, quota.Capacity, quota.Rate)
+		}
+	}
+
+	switch o.dimension {
+	case CID, UID, IP:
+	default:
+		log.Fatalf("invalid keyed limiter dimension: %s", o.dimension)
+	}
+
+	l := &Limiter{opts: o, closeCh: make(chan struct{})}
+	l.shards = make([]*shard, shardCount)
+	for i := range l.shards {
+		l.shards[i] = &shard{entries: make(map[bucketKey]*entry), index: make(map[int64]map[bucketKey]struct{})}
+	}
+
+	if o.idleTimeout > 0 {
+		xcall.Go(l.sweep)
+	}
+
+	return l
+}
+
+// GetToken 获取令牌
+// 同时获取维度的全局令牌及维度在路由上的配额令牌，任一令牌不足时均不消耗
+func (l *Limiter) GetToken(key limite.Key) bool {
+	now := xtime.Now().UnixNano()
+
+	k := l.makeKey(key)
+
+	bucket := l.load(k, now, l.opts.capacity, l.opts.rate)
+
+	// 路由0为全局令牌桶的键，不参与路由配额
+	quota, ok := l.opts.routes[key.Route]
+	if !ok || key.Route == 0 {
+		return bucket.GetToken()
+	}
+
+	k.route = key.Route
+
+	// 固定先全局后路由的加锁顺序
+	return tokenbucket.GetTokens(bucket, l.load(k, now, quota.Capacity, quota.Rate))
+}
+
+// Evict 回收连接对应的所有令牌桶
+// 仅回收以连接ID为键的令牌桶；IP及用户维度的令牌桶由多个连接共享，且重连后应沿用原有的令牌余量，由闲置清理回收
+// 用户维度下连接绑定前的流量以连接ID为键，绑定后断开时同样回收
+func (l *Limiter) Evict(key limite.Key) {
+	if l.opts.dimension == IP {
+		return
+	}
+
+	k := l.makeKey(limite.Key{CID: key.CID})
+	s := l.shard(k)
+
+	s.rw.Lock()
+	defer s.rw.Unlock()
+
+	for bk := range s.index[k.id] {
+		s.remove(bk)
+	}
+}
+
+// Close 关闭限流器，停止清理闲置的令牌桶
+func (l *Limiter) Close() {
+	l.once.Do(func() { close(l.closeCh) })
+}
+
+// Len 令牌桶数量
+func (l *Limiter) Len() int {
+	n := 0
+	for _, s := range l.shards {
+		s.rw.RLock()
+		n += len(s.entries)
+		s.rw.RUnlock()
+	}
+
+	return n
+}
+
+// 生成令牌桶键
+func (l *Limiter) makeKey(key limite.Key) bucketKey {
+	switch l.opts.dimension {
+	case IP:
+		return bucketKey{ip: key.IP}
+	case UID:
+		if key.UID != 0 {
+			return bucketKey{id: key.UID}
+		}
+		// 未绑定用户的连接以负数连接ID区分，避免与用户ID冲突
+		return bucketKey{id: -key.CID}
+	default:
+		return bucketKey{id: key.CID}
+	}
+}
+
+// 获取令牌桶所在分片
+func (l *Limiter) shard(k bucketKey) *shard {
+	h := uint64(k.id)
+	for i := 0; i < len(k.ip); i++ {
+		h = h*31 + uint64(k.ip[i])
+	}
+
+	return l.shards[h%shardCount]
+}
+
+// 加载令牌桶，不存在时创建
+func (l *Limiter) load(k bucketKey, now, capacity, rate int64) *tokenbucket.TokenBucketRateLimtImpl {
+	s := l.shard(k)
+
+	s.rw.RLock()
+	e, ok := s.entries[k]
+	s.rw.RUnlock()
+
+	if !ok {
+		s.rw.Lock()
+		if e, ok = s.entries[k]; !ok {
+			e = &entry{bucket: tokenbucket.NewTokenBucketRateLimtImpl(capacity, rate)}
+			s.add(k, e)
+		}
+		s.rw.Unlock()
+	}
+
+	atomic.StoreInt64(&e.lastAccess, now)
+
+	return e.bucket
+}
+
+// 定时清理闲置的令牌桶
+func (l *Limiter) sweep() {
+	ticker := time.NewTicker(l.opts.idleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.closeCh:
+			return
+		case <-ticker.C:
+			deadline := xtime.Now().UnixNano() - int64(l.opts.idleTimeout)
+
+			for _, s := range l.shards {
+				s.rw.Lock()
+				for k, e := range s.entries {
+					if atomic.LoadInt64(&e.lastAccess) < deadline {
+						s.remove(k)
+					}
+				}
+				s.rw.Unlock()
+			}
+		}
+	}
+}
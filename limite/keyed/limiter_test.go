@@ -0,0 +1,151 @@
+package keyed_test
+
+import (
+	"gatesvr/limite"
+	"gatesvr/limite/keyed"
+	"testing"
+	"time"
+)
+
+func TestLimiter_IsolateKeys(t *testing.T) {
+	limiter := keyed.NewLimiter(keyed.WithDimension(keyed.UID), keyed.WithCapacity(3), keyed.WithRate(1000))
+
+	noisy := limite.Key{CID: 1, UID: 100}
+	quiet := limite.Key{CID: 2, UID: 200}
+
+	for i := 0; i < 3; i++ {
+		if !limiter.GetToken(noisy) {
+			t.Fatalf("expected token to be available (attempt %d)", i)
+		}
+	}
+
+	if limiter.GetToken(noisy) {
+		t.Fatal("expected noisy user to be throttled")
+	}
+
+	if !limiter.GetToken(quiet) {
+		t.Fatal("expected quiet user not to be affected by noisy user")
+	}
+}
+
+func TestLimiter_UnboundConnection(t *testing.T) {
+	limiter := keyed.NewLimiter(keyed.WithDimension(keyed.UID), keyed.WithCapacity(1), keyed.WithRate(1000))
+
+	if !limiter.GetToken(limite.Key{CID: 1}) {
+		t.Fatal("expected token to be available")
+	}
+
+	if !limiter.GetToken(limite.Key{CID: 2}) {
+		t.Fatal("expected unbound connections to use separate buckets")
+	}
+
+	if limiter.GetToken(limite.Key{CID: 1}) {
+		t.Fatal("expected connection 1 to be throttled")
+	}
+}
+
+func TestLimiter_RouteQuota(t *testing.T) {
+	limiter := keyed.NewLimiter(
+		keyed.WithDimension(keyed.IP),
+		keyed.WithCapacity(100),
+		keyed.WithRate(1000),
+		keyed.WithRouteQuota(10, 1, 1000),
+	)
+
+	key := limite.Key{CID: 1, IP: "10.0.0.1", Route: 10}
+
+	if !limiter.GetToken(key) {
+		t.Fatal("expected route token to be available")
+	}
+
+	if limiter.GetToken(key) {
+		t.Fatal("expected route quota to be exhausted")
+	}
+
+	key.Route = 11
+	if !limiter.GetToken(key) {
+		t.Fatal("expected other route not to be affected")
+	}
+}
+
+func TestLimiter_RouteDenialKeepsGlobalToken(t *testing.T) {
+	limiter := keyed.NewLimiter(
+		keyed.WithDimension(keyed.CID),
+		keyed.WithCapacity(2),
+		keyed.WithRate(1000),
+		keyed.WithRouteQuota(10, 1, 1000),
+	)
+
+	key := limite.Key{CID: 1, Route: 10}
+
+	if !limiter.GetToken(key) {
+		t.Fatal("expected route token to be available")
+	}
+
+	if limiter.GetToken(key) {
+		t.Fatal("expected route quota to be exhausted")
+	}
+
+	key.Route = 11
+	if !limiter.GetToken(key) {
+		t.Fatal("expected denied route request not to consume global token")
+	}
+
+	if limiter.GetToken(key) {
+		t.Fatal("expected global tokens to be exhausted")
+	}
+}
+
+func TestLimiter_EvictIdle(t *testing.T) {
+	limiter := keyed.NewLimiter(keyed.WithCapacity(1), keyed.WithRate(1000), keyed.WithIdleTimeout(50*time.Millisecond))
+	defer limiter.Close()
+
+	limiter.GetToken(limite.Key{UID: 1})
+	limiter.GetToken(limite.Key{UID: 2})
+
+	if n := limiter.Len(); n != 2 {
+		t.Fatalf("expected 2 buckets, got %d", n)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	limiter.GetToken(limite.Key{UID: 3})
+
+	if n := limiter.Len(); n != 1 {
+		t.Fatalf("expected idle buckets to be evicted, got %d", n)
+	}
+
+	// 用户维度的令牌桶不随连接断开回收，由闲置清理回收
+	limiter.Evict(limite.Key{CID: 1, UID: 3})
+
+	if n := limiter.Len(); n != 1 {
+		t.Fatalf("expected uid bucket to be kept, got %d", n)
+	}
+
+	limiter.GetToken(limite.Key{CID: 2})
+
+	limiter.Evict(limite.Key{CID: 2})
+
+	if n := limiter.Len(); n != 1 {
+		t.Fatalf("expected unbound connection bucket to be evicted, got %d", n)
+	}
+}
+
+func TestLimiter_EvictAfterBind(t *testing.T) {
+	limiter := keyed.NewLimiter(keyed.WithCapacity(1), keyed.WithRate(1000))
+	defer limiter.Close()
+
+	// 绑定前的流量以连接ID为键，绑定后以用户ID为键
+	limiter.GetToken(limite.Key{CID: 1})
+	limiter.GetToken(limite.Key{CID: 1, UID: 100})
+
+	if n := limiter.Len(); n != 2 {
+		t.Fatalf("expected 2 buckets, got %d", n)
+	}
+
+	limiter.Evict(limite.Key{CID: 1, UID: 100})
+
+	if n := limiter.Len(); n != 1 {
+		t.Fatalf("expected only the uid bucket to be kept, got %d", n)
+	}
+}
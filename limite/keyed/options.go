@@ -0,0 +1,90 @@
+package keyed
+
+import (
+	"gatesvr/etc"
+	"time"
+)
+
+const (
+	defaultDimension   = UID
+	defaultCapacity    = 20
+	defaultRate        = 50
+	defaultIdleTimeout = "5m"
+)
+
+const (
+	defaultDimensionKey   = "etc.limite.keyed.dimension"
+	defaultCapacityKey    = "etc.limite.keyed.capacity"
+	defaultRateKey        = "etc.limite.keyed.rate"
+	defaultIdleTimeoutKey = "etc.limite.keyed.idleTimeout"
+	defaultRoutesKey      = "etc.limite.keyed.routes"
+)
+
+const (
+	CID Dimension = "cid" // 按连接限流
+	UID Dimension = "uid" // 按用户限流，未绑定用户的连接按连接限流
+	IP  Dimension = "ip"  // 按远端IP限流
+)
+
+type Dimension string
+
+// Quota 路由配额
+type Quota struct {
+	Route    int32 `json:"route"`    // 路由ID
+	Capacity int64 `json:"capacity"` // 令牌桶容量
+	Rate     int64 `json:"rate"`     // 令牌生成间隔（毫秒）
+}
+
+type Option func(o *options)
+
+type options struct {
+	dimension   Dimension       // 限流维度，默认uid
+	capacity    int64           // 令牌桶容量，默认20
+	rate        int64           // 令牌生成间隔（毫秒），默认50
+	idleTimeout time.Duration   // 令牌桶闲置回收时间，默认5m
+	routes      map[int32]Quota // 路由配额
+}
+
+func defaultOptions() *options {
+	opts := &options{
+		dimension:   Dimension(etc.Get(defaultDimensionKey, defaultDimension).String()),
+		capacity:    etc.Get(defaultCapacityKey, defaultCapacity).Int64(),
+		rate:        etc.Get(defaultRateKey, defaultRate).Int64(),
+		idleTimeout: etc.Get(defaultIdleTimeoutKey, defaultIdleTimeout).Duration(),
+		routes:      make(map[int32]Quota),
+	}
+
+	var quotas []Quota
+	if err := etc.Get(defaultRoutesKey).Scan(&quotas); err == nil {
+		for _, quota := range quotas {
+			opts.routes[quota.Route] = quota
+		}
+	}
+
+	return opts
+}
+
+// WithDimension 设置限流维度
+func WithDimension(dimension Dimension) Option {
+	return func(o *options) { o.dimension = dimension }
+}
+
+// WithCapacity 设置令牌桶容量
+func WithCapacity(capacity int64) Option {
+	return func(o *options) { o.capacity = capacity }
+}
+
+// WithRate 设置令牌生成间隔（毫秒）
+func WithRate(rate int64) Option {
+	return func(o *options) { o.rate = rate }
+}
+
+// WithIdleTimeout 设置令牌桶闲置回收时间
+func WithIdleTimeout(idleTimeout time.Duration) Option {
+	return func(o *options) { o.idleTimeout = idleTimeout }
+}
+
+// WithRouteQuota 设置路由配额
+func WithRouteQuota(route int32, capacity, rate int64) Option {
+	return func(o *options) { o.routes[route] = Quota{Route: route, Capacity: capacity, Rate: rate} }
+}
@@ -1,5 +1,39 @@
 package limite
 
+// Key 限流键
+type Key struct {
+	CID   int64  // 连接ID
+	UID   int64  // 用户ID
+	IP    string // 远端IP
+	Route int32  // 路由ID
+}
+
 type Limiter interface {
+	// GetToken 获取令牌
+	GetToken(key Key) bool
+}
+
+// Evictor 可回收限流键对应令牌桶的限流器，网关在连接断开时回收
+type Evictor interface {
+	// Evict 回收键对应的令牌桶
+	Evict(key Key)
+}
+
+// RateLimit 不区分限流键的限流器，例如tokenbucket.TokenBucketRateLimtImpl
+type RateLimit interface {
 	GetToken() bool
 }
+
+// Global 将不区分限流键的限流器适配为Limiter，所有连接共享同一份令牌
+func Global(limit RateLimit) Limiter {
+	return &global{limit: limit}
+}
+
+type global struct {
+	limit RateLimit
+}
+
+// GetToken 获取令牌
+func (g *global) GetToken(_ Key) bool {
+	return g.limit.GetToken()
+}
@@ -63,7 +63,42 @@ func (t *TokenBucketRateLimtImpl) GetToken() bool {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	t.refill(time.Now().UnixMilli())
+
+	if t.curCapacity > 0 {
+		t.curCapacity--
+		return true
+	}
+	return false
+}
+
+// GetTokens 从多个令牌桶中各获取一个令牌，任一令牌桶令牌不足时均不消耗
+// 令牌桶按传入顺序加锁，调用方需保证并发调用时的传入顺序一致
+func GetTokens(buckets ...*TokenBucketRateLimtImpl) bool {
 	now := time.Now().UnixMilli()
+
+	for _, t := range buckets {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+	}
+
+	for _, t := range buckets {
+		t.refill(now)
+
+		if t.curCapacity <= 0 {
+			return false
+		}
+	}
+
+	for _, t := range buckets {
+		t.curCapacity--
+	}
+
+	return true
+}
+
+// 补充令牌，调用方需持有锁
+func (t *TokenBucketRateLimtImpl) refill(now int64) {
 	elapsed := now - t.timestamp
 
 	// 补充令牌
@@ -77,10 +112,4 @@ func (t *TokenBucketRateLimtImpl) GetToken() bool {
 			t.timestamp = now
 		}
 	}
-
-	if t.curCapacity > 0 {
-		t.curCapacity--
-		return true
-	}
-	return false
 }
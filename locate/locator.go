@@ -23,6 +23,16 @@ type Locator interface {
 	LocateNode(ctx context.Context, uid int64, name string) (string, error)
 }
 
+// ActorLocator Actor定位器，定位器实现该接口后节点可通过Actor类型及编号定位Actor所在节点
+type ActorLocator interface {
+	// BindActor 绑定Actor所在节点
+	BindActor(ctx context.Context, kind, id, nid string) error
+	// UnbindActor 解绑Actor所在节点
+	UnbindActor(ctx context.Context, kind, id, nid string) error
+	// LocateActor 定位Actor所在节点
+	LocateActor(ctx context.Context, kind, id string) (string, error)
+}
+
 type Watcher interface {
 	// Next 返回用户位置列表
 	Next() ([]*Event, error)
@@ -0,0 +1,272 @@
+package memory
+
+import (
+	"context"
+	"gatesvr/cluster"
+	"gatesvr/locate"
+	"sync"
+)
+
+const name = "memory"
+
+var (
+	_ locate.Locator      = &Locator{}
+	_ locate.ActorLocator = &Locator{}
+)
+
+// Locator 内存定位器，用户位置保存在进程内，适用于单进程部署及测试
+type Locator struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	opts     *options
+	rw       sync.RWMutex
+	idx      int64
+	gates    map[int64]string             // 用户所在网关（用户ID -> 网关ID）
+	nodes    map[int64]map[string]string  // 用户所在节点（用户ID -> 节点名称 -> 节点ID）
+	actors   map[string]map[string]string // Actor所在节点（Actor类型 -> Actor编号 -> 节点ID）
+	watchers map[int64]*watcher           // 监听器
+}
+
+func NewLocator(opts ...Option) *Locator {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	l := &Locator{}
+	l.opts = o
+	l.ctx, l.cancel = context.WithCancel(o.ctx)
+	l.gates = make(map[int64]string)
+	l.nodes = make(map[int64]map[string]string)
+	l.actors = make(map[string]map[string]string)
+	l.watchers = make(map[int64]*watcher)
+
+	return l
+}
+
+// Name 获取定位器组件名
+func (l *Locator) Name() string {
+	return name
+}
+
+// LocateGate 定位用户所在网关
+func (l *Locator) LocateGate(ctx context.Context, uid int64) (string, error) {
+	if err := l.check(ctx); err != nil {
+		return "", err
+	}
+
+	l.rw.RLock()
+	defer l.rw.RUnlock()
+
+	return l.gates[uid], nil
+}
+
+// LocateNode 定位用户所在节点
+func (l *Locator) LocateNode(ctx context.Context, uid int64, name string) (string, error) {
+	if err := l.check(ctx); err != nil {
+		return "", err
+	}
+
+	l.rw.RLock()
+	defer l.rw.RUnlock()
+
+	return l.nodes[uid][name], nil
+}
+
+// BindGate 绑定网关
+func (l *Locator) BindGate(ctx context.Context, uid int64, gid string) error {
+	if err := l.check(ctx); err != nil {
+		return err
+	}
+
+	l.rw.Lock()
+	defer l.rw.Unlock()
+
+	l.gates[uid] = gid
+
+	l.broadcast(locate.BindGate, uid, gid)
+
+	return nil
+}
+
+// BindNode 绑定节点
+func (l *Locator) BindNode(ctx context.Context, uid int64, name, nid string) error {
+	if err := l.check(ctx); err != nil {
+		return err
+	}
+
+	l.rw.Lock()
+	defer l.rw.Unlock()
+
+	nodes, ok := l.nodes[uid]
+	if !ok {
+		nodes = make(map[string]string)
+		l.nodes[uid] = nodes
+	}
+
+	nodes[name] = nid
+
+	l.broadcast(locate.BindNode, uid, nid, name)
+
+	return nil
+}
+
+// UnbindGate 解绑网关
+func (l *Locator) UnbindGate(ctx context.Context, uid int64, gid string) error {
+	if err := l.check(ctx); err != nil {
+		return err
+	}
+
+	l.rw.Lock()
+	defer l.rw.Unlock()
+
+	if l.gates[uid] != gid {
+		return nil
+	}
+
+	delete(l.gates, uid)
+
+	l.broadcast(locate.UnbindGate, uid, gid)
+
+	return nil
+}
+
+// UnbindNode 解绑节点
+func (l *Locator) UnbindNode(ctx context.Context, uid int64, name, nid string) error {
+	if err := l.check(ctx); err != nil {
+		return err
+	}
+
+	l.rw.Lock()
+	defer l.rw.Unlock()
+
+	nodes, ok := l.nodes[uid]
+	if !ok || nodes[name] != nid {
+		return nil
+	}
+
+	delete(nodes, name)
+
+	if len(nodes) == 0 {
+		delete(l.nodes, uid)
+	}
+
+	l.broadcast(locate.UnbindNode, uid, nid, name)
+
+	return nil
+}
+
+// LocateActor 定位Actor所在节点
+func (l *Locator) LocateActor(ctx context.Context, kind, id string) (string, error) {
+	if err := l.check(ctx); err != nil {
+		return "", err
+	}
+
+	l.rw.RLock()
+	defer l.rw.RUnlock()
+
+	return l.actors[kind][id], nil
+}
+
+// BindActor 绑定Actor所在节点
+func (l *Locator) BindActor(ctx context.Context, kind, id, nid string) error {
+	if err := l.check(ctx); err != nil {
+		return err
+	}
+
+	l.rw.Lock()
+	defer l.rw.Unlock()
+
+	actors, ok := l.actors[kind]
+	if !ok {
+		actors = make(map[string]string)
+		l.actors[kind] = actors
+	}
+
+	actors[id] = nid
+
+	return nil
+}
+
+// UnbindActor 解绑Actor所在节点
+func (l *Locator) UnbindActor(ctx context.Context, kind, id, nid string) error {
+	if err := l.check(ctx); err != nil {
+		return err
+	}
+
+	l.rw.Lock()
+	defer l.rw.Unlock()
+
+	actors, ok := l.actors[kind]
+	if !ok || actors[id] != nid {
+		return nil
+	}
+
+	delete(actors, id)
+
+	if len(actors) == 0 {
+		delete(l.actors, kind)
+	}
+
+	return nil
+}
+
+// Watch 监听用户定位变化
+func (l *Locator) Watch(ctx context.Context, kinds ...string) (locate.Watcher, error) {
+	if err := l.check(ctx); err != nil {
+		return nil, err
+	}
+
+	l.rw.Lock()
+	defer l.rw.Unlock()
+
+	l.idx++
+
+	w := newWatcher(l, l.idx, kinds...)
+	l.watchers[w.idx] = w
+
+	return w, nil
+}
+
+// Close 关闭定位器
+func (l *Locator) Close() error {
+	l.cancel()
+
+	return nil
+}
+
+// 检测上下文状态
+func (l *Locator) check(ctx context.Context) error {
+	if err := l.ctx.Err(); err != nil {
+		return err
+	}
+
+	return ctx.Err()
+}
+
+// 回收监听器
+func (l *Locator) recycle(idx int64) {
+	l.rw.Lock()
+	delete(l.watchers, idx)
+	l.rw.Unlock()
+}
+
+// 广播事件，调用方需持有写锁
+func (l *Locator) broadcast(typ locate.EventType, uid int64, insID string, insName ...string) {
+	evt := &locate.Event{UID: uid, Type: typ, InsID: insID}
+
+	switch typ {
+	case locate.BindGate, locate.UnbindGate:
+		evt.InsKind = cluster.Gate.String()
+	case locate.BindNode, locate.UnbindNode:
+		evt.InsKind = cluster.Node.String()
+	}
+
+	if len(insName) > 0 {
+		evt.InsName = insName[0]
+	}
+
+	for _, w := range l.watchers {
+		w.notify(evt)
+	}
+}
@@ -0,0 +1,133 @@
+package memory_test
+
+import (
+	"context"
+	"gatesvr/cluster"
+	"gatesvr/locate"
+	"gatesvr/locate/memory"
+	"testing"
+	"time"
+)
+
+func TestLocator_Bind(t *testing.T) {
+	ctx := context.Background()
+	locator := memory.NewLocator()
+
+	if err := locator.BindGate(ctx, 1, "gate-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := locator.BindNode(ctx, 1, "game", "node-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if gid, _ := locator.LocateGate(ctx, 1); gid != "gate-1" {
+		t.Fatalf("gate = %q, want gate-1", gid)
+	}
+
+	if nid, _ := locator.LocateNode(ctx, 1, "game"); nid != "node-1" {
+		t.Fatalf("node = %q, want node-1", nid)
+	}
+
+	// 解绑其他实例不影响当前绑定
+	if err := locator.UnbindGate(ctx, 1, "gate-2"); err != nil {
+		t.Fatal(err)
+	}
+
+	if gid, _ := locator.LocateGate(ctx, 1); gid != "gate-1" {
+		t.Fatalf("gate = %q, want gate-1", gid)
+	}
+
+	if err := locator.UnbindGate(ctx, 1, "gate-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := locator.UnbindNode(ctx, 1, "game", "node-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if gid, _ := locator.LocateGate(ctx, 1); gid != "" {
+		t.Fatalf("gate = %q, want empty", gid)
+	}
+
+	if nid, _ := locator.LocateNode(ctx, 1, "game"); nid != "" {
+		t.Fatalf("node = %q, want empty", nid)
+	}
+}
+
+func TestLocator_BindActor(t *testing.T) {
+	ctx := context.Background()
+	locator := memory.NewLocator()
+
+	if err := locator.BindActor(ctx, "guild", "1", "node-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if nid, _ := locator.LocateActor(ctx, "guild", "1"); nid != "node-1" {
+		t.Fatalf("node = %q, want node-1", nid)
+	}
+
+	// 解绑其他节点不影响当前绑定
+	if err := locator.UnbindActor(ctx, "guild", "1", "node-2"); err != nil {
+		t.Fatal(err)
+	}
+
+	if nid, _ := locator.LocateActor(ctx, "guild", "1"); nid != "node-1" {
+		t.Fatalf("node = %q, want node-1", nid)
+	}
+
+	if err := locator.UnbindActor(ctx, "guild", "1", "node-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if nid, _ := locator.LocateActor(ctx, "guild", "1"); nid != "" {
+		t.Fatalf("node = %q, want empty", nid)
+	}
+}
+
+func TestLocator_Watch(t *testing.T) {
+	ctx := context.Background()
+	locator := memory.NewLocator()
+
+	watcher, err := locator.Watch(ctx, cluster.Node.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Stop()
+
+	_ = locator.BindGate(ctx, 1, "gate-1")
+	_ = locator.BindNode(ctx, 1, "game", "node-1")
+	_ = locator.UnbindNode(ctx, 1, "game", "node-1")
+
+	var events []*locate.Event
+	for len(events) < 2 {
+		ch := make(chan []*locate.Event, 1)
+
+		go func() {
+			evts, err := watcher.Next()
+			if err != nil {
+				t.Error(err)
+			}
+			ch <- evts
+		}()
+
+		select {
+		case evts := <-ch:
+			events = append(events, evts...)
+		case <-time.After(time.Second):
+			t.Fatal("watch timeout")
+		}
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("events = %d, want 2", len(events))
+	}
+
+	if events[0].Type != locate.BindNode || events[0].InsID != "node-1" || events[0].InsName != "game" {
+		t.Fatalf("unexpected event: %+v", events[0])
+	}
+
+	if events[1].Type != locate.UnbindNode {
+		t.Fatalf("unexpected event: %+v", events[1])
+	}
+}
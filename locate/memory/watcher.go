@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"gatesvr/locate"
+	"sync"
+)
+
+type watcher struct {
+	idx      int64
+	locator  *Locator
+	kinds    map[string]struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+	mu       sync.Mutex
+	events   []*locate.Event
+	chNotify chan struct{}
+}
+
+func newWatcher(l *Locator, idx int64, kinds ...string) *watcher {
+	w := &watcher{}
+	w.ctx, w.cancel = context.WithCancel(l.ctx)
+	w.idx = idx
+	w.locator = l
+	w.kinds = make(map[string]struct{}, len(kinds))
+	w.chNotify = make(chan struct{}, 1)
+
+	for _, kind := range kinds {
+		w.kinds[kind] = struct{}{}
+	}
+
+	return w
+}
+
+// 通知事件，事件在调用Next前暂存，不会阻塞定位器
+func (w *watcher) notify(evt *locate.Event) {
+	if _, ok := w.kinds[evt.InsKind]; !ok {
+		return
+	}
+
+	w.mu.Lock()
+	w.events = append(w.events, evt)
+	w.mu.Unlock()
+
+	select {
+	case w.chNotify <- struct{}{}:
+	default:
+	}
+}
+
+// Next 返回变动事件列表
+func (w *watcher) Next() ([]*locate.Event, error) {
+	for {
+		select {
+		case <-w.ctx.Done():
+			return nil, w.ctx.Err()
+		case <-w.chNotify:
+			w.mu.Lock()
+			events := w.events
+			w.events = nil
+			w.mu.Unlock()
+
+			// 事件可能已被上一次调用取走
+			if len(events) > 0 {
+				return events, nil
+			}
+		}
+	}
+}
+
+// Stop 停止监听
+func (w *watcher) Stop() error {
+	w.cancel()
+	w.locator.recycle(w.idx)
+
+	return nil
+}
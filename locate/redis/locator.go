@@ -19,12 +19,16 @@ import (
 const (
 	userGateKey     = "%s:locate:user:%d:gate"      // string
 	userNodeKey     = "%s:locate:user:%d:nodestart" // hash
+	actorNodeKey    = "%s:locate:actor:%s:node"     // hash
 	clusterEventKey = "%s:locate:cluster:%s:event"  // channel
 )
 
 const name = "redis"
 
-var _ locate.Locator = &Locator{}
+var (
+	_ locate.Locator      = &Locator{}
+	_ locate.ActorLocator = &Locator{}
+)
 
 type Locator struct {
 	opts             *Options
@@ -175,6 +179,39 @@ func (l *Locator) UnbindNode(ctx context.Context, uid int64, name, nid string) e
 	return nil
 }
 
+// LocateActor 定位Actor所在节点
+func (l *Locator) LocateActor(ctx context.Context, kind, id string) (string, error) {
+	key := fmt.Sprintf(actorNodeKey, l.opts.Prefix, kind)
+
+	val, err, _ := l.sfg.Do(key+id, func() (interface{}, error) {
+		val, err := l.opts.client.HGet(ctx, key, id).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return "", err
+		}
+
+		return val, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return val.(string), nil
+}
+
+// BindActor 绑定Actor所在节点
+func (l *Locator) BindActor(ctx context.Context, kind, id, nid string) error {
+	key := fmt.Sprintf(actorNodeKey, l.opts.Prefix, kind)
+
+	return l.opts.client.HSet(ctx, key, id, nid).Err()
+}
+
+// UnbindActor 解绑Actor所在节点
+func (l *Locator) UnbindActor(ctx context.Context, kind, id, nid string) error {
+	key := fmt.Sprintf(actorNodeKey, l.opts.Prefix, kind)
+
+	return l.unbindNodeScript.Run(ctx, l.opts.client, []string{key}, id, nid).Err()
+}
+
 // 广播事件
 func (l *Locator) broadcast(ctx context.Context, typ locate.EventType, uid int64, insID string, insName ...string) error {
 	evt := &locate.Event{UID: uid, Type: typ, InsID: insID}
@@ -0,0 +1,26 @@
+package log
+
+import (
+	"testing"
+)
+
+func TestFormatter_ReuseBuffer(t *testing.T) {
+	formatters := map[string]interface {
+		format(e *Entity, isTerminal bool) []byte
+	}{
+		"text": newTextFormatter(),
+		"json": newJsonFormatter(),
+	}
+
+	for name, f := range formatters {
+		first := f.format(&Entity{Level: InfoLevel, Time: "2024/01/01 00:00:00.000000", Message: "first"}, false)
+		expect := string(first)
+
+		// 格式化结果在缓冲区归还后仍被异步写出，不应被后续格式化覆盖
+		f.format(&Entity{Level: InfoLevel, Time: "2024/01/01 00:00:00.000000", Message: "second"}, false)
+
+		if string(first) != expect {
+			t.Fatalf("%s formatter output is overwritten: %q", name, first)
+		}
+	}
+}
@@ -68,5 +68,5 @@ func (f *jsonFormatter) format(e *Entity, isTerminal bool) []byte {
 
 	b.WriteString("}\n")
 
-	return b.Bytes()
+	return bytes.Clone(b.Bytes())
 }
@@ -55,5 +55,5 @@ func (f *textFormatter) format(e *Entity, isTerminal bool) []byte {
 
 	b.WriteByte('\n')
 
-	return b.Bytes()
+	return bytes.Clone(b.Bytes())
 }
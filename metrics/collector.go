@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"sync/atomic"
+)
+
+type binding[T any] struct {
+	metrics   Metrics
+	collector T
+}
+
+// 延迟绑定的指标，首次使用或指标后端变更时从当前后端获取
+type lazy[T any] struct {
+	opts  Opts
+	bound atomic.Pointer[binding[T]]
+	get   func(m Metrics, opts Opts) T
+}
+
+func (l *lazy[T]) collector() T {
+	m := GetMetrics()
+
+	if b := l.bound.Load(); b != nil && b.metrics == m {
+		return b.collector
+	}
+
+	c := l.get(m, l.opts)
+	l.bound.Store(&binding[T]{metrics: m, collector: c})
+
+	return c
+}
+
+type counter struct{ lazy[Counter] }
+
+// NewCounter 新建计数器，指标数据写入当前指标后端
+func NewCounter(opts Opts) Counter {
+	return &counter{lazy[Counter]{opts: opts, get: Metrics.Counter}}
+}
+
+func (c *counter) Add(delta float64, labelValues ...string) {
+	c.collector().Add(delta, labelValues...)
+}
+
+type gauge struct{ lazy[Gauge] }
+
+// NewGauge 新建仪表盘，指标数据写入当前指标后端
+func NewGauge(opts Opts) Gauge {
+	return &gauge{lazy[Gauge]{opts: opts, get: Metrics.Gauge}}
+}
+
+func (g *gauge) Set(value float64, labelValues ...string) {
+	g.collector().Set(value, labelValues...)
+}
+
+func (g *gauge) Add(delta float64, labelValues ...string) {
+	g.collector().Add(delta, labelValues...)
+}
+
+type histogram struct{ lazy[Histogram] }
+
+// NewHistogram 新建直方图，指标数据写入当前指标后端
+func NewHistogram(opts Opts) Histogram {
+	return &histogram{lazy[Histogram]{opts: opts, get: Metrics.Histogram}}
+}
+
+func (h *histogram) Observe(value float64, labelValues ...string) {
+	h.collector().Observe(value, labelValues...)
+}
@@ -0,0 +1,70 @@
+package metrics
+
+// 框架内置指标
+var (
+	// NetworkConnections 当前连接数
+	NetworkConnections = NewGauge(Opts{
+		Name:   "gatesvr_network_connections",
+		Help:   "Number of client connections currently held by the server.",
+		Labels: []string{"protocol"},
+	})
+
+	// NetworkRejections 拒绝建立的连接数
+	NetworkRejections = NewCounter(Opts{
+		Name:   "gatesvr_network_rejections_total",
+		Help:   "Number of client connections rejected on accept.",
+		Labels: []string{"protocol", "reason"},
+	})
+
+	// LimiterDenials 被限流的消息数
+	LimiterDenials = NewCounter(Opts{
+		Name:   "gatesvr_limiter_denials_total",
+		Help:   "Number of client messages denied by the limiter.",
+		Labels: []string{"route"},
+	})
+
+	// DeliverDuration 投递消息到节点的耗时
+	DeliverDuration = NewHistogram(Opts{
+		Name:    "gatesvr_deliver_duration_seconds",
+		Help:    "Time taken to deliver a client message to a node.",
+		Labels:  []string{"route"},
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	})
+
+	// DeliverErrors 投递消息到节点失败数
+	DeliverErrors = NewCounter(Opts{
+		Name:   "gatesvr_deliver_errors_total",
+		Help:   "Number of client messages failed to deliver to a node.",
+		Labels: []string{"route"},
+	})
+
+	// TransporterDuration 内部传输调用耗时
+	TransporterDuration = NewHistogram(Opts{
+		Name:    "gatesvr_transporter_duration_seconds",
+		Help:    "Time taken by internal transporter requests per remote instance.",
+		Labels:  []string{"addr", "method"},
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	})
+
+	// TransporterErrors 内部传输调用失败数
+	TransporterErrors = NewCounter(Opts{
+		Name:   "gatesvr_transporter_errors_total",
+		Help:   "Number of failed internal transporter requests per remote instance.",
+		Labels: []string{"addr", "method"},
+	})
+
+	// ActorMailboxDepth Actor投递消息时的邮箱积压深度
+	ActorMailboxDepth = NewHistogram(Opts{
+		Name:    "gatesvr_actor_mailbox_depth",
+		Help:    "Number of messages waiting in an actor mailbox when a new one is queued.",
+		Labels:  []string{"kind"},
+		Buckets: []float64{0, 1, 2, 5, 10, 20, 50, 100, 200, 500, 1000},
+	})
+
+	// ActorMailboxOverflows Actor邮箱溢出的消息数
+	ActorMailboxOverflows = NewCounter(Opts{
+		Name:   "gatesvr_actor_mailbox_overflows_total",
+		Help:   "Number of messages dropped or rejected because an actor mailbox was full.",
+		Labels: []string{"kind", "policy"},
+	})
+)
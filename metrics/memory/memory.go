@@ -0,0 +1,272 @@
+package memory
+
+import (
+	"fmt"
+	"gatesvr/metrics"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const Name = "memory"
+
+const (
+	counterKind   = "counter"
+	gaugeKind     = "gauge"
+	histogramKind = "histogram"
+)
+
+var _ metrics.Metrics = &Metrics{}
+
+// Metrics 内存指标后端，指标数据保存在内存中，可直接读取，适用于测试
+type Metrics struct {
+	rw       sync.RWMutex
+	families map[string]*family
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{families: make(map[string]*family)}
+}
+
+// Name 名称
+func (m *Metrics) Name() string {
+	return Name
+}
+
+// Counter 获取计数器
+func (m *Metrics) Counter(opts metrics.Opts) metrics.Counter {
+	return m.family(counterKind, opts)
+}
+
+// Gauge 获取仪表盘
+func (m *Metrics) Gauge(opts metrics.Opts) metrics.Gauge {
+	return m.family(gaugeKind, opts)
+}
+
+// Histogram 获取直方图
+func (m *Metrics) Histogram(opts metrics.Opts) metrics.Histogram {
+	return m.family(histogramKind, opts)
+}
+
+// Value 获取计数器或仪表盘的值，直方图返回观测值之和
+func (m *Metrics) Value(name string, labelValues ...string) float64 {
+	if s := m.sample(name, labelValues); s != nil {
+		return s.load().value
+	}
+
+	return 0
+}
+
+// Count 获取直方图的观测次数
+func (m *Metrics) Count(name string, labelValues ...string) uint64 {
+	if s := m.sample(name, labelValues); s != nil {
+		return s.load().count
+	}
+
+	return 0
+}
+
+// Handler 获取指标导出处理器，以Prometheus文本格式输出
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = w.Write([]byte(m.String()))
+	})
+}
+
+// String 以Prometheus文本格式输出全部指标
+func (m *Metrics) String() string {
+	m.rw.RLock()
+	names := make([]string, 0, len(m.families))
+	for name := range m.families {
+		names = append(names, name)
+	}
+	m.rw.RUnlock()
+
+	sort.Strings(names)
+
+	var buf strings.Builder
+	for _, name := range names {
+		m.rw.RLock()
+		f := m.families[name]
+		m.rw.RUnlock()
+
+		f.write(&buf)
+	}
+
+	return buf.String()
+}
+
+func (m *Metrics) family(kind string, opts metrics.Opts) *family {
+	m.rw.RLock()
+	f, ok := m.families[opts.Name]
+	m.rw.RUnlock()
+
+	if ok {
+		return f
+	}
+
+	m.rw.Lock()
+	defer m.rw.Unlock()
+
+	if f, ok = m.families[opts.Name]; ok {
+		return f
+	}
+
+	f = &family{kind: kind, opts: opts, samples: make(map[string]*sample)}
+	m.families[opts.Name] = f
+
+	return f
+}
+
+func (m *Metrics) sample(name string, labelValues []string) *sample {
+	m.rw.RLock()
+	f, ok := m.families[name]
+	m.rw.RUnlock()
+
+	if !ok {
+		return nil
+	}
+
+	f.rw.RLock()
+	defer f.rw.RUnlock()
+
+	return f.samples[key(labelValues)]
+}
+
+type family struct {
+	rw      sync.RWMutex
+	kind    string
+	opts    metrics.Opts
+	samples map[string]*sample
+}
+
+func (f *family) Add(delta float64, labelValues ...string) {
+	f.sample(labelValues).update(func(s *snapshot) { s.value += delta })
+}
+
+func (f *family) Set(value float64, labelValues ...string) {
+	f.sample(labelValues).update(func(s *snapshot) { s.value = value })
+}
+
+func (f *family) Observe(value float64, labelValues ...string) {
+	f.sample(labelValues).update(func(s *snapshot) {
+		s.value += value
+		s.count++
+		for i, bucket := range f.opts.Buckets {
+			if value <= bucket {
+				s.buckets[i]++
+			}
+		}
+	})
+}
+
+func (f *family) sample(labelValues []string) *sample {
+	k := key(labelValues)
+
+	f.rw.RLock()
+	s, ok := f.samples[k]
+	f.rw.RUnlock()
+
+	if ok {
+		return s
+	}
+
+	f.rw.Lock()
+	defer f.rw.Unlock()
+
+	if s, ok = f.samples[k]; ok {
+		return s
+	}
+
+	s = &sample{labelValues: append([]string(nil), labelValues...)}
+	s.buckets = make([]uint64, len(f.opts.Buckets))
+	f.samples[k] = s
+
+	return s
+}
+
+func (f *family) write(buf *strings.Builder) {
+	f.rw.RLock()
+	samples := make([]*sample, 0, len(f.samples))
+	for _, s := range f.samples {
+		samples = append(samples, s)
+	}
+	f.rw.RUnlock()
+
+	sort.Slice(samples, func(i, j int) bool {
+		return key(samples[i].labelValues) < key(samples[j].labelValues)
+	})
+
+	fmt.Fprintf(buf, "# HELP %s %s\n", f.opts.Name, f.opts.Help)
+	fmt.Fprintf(buf, "# TYPE %s %s\n", f.opts.Name, f.kind)
+
+	for _, s := range samples {
+		snap := s.load()
+
+		if f.kind != histogramKind {
+			fmt.Fprintf(buf, "%s%s %v\n", f.opts.Name, f.labels(s.labelValues), snap.value)
+			continue
+		}
+
+		for i, bucket := range f.opts.Buckets {
+			fmt.Fprintf(buf, "%s_bucket%s %d\n", f.opts.Name, f.labels(s.labelValues, "le", fmt.Sprint(bucket)), snap.buckets[i])
+		}
+		fmt.Fprintf(buf, "%s_bucket%s %d\n", f.opts.Name, f.labels(s.labelValues, "le", fmt.Sprint(math.Inf(1))), snap.count)
+		fmt.Fprintf(buf, "%s_sum%s %v\n", f.opts.Name, f.labels(s.labelValues), snap.value)
+		fmt.Fprintf(buf, "%s_count%s %d\n", f.opts.Name, f.labels(s.labelValues), snap.count)
+	}
+}
+
+func (f *family) labels(labelValues []string, extra ...string) string {
+	pairs := make([]string, 0, len(labelValues)+1)
+	for i, name := range f.opts.Labels {
+		if i < len(labelValues) {
+			pairs = append(pairs, fmt.Sprintf("%s=%q", name, labelValues[i]))
+		}
+	}
+
+	if len(extra) == 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", extra[0], extra[1]))
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+type snapshot struct {
+	value   float64
+	count   uint64
+	buckets []uint64
+}
+
+type sample struct {
+	mu          sync.Mutex
+	labelValues []string
+	snapshot
+}
+
+func (s *sample) update(fn func(s *snapshot)) {
+	s.mu.Lock()
+	fn(&s.snapshot)
+	s.mu.Unlock()
+}
+
+func (s *sample) load() snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.snapshot
+	snap.buckets = append([]uint64(nil), s.buckets...)
+
+	return snap
+}
+
+func key(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
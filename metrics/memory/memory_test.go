@@ -0,0 +1,86 @@
+package memory_test
+
+import (
+	"gatesvr/metrics"
+	"gatesvr/metrics/memory"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics_Value(t *testing.T) {
+	m := memory.NewMetrics()
+
+	counter := m.Counter(metrics.Opts{Name: "requests_total", Labels: []string{"route"}})
+	counter.Add(1, "1")
+	counter.Add(2, "1")
+	counter.Add(1, "2")
+
+	if v := m.Value("requests_total", "1"); v != 3 {
+		t.Fatalf("counter value = %v, want 3", v)
+	}
+
+	if v := m.Value("requests_total", "2"); v != 1 {
+		t.Fatalf("counter value = %v, want 1", v)
+	}
+
+	gauge := m.Gauge(metrics.Opts{Name: "connections"})
+	gauge.Set(10)
+	gauge.Add(-3)
+
+	if v := m.Value("connections"); v != 7 {
+		t.Fatalf("gauge value = %v, want 7", v)
+	}
+
+	histogram := m.Histogram(metrics.Opts{Name: "duration_seconds", Buckets: []float64{0.1, 1}})
+	histogram.Observe(0.05)
+	histogram.Observe(0.5)
+
+	if c := m.Count("duration_seconds"); c != 2 {
+		t.Fatalf("histogram count = %d, want 2", c)
+	}
+
+	if v := m.Value("unknown"); v != 0 {
+		t.Fatalf("unknown value = %v, want 0", v)
+	}
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	m := memory.NewMetrics()
+	m.Counter(metrics.Opts{Name: "requests_total", Help: "Requests.", Labels: []string{"route"}}).Add(2, "1")
+	m.Histogram(metrics.Opts{Name: "duration_seconds", Help: "Duration.", Buckets: []float64{0.1, 1}}).Observe(0.5)
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, _ := io.ReadAll(w.Body)
+
+	for _, line := range []string{
+		"# TYPE requests_total counter",
+		`requests_total{route="1"} 2`,
+		`duration_seconds_bucket{le="0.1"} 0`,
+		`duration_seconds_bucket{le="1"} 1`,
+		`duration_seconds_bucket{le="+Inf"} 1`,
+		"duration_seconds_count 1",
+	} {
+		if !strings.Contains(string(body), line) {
+			t.Fatalf("missing line %q in:\n%s", line, body)
+		}
+	}
+}
+
+func TestSetMetrics(t *testing.T) {
+	counter := metrics.NewCounter(metrics.Opts{Name: "lazy_total"})
+	counter.Add(1)
+
+	m := memory.NewMetrics()
+	metrics.SetMetrics(m)
+	defer metrics.SetMetrics(nil)
+
+	counter.Add(2)
+
+	if v := m.Value("lazy_total"); v != 2 {
+		t.Fatalf("counter value = %v, want 2", v)
+	}
+}
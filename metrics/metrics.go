@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+type Metrics interface {
+	// Name 名称
+	Name() string
+	// Counter 获取计数器，同名指标返回同一计数器
+	Counter(opts Opts) Counter
+	// Gauge 获取仪表盘，同名指标返回同一仪表盘
+	Gauge(opts Opts) Gauge
+	// Histogram 获取直方图，同名指标返回同一直方图
+	Histogram(opts Opts) Histogram
+	// Handler 获取指标导出处理器
+	Handler() http.Handler
+}
+
+type Opts struct {
+	Name    string    // 指标名称
+	Help    string    // 指标说明
+	Labels  []string  // 标签名
+	Buckets []float64 // 直方图分桶，仅直方图有效
+}
+
+type Counter interface {
+	// Add 增加计数
+	Add(delta float64, labelValues ...string)
+}
+
+type Gauge interface {
+	// Set 设置值
+	Set(value float64, labelValues ...string)
+	// Add 增减值
+	Add(delta float64, labelValues ...string)
+}
+
+type Histogram interface {
+	// Observe 记录观测值
+	Observe(value float64, labelValues ...string)
+}
+
+type holder struct {
+	metrics Metrics
+}
+
+var globalMetrics atomic.Pointer[holder]
+
+func init() {
+	SetMetrics(&noop{})
+}
+
+// SetMetrics 设置指标后端
+func SetMetrics(metrics Metrics) {
+	if metrics == nil {
+		metrics = &noop{}
+	}
+
+	globalMetrics.Store(&holder{metrics: metrics})
+}
+
+// GetMetrics 获取指标后端
+func GetMetrics() Metrics {
+	return globalMetrics.Load().metrics
+}
@@ -0,0 +1,26 @@
+package metrics
+
+import (
+	"net/http"
+)
+
+// 空指标后端，未设置指标后端时使用
+type noop struct{}
+
+func (*noop) Name() string { return "noop" }
+
+func (*noop) Counter(Opts) Counter { return noopCollector{} }
+
+func (*noop) Gauge(Opts) Gauge { return noopCollector{} }
+
+func (*noop) Histogram(Opts) Histogram { return noopCollector{} }
+
+func (*noop) Handler() http.Handler { return http.NotFoundHandler() }
+
+type noopCollector struct{}
+
+func (noopCollector) Add(float64, ...string) {}
+
+func (noopCollector) Set(float64, ...string) {}
+
+func (noopCollector) Observe(float64, ...string) {}
@@ -0,0 +1,108 @@
+package prometheus
+
+import (
+	"gatesvr/log"
+	"gatesvr/metrics"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const Name = "prometheus"
+
+var _ metrics.Metrics = &Metrics{}
+
+// Metrics Prometheus指标后端
+type Metrics struct {
+	mu         sync.Mutex
+	registry   *prometheus.Registry
+	collectors map[string]any
+}
+
+func NewMetrics() *Metrics {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector())
+	registry.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	return &Metrics{registry: registry, collectors: make(map[string]any)}
+}
+
+// Name 名称
+func (m *Metrics) Name() string {
+	return Name
+}
+
+// Registry 获取注册表，可用于注册自定义指标
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Counter 获取计数器
+func (m *Metrics) Counter(opts metrics.Opts) metrics.Counter {
+	return load(m, opts, func() *counter {
+		return &counter{prometheus.NewCounterVec(prometheus.CounterOpts{Name: opts.Name, Help: opts.Help}, opts.Labels)}
+	})
+}
+
+// Gauge 获取仪表盘
+func (m *Metrics) Gauge(opts metrics.Opts) metrics.Gauge {
+	return load(m, opts, func() *gauge {
+		return &gauge{prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: opts.Name, Help: opts.Help}, opts.Labels)}
+	})
+}
+
+// Histogram 获取直方图
+func (m *Metrics) Histogram(opts metrics.Opts) metrics.Histogram {
+	return load(m, opts, func() *histogram {
+		return &histogram{prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: opts.Name, Help: opts.Help, Buckets: opts.Buckets}, opts.Labels)}
+	})
+}
+
+// Handler 获取指标导出处理器
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// 获取或注册同名指标
+func load[T prometheus.Collector](m *Metrics, opts metrics.Opts, create func() T) T {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if c, ok := m.collectors[opts.Name]; ok {
+		if v, ok := c.(T); ok {
+			return v
+		}
+		log.Fatalf("the %s metric has been registered with another type", opts.Name)
+	}
+
+	c := create()
+	m.registry.MustRegister(c)
+	m.collectors[opts.Name] = c
+
+	return c
+}
+
+type counter struct{ *prometheus.CounterVec }
+
+func (c *counter) Add(delta float64, labelValues ...string) {
+	c.WithLabelValues(labelValues...).Add(delta)
+}
+
+type gauge struct{ *prometheus.GaugeVec }
+
+func (g *gauge) Set(value float64, labelValues ...string) {
+	g.WithLabelValues(labelValues...).Set(value)
+}
+
+func (g *gauge) Add(delta float64, labelValues ...string) {
+	g.WithLabelValues(labelValues...).Add(delta)
+}
+
+type histogram struct{ *prometheus.HistogramVec }
+
+func (h *histogram) Observe(value float64, labelValues ...string) {
+	h.WithLabelValues(labelValues...).Observe(value)
+}
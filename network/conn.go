@@ -39,6 +39,19 @@ type (
 		// RemoteAddr 获取远端地址
 		RemoteAddr() (net.Addr, error)
 
+		// CheckAndSendPendingMessages 检查并发送断线期间缓存的消息
 		CheckAndSendPendingMessages() error
+		// SetCipher 设置会话加密器，设置后Send、Push发送的消息由连接加密，断线缓存的消息以明文保存
+		SetCipher(cipher Cipher)
+		// Cipher 获取会话加密器，握手未完成时为nil
+		Cipher() Cipher
+	}
+
+	// Cipher 会话加密器，由连接握手协商产生，每个连接独立
+	Cipher interface {
+		// Seal 加密
+		Seal(seq int32, data []byte) ([]byte, error)
+		// Open 解密
+		Open(seq int32, data []byte) ([]byte, error)
 	}
 )
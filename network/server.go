@@ -28,3 +28,9 @@ type Server interface {
 	// OnDisconnect 监听连接断开
 	OnDisconnect(handler DisconnectHandler)
 }
+
+// Pauser 可暂停接入新连接的服务器
+type Pauser interface {
+	// Pause 暂停接入新连接，返回后不再有新连接打开，已建立的连接不受影响
+	Pause()
+}
@@ -6,6 +6,8 @@ import (
 	"sync/atomic"
 )
 
+// TCP客户端，不支持断线重连，连接断开后不会自动重新拨号
+// 需要断线重连时使用cluster/client的重连策略（client.WithReconnect、client.WithDialReconnect），由其通过重新拨号实现
 type client struct {
 	opts              *clientOptions            // 配置
 	id                int64                     // 连接ID
@@ -16,15 +16,16 @@ import (
 
 type clientConn struct {
 	rw                sync.RWMutex
-	id                int64         // 连接ID
-	uid               int64         // 用户ID
-	conn              net.Conn      // TCP源连接
-	state             int32         // 连接状态
-	client            *client       // 客户端
-	chWrite           chan chWrite  // 写入队列
-	done              chan struct{} // 写入完成信号
-	close             chan struct{} // 关闭信号
-	lastHeartbeatTime int64         // 上次心跳时间
+	id                int64          // 连接ID
+	uid               int64          // 用户ID
+	conn              net.Conn       // TCP源连接
+	state             int32          // 连接状态
+	client            *client        // 客户端
+	chWrite           chan chWrite   // 写入队列
+	done              chan struct{}  // 写入完成信号
+	close             chan struct{}  // 关闭信号
+	lastHeartbeatTime int64          // 上次心跳时间
+	cipher            network.Cipher // 会话加密器
 }
 
 var _ network.Conn = &clientConn{}
@@ -73,33 +74,43 @@ func (c *clientConn) Unbind() {
 }
 
 // Send 发送消息（同步）
-func (c *clientConn) Send(msg []byte) error {
-	if err := c.checkState(); err != nil {
-		return err
+func (c *clientConn) Send(msg []byte) (err error) {
+	if err = c.checkState(); err != nil {
+		return
 	}
 
 	c.rw.RLock()
-	conn := c.conn
+	conn, cipher := c.conn, c.cipher
 	c.rw.RUnlock()
 
 	if conn == nil {
 		return errors.ErrConnectionClosed
 	}
 
-	_, err := conn.Write(msg)
-	return err
+	if msg, err = seal(cipher, msg); err != nil {
+		return
+	}
+
+	_, err = conn.Write(msg)
+	return
 }
 
 // Push 发送消息（异步）
 func (c *clientConn) Push(msg []byte) (err error) {
+	c.rw.RLock()
+	defer c.rw.RUnlock()
 
+	// 持有读锁后再检测状态，避免向已关闭的写入队列投递消息
 	if err = c.checkState(); err != nil {
 		return
 	}
-	c.rw.RLock()
+
+	if msg, err = seal(c.cipher, msg); err != nil {
+		return
+	}
+
 	c.chWrite <- chWrite{typ: dataPacket, msg: msg}
 	//log.Debugf("push给conn的 msg:%v", msg)
-	c.rw.RUnlock()
 
 	return
 }
@@ -356,6 +367,22 @@ func (c *clientConn) write() {
 func (c *clientConn) isClosed() bool {
 	return network.ConnState(atomic.LoadInt32(&c.state)) == network.ConnClosed
 }
+
+// SetCipher 设置会话加密器
+func (c *clientConn) SetCipher(cipher network.Cipher) {
+	c.rw.Lock()
+	c.cipher = cipher
+	c.rw.Unlock()
+}
+
+// Cipher 获取会话加密器
+func (c *clientConn) Cipher() network.Cipher {
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	return c.cipher
+}
+
 func (c *clientConn) CheckAndSendPendingMessages() error {
 	//TODO implement me
 	return nil
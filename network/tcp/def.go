@@ -1,5 +1,10 @@
 package tcp
 
+import (
+	"gatesvr/network"
+	"gatesvr/packet"
+)
+
 const protocol = "tcp"
 
 const (
@@ -8,6 +13,16 @@ const (
 )
 
 type chWrite struct {
-	typ int
-	msg []byte
+	typ   int
+	msg   []byte // 待写入的消息，握手完成后为密文
+	plain []byte // 加密前的消息，连接断开时缓存以便重新绑定后以新连接的会话密钥重新加密
+}
+
+// 使用会话加密器加密已打包的消息，握手未完成时原样返回
+func seal(cipher network.Cipher, msg []byte) ([]byte, error) {
+	if cipher == nil {
+		return msg, nil
+	}
+
+	return packet.SealMessage(msg, cipher.Seal)
 }
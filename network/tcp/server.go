@@ -3,7 +3,9 @@ package tcp
 import (
 	"gatesvr/log"
 	"gatesvr/network"
+	"gatesvr/utils/xcall"
 	"net"
+	"sync"
 	"time"
 )
 
@@ -16,9 +18,15 @@ type server struct {
 	connectHandler    network.ConnectHandler    // 连接打开hook函数
 	disconnectHandler network.DisconnectHandler // 连接关闭hook函数
 	receiveHandler    network.ReceiveHandler    // 接收消息hook函数
+	done              chan struct{}             // 关闭信号
+	rw                sync.RWMutex              // 读写锁，保证暂停后不再有连接在分配中
+	paused            bool                      // 是否暂停接入新连接
 }
 
-var _ network.Server = &server{}
+var (
+	_ network.Server = &server{}
+	_ network.Pauser = &server{}
+)
 
 func NewServer(opts ...ServerOption) network.Server {
 	o := defaultServerOptions()
@@ -50,20 +58,7 @@ func (s *server) Start() error {
 
 	go s.serve()
 
-	//// 启动协程定期清理过期消息
-	//xcall.Go(func() {
-	//	ticker := time.NewTicker(time.Minute * 3) // 每分钟清理一次
-	//	defer ticker.Stop()
-	//
-	//	for {
-	//		select {
-	//		case <-ticker.C:
-	//			s.connMgr.clearExpiredMessages()
-	//		default:
-	//			continue
-	//		}
-	//	}
-	//})
+	xcall.Go(func() { s.connMgr.sweep(s.done) })
 
 	return nil
 }
@@ -74,6 +69,8 @@ func (s *server) Stop() error {
 		return err
 	}
 
+	close(s.done)
+
 	s.connMgr.close()
 
 	if s.stopHandler != nil {
@@ -126,6 +123,7 @@ func (s *server) init() error {
 	}
 
 	s.listener = ln
+	s.done = make(chan struct{})
 
 	return nil
 }
@@ -158,9 +156,29 @@ func (s *server) serve() {
 
 		tempDelay = 0
 
-		if err = s.connMgr.allocate(conn); err != nil {
-			log.Errorf("connection allocate error: %v", err)
-			_ = conn.Close()
-		}
+		s.accept(conn)
 	}
 }
+
+// 接入连接，暂停接入期间直接关闭新连接
+func (s *server) accept(conn net.Conn) {
+	s.rw.RLock()
+	defer s.rw.RUnlock()
+
+	if s.paused {
+		_ = conn.Close()
+		return
+	}
+
+	if err := s.connMgr.allocate(conn); err != nil {
+		log.Errorf("connection allocate error: %v", err)
+		_ = conn.Close()
+	}
+}
+
+// Pause 暂停接入新连接，已建立的连接不受影响
+func (s *server) Pause() {
+	s.rw.Lock()
+	s.paused = true
+	s.rw.Unlock()
+}
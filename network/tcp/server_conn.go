@@ -14,10 +14,6 @@ import (
 	"time"
 )
 
-const (
-	DISCONNECT_WAITING_TIME = 3 * 60 * 1e9 // 3 minutes
-)
-
 type serverConn struct {
 	id                int64          // 连接ID
 	uid               int64          // 用户ID
@@ -29,6 +25,14 @@ type serverConn struct {
 	done              chan struct{}  // 写入完成信号
 	close             chan struct{}  // 关闭信号
 	lastHeartbeatTime int64          // 上次心跳时间
+	cipher            network.Cipher // 会话加密器
+	reservation       *reservation   // 关闭时的缓存信息
+}
+
+// 连接关闭时的缓存信息，每次初始化连接时重新创建，保证连接回收复用后写入协程读取到的仍是原连接的信息
+type reservation struct {
+	uid      int64 // 关闭时绑定的用户ID
+	reserved bool  // 是否缓存未发送的消息
 }
 
 var _ network.Conn = &serverConn{}
@@ -43,7 +47,7 @@ func (c *serverConn) UID() int64 {
 	return atomic.LoadInt64(&c.uid)
 }
 
-// Bind 绑定用户ID
+// Bind 绑定用户ID，断线期间缓存的消息由调用方在释放锁后通过CheckAndSendPendingMessages重放
 func (c *serverConn) Bind(uid int64) {
 	atomic.StoreInt64(&c.uid, uid)
 }
@@ -60,13 +64,17 @@ func (c *serverConn) Send(msg []byte) (err error) {
 	}
 
 	c.rw.RLock()
-	conn := c.conn
+	conn, cipher := c.conn, c.cipher
 	c.rw.RUnlock()
 
 	if conn == nil {
 		return errors.ErrConnectionClosed
 	}
 
+	if msg, err = seal(cipher, msg); err != nil {
+		return
+	}
+
 	_, err = conn.Write(msg)
 	return
 }
@@ -80,7 +88,12 @@ func (c *serverConn) Push(msg []byte) (err error) {
 		return
 	}
 
-	c.chWrite <- chWrite{typ: dataPacket, msg: msg}
+	sealed, err := seal(c.cipher, msg)
+	if err != nil {
+		return
+	}
+
+	c.chWrite <- chWrite{typ: dataPacket, msg: sealed, plain: msg}
 
 	return
 }
@@ -93,7 +106,7 @@ func (c *serverConn) State() network.ConnState {
 // Close 关闭连接
 func (c *serverConn) Close(force ...bool) error {
 	if len(force) > 0 && force[0] {
-		return c.forceClose(true)
+		return c.forceClose(true, false)
 	} else {
 		return c.graceClose(true)
 	}
@@ -174,16 +187,14 @@ func (c *serverConn) init(cm *serverConnMgr, id int64, conn net.Conn) {
 	c.done = make(chan struct{})
 	c.close = make(chan struct{})
 	c.lastHeartbeatTime = xtime.Now().UnixNano()
+	c.cipher = nil
+	c.reservation = &reservation{}
 	atomic.StoreInt64(&c.uid, 0)
 	atomic.StoreInt32(&c.state, int32(network.ConnOpened))
-	//if err := c.checkAndSendPendingMessages(); err != nil {
-	//	log.Error("init connection failed, error: %s", err.Error())
-	//	c.Close()
-	//	return
-	//}
-	xcall.Go(c.read)
 
-	xcall.Go(c.write)
+	xcall.Go(func() { c.read(conn) })
+
+	xcall.Go(func() { c.write(conn) })
 
 	if c.connMgr.server.connectHandler != nil {
 		c.connMgr.server.connectHandler(c)
@@ -227,8 +238,8 @@ func (c *serverConn) graceClose(isNeedRecycle bool) error {
 	return err
 }
 
-// 强制关闭
-func (c *serverConn) forceClose(isNeedRecycle bool) error {
+// 强制关闭，isNeedReserve为true时缓存写入队列中未发送的消息，待用户重新绑定连接后重放
+func (c *serverConn) forceClose(isNeedRecycle bool, isNeedReserve bool) error {
 	if !atomic.CompareAndSwapInt32(&c.state, int32(network.ConnOpened), int32(network.ConnClosed)) {
 		if !atomic.CompareAndSwapInt32(&c.state, int32(network.ConnHanged), int32(network.ConnClosed)) {
 			return errors.ErrConnectionClosed
@@ -237,11 +248,11 @@ func (c *serverConn) forceClose(isNeedRecycle bool) error {
 
 	c.rw.Lock()
 	close(c.chWrite)
-	//var pending []chWrite
-	//for msg := range c.chWrite {
-	//	pending = append(pending, msg)
-	//}
-	//c.savePendingMessages(pending)
+	if isNeedReserve {
+		c.reservation.uid = atomic.LoadInt64(&c.uid)
+		c.reservation.reserved = true
+		c.savePendingMessages()
+	}
 	close(c.close)
 	close(c.done)
 	conn := c.conn
@@ -261,46 +272,8 @@ func (c *serverConn) forceClose(isNeedRecycle bool) error {
 	return err
 }
 
-//func (c *serverConn) forceClose(isNeedRecycle bool) error {
-//	if !atomic.CompareAndSwapInt32(&c.state, int32(network.ConnOpened), int32(network.ConnHanged)) {
-//		return errors.ErrConnectionNotOpened
-//	}
-//
-//	c.rw.RLock()
-//	c.chWrite <- chWrite{typ: closeSig}
-//	c.rw.RUnlock()
-//
-//	<-c.done
-//
-//	if !atomic.CompareAndSwapInt32(&c.state, int32(network.ConnHanged), int32(network.ConnClosed)) {
-//		return errors.ErrConnectionNotHanged
-//	}
-//
-//	c.rw.Lock()
-//	close(c.chWrite)
-//	close(c.close)
-//	close(c.done)
-//	conn := c.conn
-//	c.conn = nil
-//	c.rw.Unlock()
-//
-//	err := conn.Close()
-//
-//	if isNeedRecycle {
-//		c.connMgr.recycle(conn)
-//	}
-//
-//	if c.connMgr.server.disconnectHandler != nil {
-//		c.connMgr.server.disconnectHandler(c)
-//	}
-//
-//	return err
-//}
-
-// 读取消息
-func (c *serverConn) read() {
-	conn := c.conn
-
+// 读取消息，源连接由初始化时传入，避免与关闭时清空c.conn并发读写
+func (c *serverConn) read(conn net.Conn) {
 	for {
 		select {
 		case <-c.close:
@@ -308,7 +281,7 @@ func (c *serverConn) read() {
 		default:
 			msg, err := packet.ReadMessage(conn)
 			if err != nil {
-				_ = c.forceClose(true)
+				_ = c.forceClose(true, true)
 				return
 			}
 
@@ -359,10 +332,11 @@ func (c *serverConn) read() {
 }
 
 // 写入消息
-func (c *serverConn) write() {
+func (c *serverConn) write(conn net.Conn) {
 	var (
-		conn   = c.conn
-		ticker *time.Ticker
+		closeCh     = c.close
+		reservation = c.reservation
+		ticker      *time.Ticker
 	)
 
 	if c.connMgr.server.opts.heartbeatInterval > 0 {
@@ -387,19 +361,23 @@ func (c *serverConn) write() {
 			}
 
 			if c.isClosed() {
+				c.saveInflightMessage(closeCh, reservation, r)
 				return
 			}
-			//time.Sleep(200 * time.Millisecond)
+
 			if _, err := conn.Write(r.msg); err != nil {
-				//log.Debugf("发送失败的消息: %v", r.msg)
+				if c.isClosed() {
+					c.saveInflightMessage(closeCh, reservation, r)
+					return
+				}
+
 				log.Errorf("write data message error: %v", err)
 			}
-			//log.Debugf("conn.Write发送消息: %v", r.msg)
 		case <-ticker.C:
 			deadline := xtime.Now().Add(-2 * c.connMgr.server.opts.heartbeatInterval).UnixNano()
 			if atomic.LoadInt64(&c.lastHeartbeatTime) < deadline {
 				log.Debugf("connection heartbeat timeout, cid: %d,uid :&=%d", c.id, c.uid)
-				_ = c.forceClose(true)
+				_ = c.forceClose(true, true)
 				return
 			} else {
 				if c.connMgr.server.opts.heartbeatMechanism == TickHeartbeat {
@@ -426,80 +404,105 @@ func (c *serverConn) isClosed() bool {
 	return network.ConnState(atomic.LoadInt32(&c.state)) == network.ConnClosed
 }
 
-// 检查并发送待传输消息
+// SetCipher 设置会话加密器
+func (c *serverConn) SetCipher(cipher network.Cipher) {
+	c.rw.Lock()
+	c.cipher = cipher
+	c.rw.Unlock()
+}
+
+// Cipher 获取会话加密器
+func (c *serverConn) Cipher() network.Cipher {
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	return c.cipher
+}
+
+// CheckAndSendPendingMessages 检查并发送待传输消息
 func (c *serverConn) CheckAndSendPendingMessages() error {
 	uid := atomic.LoadInt64(&c.uid)
 	if uid == 0 {
 		return nil
 	}
 
-	// 从全局pendingMessages中获取该uid的消息
-	value, ok := c.connMgr.pendingMessages.Load(uid)
-	if !ok {
+	messages := c.connMgr.takePendingMessages(uid)
+	if len(messages) == 0 {
 		return nil
 	}
 
-	data := value.(*uidTimestamp)
-	c.rw.Lock()
-	defer c.rw.Unlock()
+	timer := time.NewTimer(time.Second)
+	defer timer.Stop()
 
-	// 检查连接状态
-	if err := c.checkState(); err != nil {
-		return err
-	}
+	retry := time.NewTicker(10 * time.Millisecond)
+	defer retry.Stop()
+
+	for i := 0; i < len(messages); {
+		ok, err := c.tryPush(messages[i].msg)
+		if err != nil {
+			c.connMgr.savePendingMessages(uid, messages[i:], true)
+			return err
+		}
+
+		if ok {
+			i++
+			continue
+		}
 
-	// 将消息写入chWrite
-	for _, msg := range data.messages {
+		// 写入队列已满，不持有锁等待写入协程消费
 		select {
-		case c.chWrite <- chWrite{typ: dataPacket, msg: msg.msg}:
-		case <-time.After(100 * time.Millisecond):
-			return errors.New("write pending messages timeout")
+		case <-retry.C:
+		case <-timer.C:
+			c.connMgr.savePendingMessages(uid, messages[i:], true)
+			return errors.ErrReplayPendingTimeout
 		}
 	}
 
-	// 清理已发送的消息
-	c.connMgr.pendingMessages.Delete(uid)
 	return nil
 }
 
-// 保存未发送的消息
-//
-//	func (c *serverConn) savePendingMessages() {
-//		c.rw.RLock()
-//		defer c.rw.RUnlock()
-//
-//		uid := atomic.LoadInt64(&c.uid)
-//		if uid == 0 {
-//			return
-//		}
-//
-//		var messages []pendingMsg
-//		for msg := range c.chWrite {
-//			if msg.typ == dataPacket {
-//				messages = append(messages, pendingMsg{msg: msg.msg})
-//			}
-//		}
-//
-//		if len(messages) > 0 {
-//			c.connMgr.pendingMessages.Store(uid, &uidTimestamp{
-//				timestamp: xtime.Now().UnixNano(),
-//				messages:  messages,
-//			})
-//		}
-//		value, ok := c.connMgr.pendingMessages.Load(uid) // 获取uidTimestamp
-//		if ok {
-//			value.(*uidTimestamp).messages = append(value.(*uidTimestamp).messages, messages...)
-//			log.Debugf("save pending messages: %v", value.(*uidTimestamp).messages)
-//		} else {
-//			log.Debugf("save pending fial")
-//		}
-//
-// }
-func (c *serverConn) savePendingMessages(msgs []chWrite) {
-	// 这里可以写入磁盘、数据库、内存队列等
-	for _, m := range msgs {
-		log.Debugf("save pending messages: %v", m.msg)
-		// 伪代码：写入本地队列
-		// localQueue.Push(m)
+// 尝试将消息放入写入队列，队列已满时返回false，缓存的消息为明文，以当前连接的会话密钥加密
+func (c *serverConn) tryPush(msg []byte) (bool, error) {
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	if err := c.checkState(); err != nil {
+		return false, err
+	}
+
+	sealed, err := seal(c.cipher, msg)
+	if err != nil {
+		return false, err
+	}
+
+	select {
+	case c.chWrite <- chWrite{typ: dataPacket, msg: sealed, plain: msg}:
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// 保存写入队列中未发送的消息，调用方需持有写锁且已关闭写入队列
+func (c *serverConn) savePendingMessages() {
+	var messages []pendingMsg
+	for r := range c.chWrite {
+		if r.typ == dataPacket {
+			messages = append(messages, pendingMsg{msg: r.plain})
+		}
+	}
+
+	c.connMgr.savePendingMessages(atomic.LoadInt64(&c.uid), messages, false)
+}
+
+// 保存连接关闭时正在发送的消息，该消息早于写入队列中剩余的消息
+// 关闭信号发出前缓存信息已写入reservation，连接此后可能被回收复用，不可再读取连接上的字段
+func (c *serverConn) saveInflightMessage(closeCh <-chan struct{}, reservation *reservation, r chWrite) {
+	<-closeCh
+
+	if r.typ != dataPacket || !reservation.reserved {
+		return
 	}
+
+	c.connMgr.savePendingMessages(reservation.uid, []pendingMsg{{msg: r.plain}}, true)
 }
@@ -4,12 +4,14 @@ import (
 	"gatesvr/errors"
 	"gatesvr/filter"
 	"gatesvr/log"
+	"gatesvr/metrics"
 	"gatesvr/utils/xcall"
 	"gatesvr/utils/xtime"
 	"net"
 	"reflect"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type pendingMsg struct {
@@ -17,17 +19,18 @@ type pendingMsg struct {
 }
 
 type uidTimestamp struct {
-	timestamp int64
-	messages  []pendingMsg
+	timestamp int64        // 缓存创建时间
+	messages  []pendingMsg // 待发送消息
 }
 
 type serverConnMgr struct {
-	id              int64        // 连接ID
-	total           int64        // 总连接数
-	server          *server      // 服务器
-	pool            sync.Pool    // 连接池
-	partitions      []*partition // 连接管理
-	pendingMessages sync.Map     // map[int64][]pendingMsg
+	id              int64                   // 连接ID
+	total           int64                   // 总连接数
+	server          *server                 // 服务器
+	pool            sync.Pool               // 连接池
+	partitions      []*partition            // 连接管理
+	pendingMu       sync.Mutex              // 断线消息锁
+	pendingMessages map[int64]*uidTimestamp // 断线消息（用户ID -> 待发送消息）
 }
 
 func newServerConnMgr(server *server) *serverConnMgr {
@@ -35,7 +38,7 @@ func newServerConnMgr(server *server) *serverConnMgr {
 	cm.server = server
 	cm.pool = sync.Pool{New: func() interface{} { return &serverConn{} }}
 	cm.partitions = make([]*partition, 100)
-	cm.pendingMessages = sync.Map{}
+	cm.pendingMessages = make(map[int64]*uidTimestamp)
 
 	for i := 0; i < len(cm.partitions); i++ {
 		cm.partitions[i] = &partition{connections: make(map[net.Conn]*serverConn)}
@@ -66,24 +69,26 @@ func (cm *serverConnMgr) close() {
 func (cm *serverConnMgr) allocate(c net.Conn) error {
 	if isBlack := filter.BlackListCheck(c.RemoteAddr()); isBlack {
 		log.Errorf("black list check failed, ip = %v", c.RemoteAddr())
+		metrics.NetworkRejections.Add(1, "tcp", "blacklist")
 		c.Close()
 		return errors.ErrBlackUser
 	}
 	if atomic.LoadInt64(&cm.total) >= int64(cm.server.opts.maxConnNum) {
+		metrics.NetworkRejections.Add(1, "tcp", "max_conn")
 		return errors.ErrTooManyConnection
 	}
-	if isWrite := filter.WriteListCheck(c.RemoteAddr()); !isWrite {
-		if isOverMaxCount := filter.IpConnectCountCheck(c.RemoteAddr()); isOverMaxCount {
-			c.Close()
-			return errors.ErrTooManyConnection
-		}
+	if !filter.TryConnect(c.RemoteAddr()) {
+		metrics.NetworkRejections.Add(1, "tcp", "max_conn_per_ip")
+		c.Close()
+		return errors.ErrTooManyConnection
 	}
 	id := atomic.AddInt64(&cm.id, 1)
 	conn := cm.pool.Get().(*serverConn)
-	conn.init(cm, id, c)
+	// 先存储再初始化，初始化后读写协程即已启动，连接立即断开时回收需能找到连接以释放单IP连接数
 	index := int(reflect.ValueOf(c).Pointer()) % len(cm.partitions)
 	cm.partitions[index].store(c, conn)
-	atomic.AddInt64(&cm.total, 1)
+	metrics.NetworkConnections.Set(float64(atomic.AddInt64(&cm.total, 1)), "tcp")
+	conn.init(cm, id, c)
 
 	return nil
 }
@@ -93,7 +98,8 @@ func (cm *serverConnMgr) recycle(c net.Conn) {
 	index := int(reflect.ValueOf(c).Pointer()) % len(cm.partitions)
 	if conn, ok := cm.partitions[index].delete(c); ok {
 		cm.pool.Put(conn)
-		atomic.AddInt64(&cm.total, -1)
+		metrics.NetworkConnections.Set(float64(atomic.AddInt64(&cm.total, -1)), "tcp")
+		filter.Disconnect(c.RemoteAddr())
 	}
 }
 
@@ -137,16 +143,90 @@ func (p *partition) close() {
 	}
 }
 
+// 保存未发送的消息，prepend为true时将消息插入到已缓存消息之前
+func (cm *serverConnMgr) savePendingMessages(uid int64, messages []pendingMsg, prepend bool) {
+	if uid == 0 || len(messages) == 0 || cm.server.opts.pendingTimeout <= 0 {
+		return
+	}
+
+	cm.pendingMu.Lock()
+	defer cm.pendingMu.Unlock()
+
+	data, ok := cm.pendingMessages[uid]
+	if !ok || cm.isExpired(data) {
+		data = &uidTimestamp{timestamp: xtime.Now().UnixNano()}
+		cm.pendingMessages[uid] = data
+	}
+
+	if prepend {
+		data.messages = append(append(make([]pendingMsg, 0, len(messages)+len(data.messages)), messages...), data.messages...)
+	} else {
+		data.messages = append(data.messages, messages...)
+	}
+
+	// 超出缓存上限时丢弃最早的消息
+	if limit := cm.server.opts.pendingMaxNum; limit > 0 && len(data.messages) > limit {
+		log.Warnf("pending messages overflow, uid: %d, discard: %d", uid, len(data.messages)-limit)
+		data.messages = append([]pendingMsg(nil), data.messages[len(data.messages)-limit:]...)
+	}
+}
+
+// 取出未发送的消息
+func (cm *serverConnMgr) takePendingMessages(uid int64) []pendingMsg {
+	cm.pendingMu.Lock()
+	defer cm.pendingMu.Unlock()
+
+	data, ok := cm.pendingMessages[uid]
+	if !ok {
+		return nil
+	}
+
+	delete(cm.pendingMessages, uid)
+
+	if cm.isExpired(data) {
+		return nil
+	}
+
+	return data.messages
+}
+
 // 清理过期的未发送消息
-func (m *serverConnMgr) clearExpiredMessages() {
-	m.pendingMessages.Range(func(key, value interface{}) bool {
-		uid := key.(int64)
-		data := value.(*uidTimestamp)
+func (cm *serverConnMgr) clearExpiredMessages() {
+	cm.pendingMu.Lock()
+	defer cm.pendingMu.Unlock()
 
-		if xtime.Now().UnixNano()-data.timestamp > DISCONNECT_WAITING_TIME { // 3分钟
-			m.pendingMessages.Delete(uid)
+	for uid, data := range cm.pendingMessages {
+		if cm.isExpired(data) {
+			delete(cm.pendingMessages, uid)
 		}
+	}
+}
+
+// 定期清理过期的未发送消息
+func (cm *serverConnMgr) sweep(done <-chan struct{}) {
+	interval := cm.server.opts.pendingTimeout / 2
+	if interval <= 0 {
+		return
+	}
+
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			cm.clearExpiredMessages()
+		}
+	}
+}
 
-		return true
-	})
+// 检测缓存是否过期
+func (cm *serverConnMgr) isExpired(data *uidTimestamp) bool {
+	return xtime.Now().UnixNano()-data.timestamp > int64(cm.server.opts.pendingTimeout)
 }
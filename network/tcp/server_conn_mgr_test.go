@@ -1,83 +1,157 @@
 package tcp
 
 import (
+	"gatesvr/filter"
+	"gatesvr/network"
 	"gatesvr/utils/xtime"
-	"sync"
+	"net"
+	"strconv"
 	"testing"
+	"time"
 )
 
 func TestSavePendingMessages(t *testing.T) {
-	mgr := &serverConnMgr{
-		pendingMessages: sync.Map{},
+	mgr := newTestConnMgr()
+
+	conn := newTestConn(mgr, 123)
+	conn.chWrite <- chWrite{typ: dataPacket, msg: []byte("sealed message"), plain: []byte("test message")}
+	conn.chWrite <- chWrite{typ: closeSig}
+	close(conn.chWrite)
+
+	conn.savePendingMessages()
+
+	messages := mgr.takePendingMessages(123)
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 pending message, got %d", len(messages))
 	}
 
-	conn := &serverConn{
-		connMgr: mgr,
-		uid:     123,
-		chWrite: make(chan chWrite, 10),
+	if string(messages[0].msg) != "test message" {
+		t.Errorf("Expected message 'test message', got '%s'", string(messages[0].msg))
 	}
+}
 
-	// 发送测试消息
-	msg := []byte("test message")
-	conn.chWrite <- chWrite{typ: dataPacket, msg: msg}
-	close(conn.chWrite)
+func TestSavePendingMessages_Order(t *testing.T) {
+	mgr := newTestConnMgr()
+	mgr.savePendingMessages(123, []pendingMsg{{msg: []byte("2")}, {msg: []byte("3")}}, false)
+	mgr.savePendingMessages(123, []pendingMsg{{msg: []byte("1")}}, true)
+	mgr.savePendingMessages(123, []pendingMsg{{msg: []byte("4")}}, false)
 
-	// 调用保存方法
-	conn.savePendingMessages()
+	messages := mgr.takePendingMessages(123)
+	for i, msg := range messages {
+		if string(msg.msg) != strconv.Itoa(i+1) {
+			t.Fatalf("Expected message '%d', got '%s'", i+1, string(msg.msg))
+		}
+	}
+}
 
-	// 验证消息是否保存
-	value, ok := mgr.pendingMessages.Load(int64(123))
-	t.Logf("Pending messages: %v", value)
-	if !ok {
-		t.Fatal("Expected pending messages for uid 123, but none found")
+func TestSavePendingMessages_Overflow(t *testing.T) {
+	mgr := newTestConnMgr(WithServerPendingMaxNum(3))
+
+	for i := 1; i <= 5; i++ {
+		mgr.savePendingMessages(123, []pendingMsg{{msg: []byte(strconv.Itoa(i))}}, false)
 	}
 
-	data := value.(*uidTimestamp)
-	if len(data.messages) != 1 {
-		t.Errorf("Expected 1 pending message, got %d", len(data.messages))
+	messages := mgr.takePendingMessages(123)
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 pending messages, got %d", len(messages))
 	}
-	t.Logf("Pending message content: %v", data.messages[0].msg)
-	if string(data.messages[0].msg) != string(msg) {
-		t.Errorf("Expected message '%s', got '%s'", string(msg), string(data.messages[0].msg))
+
+	if string(messages[0].msg) != "3" {
+		t.Errorf("Expected oldest message discarded, got '%s'", string(messages[0].msg))
 	}
 }
 
-func TestClearExpiredMessages(t *testing.T) {
-	mgr := &serverConnMgr{
-		pendingMessages: sync.Map{},
+func TestSavePendingMessages_Disabled(t *testing.T) {
+	mgr := newTestConnMgr(WithServerPendingTimeout(0))
+	mgr.savePendingMessages(123, []pendingMsg{{msg: []byte("test message")}}, false)
+
+	if messages := mgr.takePendingMessages(123); len(messages) != 0 {
+		t.Errorf("Expected no pending messages, got %d", len(messages))
 	}
+}
+
+func TestClearExpiredMessages(t *testing.T) {
+	mgr := newTestConnMgr(WithServerPendingTimeout(3 * time.Minute))
 
 	// 添加过期消息
-	expiredTime := xtime.Now().UnixNano() - 4*60*1e9 // 4分钟前
-	mgr.pendingMessages.Store(int64(123), &uidTimestamp{
-		timestamp: expiredTime,
+	mgr.pendingMessages[123] = &uidTimestamp{
+		timestamp: xtime.Now().Add(-4 * time.Minute).UnixNano(),
 		messages:  []pendingMsg{{msg: []byte("expired message")}},
-	})
+	}
 
 	// 添加未过期消息
-	validTime := xtime.Now().UnixNano() - 2*60*1e9 // 2分钟前
-	mgr.pendingMessages.Store(int64(456), &uidTimestamp{
-		timestamp: validTime,
+	mgr.pendingMessages[456] = &uidTimestamp{
+		timestamp: xtime.Now().Add(-2 * time.Minute).UnixNano(),
 		messages:  []pendingMsg{{msg: []byte("valid message")}},
-	})
+	}
 
-	// 调用清理方法
 	mgr.clearExpiredMessages()
 
-	// 验证过期消息是否被清理
-	_, ok := mgr.pendingMessages.Load(int64(123))
-	if ok {
+	if _, ok := mgr.pendingMessages[123]; ok {
 		t.Error("Expected expired messages to be deleted, but they were found")
 	}
 
-	// 验证未过期消息是否保留
-	value, ok := mgr.pendingMessages.Load(int64(456))
-	if !ok {
-		t.Fatal("Expected valid messages for uid 456, but none found")
+	if messages := mgr.takePendingMessages(456); len(messages) != 1 {
+		t.Errorf("Expected 1 pending message, got %d", len(messages))
+	}
+}
+
+func TestSweep(t *testing.T) {
+	mgr := newTestConnMgr(WithServerPendingTimeout(20 * time.Millisecond))
+	mgr.savePendingMessages(123, []pendingMsg{{msg: []byte("test message")}}, false)
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go mgr.sweep(done)
+
+	time.Sleep(60 * time.Millisecond)
+
+	mgr.pendingMu.Lock()
+	n := len(mgr.pendingMessages)
+	mgr.pendingMu.Unlock()
+
+	if n != 0 {
+		t.Errorf("Expected expired buffers swept, got %d", n)
+	}
+}
+
+func TestAllocate_CloseImmediately(t *testing.T) {
+	f := filter.NewFilter()
+	filter.SetFilter(f)
+
+	mgr := newTestConnMgr(WithServerMaxConnNum(100))
+	// 连接回调耗时较长时，读协程已感知到断开并回收连接
+	mgr.server.connectHandler = func(network.Conn) { time.Sleep(10 * time.Millisecond) }
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	client, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 对端在接入后立即断开
+	_ = client.Close()
+
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = mgr.allocate(c); err != nil {
+		t.Fatal(err)
 	}
 
-	data := value.(*uidTimestamp)
-	if len(data.messages) != 1 {
-		t.Errorf("Expected 1 pending message, got %d", len(data.messages))
+	deadline := time.Now().Add(3 * time.Second)
+	for f.ConnCount("127.0.0.1") != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("per-ip connection count leaked: %d", f.ConnCount("127.0.0.1"))
+		}
+		time.Sleep(10 * time.Millisecond)
 	}
 }
@@ -1,102 +1,271 @@
 package tcp
 
 import (
+	"gatesvr/crypto/ecdh"
+	"gatesvr/errors"
 	"gatesvr/network"
-	"gatesvr/utils/xtime"
-	"sync"
+	"gatesvr/packet"
+	"net"
+	"strconv"
 	"sync/atomic"
 	"testing"
 	"time"
 )
 
-func TestCheckAndSendPendingMessages(t *testing.T) {
-	// 初始化serverConnMgr
-	mgr := &serverConnMgr{
-		pendingMessages: sync.Map{},
+func newTestConnMgr(opts ...ServerOption) *serverConnMgr {
+	o := &serverOptions{pendingMaxNum: 10, pendingTimeout: time.Minute}
+	for _, opt := range opts {
+		opt(o)
 	}
 
-	// 初始化serverConn
+	return newServerConnMgr(&server{opts: o})
+}
+
+func newTestConn(mgr *serverConnMgr, uid int64) *serverConn {
 	conn := &serverConn{
-		connMgr: mgr,
-		uid:     123,
-		chWrite: make(chan chWrite, 10),
-		rw:      sync.RWMutex{},
+		connMgr:     mgr,
+		uid:         uid,
+		chWrite:     make(chan chWrite, 10),
+		close:       make(chan struct{}),
+		done:        make(chan struct{}),
+		reservation: &reservation{},
 	}
 	atomic.StoreInt32(&conn.state, int32(network.ConnOpened))
 
-	// 添加测试消息
-	testMsg := []byte("test message")
-	mgr.pendingMessages.Store(int64(123), &uidTimestamp{
-		timestamp: xtime.Now().UnixNano(),
-		messages:  []pendingMsg{{msg: testMsg}},
-	})
+	return conn
+}
 
-	// 验证消息是否发送
-	select {
-	case msg := <-conn.chWrite:
-		if msg.typ != dataPacket {
-			t.Errorf("Expected dataPacket type, got %v", msg.typ)
-		}
-		if string(msg.msg) != string(testMsg) {
-			t.Errorf("Expected message '%s', got '%s'", string(testMsg), string(msg.msg))
+func TestCheckAndSendPendingMessages(t *testing.T) {
+	mgr := newTestConnMgr()
+	mgr.savePendingMessages(123, []pendingMsg{{msg: []byte("1")}, {msg: []byte("2")}}, false)
+
+	conn := newTestConn(mgr, 0)
+	conn.Bind(123)
+
+	if err := conn.CheckAndSendPendingMessages(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, expected := range []string{"1", "2"} {
+		select {
+		case msg := <-conn.chWrite:
+			if msg.typ != dataPacket {
+				t.Errorf("Expected dataPacket type, got %v", msg.typ)
+			}
+			if string(msg.msg) != expected {
+				t.Errorf("Expected message '%s', got '%s'", expected, string(msg.msg))
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Fatal("Expected message to be sent, but none received")
 		}
-	case <-time.After(100 * time.Millisecond):
-		t.Error("Expected message to be sent, but none received")
 	}
 
-	// 验证消息是否被清理
-	_, ok := mgr.pendingMessages.Load(int64(123))
-	if ok {
+	if messages := mgr.takePendingMessages(123); len(messages) != 0 {
 		t.Error("Expected pending messages to be deleted, but they still exist")
 	}
 }
 
 func TestCheckAndSendPendingMessages_NoMessages(t *testing.T) {
-	mgr := &serverConnMgr{
-		pendingMessages: sync.Map{},
-	}
+	conn := newTestConn(newTestConnMgr(), 123)
 
-	conn := &serverConn{
-		connMgr: mgr,
-		uid:     123,
-		chWrite: make(chan chWrite, 10),
-		rw:      sync.RWMutex{},
-	}
-	atomic.StoreInt32(&conn.state, int32(network.ConnOpened))
-
-	err := conn.checkAndSendPendingMessages()
-	if err != nil {
+	if err := conn.CheckAndSendPendingMessages(); err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
 	select {
 	case <-conn.chWrite:
 		t.Error("Expected no message to be sent, but got one")
-	case <-time.After(100 * time.Millisecond):
-		// 正常情况
+	default:
 	}
 }
 
 func TestCheckAndSendPendingMessages_ClosedConnection(t *testing.T) {
-	mgr := &serverConnMgr{
-		pendingMessages: sync.Map{},
+	mgr := newTestConnMgr()
+	mgr.savePendingMessages(123, []pendingMsg{{msg: []byte("test message")}}, false)
+
+	conn := newTestConn(mgr, 123)
+	atomic.StoreInt32(&conn.state, int32(network.ConnClosed))
+
+	if err := conn.CheckAndSendPendingMessages(); !errors.Is(err, errors.ErrConnectionClosed) {
+		t.Errorf("Expected error %v, got %v", errors.ErrConnectionClosed, err)
 	}
 
-	conn := &serverConn{
-		connMgr: mgr,
-		uid:     123,
-		chWrite: make(chan chWrite, 10),
-		rw:      sync.RWMutex{},
+	if messages := mgr.takePendingMessages(123); len(messages) != 1 {
+		t.Errorf("Expected pending messages to be kept, got %d", len(messages))
 	}
-	atomic.StoreInt32(&conn.state, int32(network.ConnClosed))
+}
+
+func TestForceClose_ReplayOnRebind(t *testing.T) {
+	mgr := newTestConnMgr()
+
+	c1, c2 := net.Pipe()
+	defer c2.Close()
 
-	mgr.pendingMessages.Store(int64(123), &uidTimestamp{
-		timestamp: xtime.Now().UnixNano(),
-		messages:  []pendingMsg{{msg: []byte("test message")}},
-	})
+	old := newTestConn(mgr, 123)
+	old.conn = c1
 
-	err := conn.checkAndSendPendingMessages()
-	if err == nil {
-		t.Error("Expected error for closed connection, but got nil")
+	for i := 1; i <= 3; i++ {
+		if err := old.Push([]byte(strconv.Itoa(i))); err != nil {
+			t.Fatalf("push message failed: %v", err)
+		}
+	}
+
+	if err := old.forceClose(false, true); err != nil {
+		t.Fatalf("force close failed: %v", err)
+	}
+
+	conn := newTestConn(mgr, 0)
+	conn.Bind(123)
+
+	if err := conn.CheckAndSendPendingMessages(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		msg := <-conn.chWrite
+		if string(msg.msg) != strconv.Itoa(i) {
+			t.Fatalf("Expected message '%d', got '%s'", i, string(msg.msg))
+		}
+	}
+}
+
+func TestForceClose_WithoutReserve(t *testing.T) {
+	mgr := newTestConnMgr()
+
+	c1, c2 := net.Pipe()
+	defer c2.Close()
+
+	old := newTestConn(mgr, 123)
+	old.conn = c1
+	_ = old.Push([]byte("kicked"))
+
+	if err := old.forceClose(false, false); err != nil {
+		t.Fatalf("force close failed: %v", err)
+	}
+
+	if messages := mgr.takePendingMessages(123); len(messages) != 0 {
+		t.Errorf("Expected no pending messages, got %d", len(messages))
+	}
+}
+
+func TestSaveInflightMessage_AfterReuse(t *testing.T) {
+	mgr := newTestConnMgr()
+
+	c1, c2 := net.Pipe()
+	defer c2.Close()
+
+	conn := newTestConn(mgr, 123)
+	conn.conn = c1
+	closeCh, old := conn.close, conn.reservation
+
+	if err := conn.forceClose(false, true); err != nil {
+		t.Fatalf("force close failed: %v", err)
+	}
+
+	// 模拟连接被回收后重新初始化
+	conn.reservation = &reservation{}
+	atomic.StoreInt64(&conn.uid, 456)
+
+	conn.saveInflightMessage(closeCh, old, chWrite{typ: dataPacket, msg: []byte("sealed"), plain: []byte("inflight")})
+
+	if messages := mgr.takePendingMessages(123); len(messages) != 1 || string(messages[0].msg) != "inflight" {
+		t.Fatalf("Expected inflight message saved for original uid, got %v", messages)
+	}
+
+	if messages := mgr.takePendingMessages(456); len(messages) != 0 {
+		t.Fatalf("Expected no message saved for reused uid, got %d", len(messages))
+	}
+}
+
+// 协商一对会话加密器，分别用于服务端与客户端
+func negotiate(t *testing.T) (*ecdh.Cipher, *ecdh.Cipher) {
+	serverKey, err := ecdh.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientKey, err := ecdh.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server, err := serverKey.Negotiate(ecdh.Server, clientKey.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := clientKey.Negotiate(ecdh.Client, serverKey.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return server, client
+}
+
+func TestForceClose_ReplayWithHandshake(t *testing.T) {
+	mgr := newTestConnMgr()
+
+	c1, c2 := net.Pipe()
+	defer c2.Close()
+
+	oldServer, oldClient := negotiate(t)
+
+	old := newTestConn(mgr, 123)
+	old.conn = c1
+	old.SetCipher(oldServer)
+
+	for i := 1; i <= 3; i++ {
+		msg, err := packet.PackMessage(&packet.Message{Seq: int32(i), Route: 1, Buffer: []byte(strconv.Itoa(i))})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err = old.Push(msg); err != nil {
+			t.Fatalf("push message failed: %v", err)
+		}
+	}
+
+	// 断线前写入队列中的消息已使用原连接的会话密钥加密
+	sealed, err := packet.UnpackMessage((<-old.chWrite).msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = oldClient.Open(sealed.Seq, sealed.Buffer); err != nil {
+		t.Fatalf("open message with old cipher failed: %v", err)
+	}
+
+	if err = old.forceClose(false, true); err != nil {
+		t.Fatalf("force close failed: %v", err)
+	}
+
+	newServer, newClient := negotiate(t)
+
+	conn := newTestConn(mgr, 0)
+	conn.SetCipher(newServer)
+	conn.Bind(123)
+
+	if err = conn.CheckAndSendPendingMessages(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for i := 2; i <= 3; i++ {
+		msg, err := packet.UnpackMessage((<-conn.chWrite).msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = oldClient.Open(msg.Seq, msg.Buffer); err == nil {
+			t.Fatal("Expected replayed message not sealed with old cipher")
+		}
+
+		plaintext, err := newClient.Open(msg.Seq, msg.Buffer)
+		if err != nil {
+			t.Fatalf("open replayed message with new cipher failed: %v", err)
+		}
+
+		if msg.Seq != int32(i) || string(plaintext) != strconv.Itoa(i) {
+			t.Fatalf("Expected message '%d', got seq: %d buffer: '%s'", i, msg.Seq, plaintext)
+		}
 	}
 }
@@ -10,6 +10,8 @@ const (
 	defaultServerMaxConnNum         = 5000
 	defaultServerHeartbeatInterval  = "1s"
 	defaultServerHeartbeatMechanism = "resp"
+	defaultServerPendingMaxNum      = 1000
+	defaultServerPendingTimeout     = "3m"
 )
 
 const (
@@ -17,6 +19,8 @@ const (
 	defaultServerMaxConnNumKey         = "etc.network.tcp.server.maxConnNum"
 	defaultServerHeartbeatIntervalKey  = "etc.network.tcp.server.heartbeatInterval"
 	defaultServerHeartbeatMechanismKey = "etc.network.tcp.server.heartbeatMechanism"
+	defaultServerPendingMaxNumKey      = "etc.network.tcp.server.pendingMaxNum"
+	defaultServerPendingTimeoutKey     = "etc.network.tcp.server.pendingTimeout"
 )
 
 const (
@@ -33,6 +37,8 @@ type serverOptions struct {
 	maxConnNum         int                // 最大连接数，默认5000
 	heartbeatInterval  time.Duration      // 心跳检测间隔时间，默认1s
 	heartbeatMechanism HeartbeatMechanism // 心跳机制，默认resp
+	pendingMaxNum      int                // 断线后每个用户最多缓存的消息数，默认1000
+	pendingTimeout     time.Duration      // 断线消息缓存时间，默认3m，设置为0则不缓存
 }
 
 func defaultServerOptions() *serverOptions {
@@ -41,6 +47,8 @@ func defaultServerOptions() *serverOptions {
 		maxConnNum:         etc.Get(defaultServerMaxConnNumKey, defaultServerMaxConnNum).Int(),
 		heartbeatInterval:  etc.Get(defaultServerHeartbeatIntervalKey, defaultServerHeartbeatInterval).Duration(),
 		heartbeatMechanism: HeartbeatMechanism(etc.Get(defaultServerHeartbeatMechanismKey, defaultServerHeartbeatMechanism).String()),
+		pendingMaxNum:      etc.Get(defaultServerPendingMaxNumKey, defaultServerPendingMaxNum).Int(),
+		pendingTimeout:     etc.Get(defaultServerPendingTimeoutKey, defaultServerPendingTimeout).Duration(),
 	}
 }
 
@@ -63,3 +71,13 @@ func WithServerHeartbeatInterval(heartbeatInterval time.Duration) ServerOption {
 func WithServerHeartbeatMechanism(heartbeatMechanism HeartbeatMechanism) ServerOption {
 	return func(o *serverOptions) { o.heartbeatMechanism = heartbeatMechanism }
 }
+
+// WithServerPendingMaxNum 设置断线后每个用户最多缓存的消息数
+func WithServerPendingMaxNum(pendingMaxNum int) ServerOption {
+	return func(o *serverOptions) { o.pendingMaxNum = pendingMaxNum }
+}
+
+// WithServerPendingTimeout 设置断线消息缓存时间
+func WithServerPendingTimeout(pendingTimeout time.Duration) ServerOption {
+	return func(o *serverOptions) { o.pendingTimeout = pendingTimeout }
+}
@@ -0,0 +1,27 @@
+package ws
+
+import (
+	"gatesvr/network"
+	"gatesvr/packet"
+)
+
+const protocol = "ws"
+
+const (
+	closeSig   int = iota // 关闭信号
+	dataPacket            // 数据包
+)
+
+type chWrite struct {
+	typ int
+	msg []byte
+}
+
+// 使用会话加密器加密已打包的消息，握手未完成时原样返回
+func seal(cipher network.Cipher, msg []byte) ([]byte, error) {
+	if cipher == nil {
+		return msg, nil
+	}
+
+	return packet.SealMessage(msg, cipher.Seal)
+}
@@ -0,0 +1,189 @@
+package ws
+
+import (
+	"gatesvr/log"
+	"gatesvr/network"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+type server struct {
+	opts              *serverOptions            // 配置
+	listener          net.Listener              // 监听器
+	httpServer        *http.Server              // HTTP服务器
+	upgrader          *websocket.Upgrader       // 协议升级器
+	connMgr           *serverConnMgr            // 连接管理器
+	startHandler      network.StartHandler      // 服务器启动hook函数
+	stopHandler       network.CloseHandler      // 服务器关闭hook函数
+	connectHandler    network.ConnectHandler    // 连接打开hook函数
+	disconnectHandler network.DisconnectHandler // 连接关闭hook函数
+	receiveHandler    network.ReceiveHandler    // 接收消息hook函数
+	rw                sync.RWMutex              // 读写锁，保证暂停后不再有连接在分配中
+	paused            bool                      // 是否暂停接入新连接
+}
+
+var (
+	_ network.Server = &server{}
+	_ network.Pauser = &server{}
+)
+
+func NewServer(opts ...ServerOption) network.Server {
+	o := defaultServerOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	s := &server{}
+	s.opts = o
+	s.connMgr = newServerConnMgr(s)
+
+	return s
+}
+
+// Addr 监听地址
+func (s *server) Addr() string {
+	return s.opts.addr
+}
+
+// Start 启动服务器
+func (s *server) Start() error {
+	if err := s.init(); err != nil {
+		return err
+	}
+
+	if s.startHandler != nil {
+		s.startHandler()
+	}
+
+	go s.serve()
+
+	return nil
+}
+
+// Stop 关闭服务器
+func (s *server) Stop() error {
+	if err := s.httpServer.Close(); err != nil {
+		return err
+	}
+
+	s.connMgr.close()
+
+	if s.stopHandler != nil {
+		s.stopHandler()
+	}
+
+	return nil
+}
+
+// Protocol 协议
+func (s *server) Protocol() string {
+	return protocol
+}
+
+// OnStart 监听服务器启动
+func (s *server) OnStart(handler network.StartHandler) {
+	s.startHandler = handler
+}
+
+// OnStop 监听服务器关闭
+func (s *server) OnStop(handler network.CloseHandler) {
+	s.stopHandler = handler
+}
+
+// OnConnect 监听连接打开
+func (s *server) OnConnect(handler network.ConnectHandler) {
+	s.connectHandler = handler
+}
+
+// OnDisconnect 监听连接关闭
+func (s *server) OnDisconnect(handler network.DisconnectHandler) {
+	s.disconnectHandler = handler
+}
+
+// OnReceive 监听接收到消息
+func (s *server) OnReceive(handler network.ReceiveHandler) {
+	s.receiveHandler = handler
+}
+
+// 初始化Websocket服务器
+func (s *server) init() error {
+	addr, err := net.ResolveTCPAddr("tcp", s.opts.addr)
+	if err != nil {
+		return err
+	}
+
+	ln, err := net.ListenTCP(addr.Network(), addr)
+	if err != nil {
+		return err
+	}
+
+	s.listener = ln
+	s.upgrader = &websocket.Upgrader{
+		ReadBufferSize:   4096,
+		WriteBufferSize:  4096,
+		HandshakeTimeout: s.opts.handshakeTimeout,
+		CheckOrigin:      s.opts.checkOrigin,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(s.opts.path, s.handleUpgrade)
+	s.httpServer = &http.Server{Handler: mux}
+
+	return nil
+}
+
+// 等待连接
+func (s *server) serve() {
+	var err error
+
+	if s.opts.certFile != "" && s.opts.keyFile != "" {
+		err = s.httpServer.ServeTLS(s.listener, s.opts.certFile, s.opts.keyFile)
+	} else {
+		err = s.httpServer.Serve(s.listener)
+	}
+
+	if err != nil && err != http.ErrServerClosed {
+		log.Warnf("websocket serve error: %v", err)
+	}
+}
+
+// 处理协议升级
+func (s *server) handleUpgrade(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.rw.RLock()
+	defer s.rw.RUnlock()
+
+	if s.paused {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Warnf("websocket upgrade error: %v", err)
+		return
+	}
+
+	if s.opts.maxMsgLen > 0 {
+		conn.SetReadLimit(int64(s.opts.maxMsgLen))
+	}
+
+	if err = s.connMgr.allocate(conn); err != nil {
+		log.Errorf("connection allocate error: %v", err)
+		_ = conn.Close()
+	}
+}
+
+// Pause 暂停接入新连接，已建立的连接不受影响
+func (s *server) Pause() {
+	s.rw.Lock()
+	s.paused = true
+	s.rw.Unlock()
+}
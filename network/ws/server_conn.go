@@ -0,0 +1,417 @@
+package ws
+
+import (
+	"gatesvr/errors"
+	"gatesvr/log"
+	"gatesvr/network"
+	"gatesvr/packet"
+	"gatesvr/utils/xcall"
+	"gatesvr/utils/xnet"
+	"gatesvr/utils/xtime"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type serverConn struct {
+	id                int64           // 连接ID
+	uid               int64           // 用户ID
+	state             int32           // 连接状态
+	connMgr           *serverConnMgr  // 连接管理
+	rw                sync.RWMutex    // 读写锁
+	wmu               sync.Mutex      // 写入锁，websocket不支持并发写
+	conn              *websocket.Conn // WS源连接
+	chWrite           chan chWrite    // 写入队列
+	done              chan struct{}   // 写入完成信号
+	close             chan struct{}   // 关闭信号
+	lastHeartbeatTime int64           // 上次心跳时间
+	cipher            network.Cipher  // 会话加密器
+}
+
+var _ network.Conn = &serverConn{}
+
+// ID 获取连接ID
+func (c *serverConn) ID() int64 {
+	return c.id
+}
+
+// UID 获取用户ID
+func (c *serverConn) UID() int64 {
+	return atomic.LoadInt64(&c.uid)
+}
+
+// Bind 绑定用户ID
+func (c *serverConn) Bind(uid int64) {
+	atomic.StoreInt64(&c.uid, uid)
+}
+
+// Unbind 解绑用户ID
+func (c *serverConn) Unbind() {
+	atomic.StoreInt64(&c.uid, 0)
+}
+
+// Send 发送消息（同步）
+func (c *serverConn) Send(msg []byte) (err error) {
+	if err = c.checkState(); err != nil {
+		return
+	}
+
+	c.rw.RLock()
+	conn, cipher := c.conn, c.cipher
+	c.rw.RUnlock()
+
+	if conn == nil {
+		return errors.ErrConnectionClosed
+	}
+
+	if msg, err = seal(cipher, msg); err != nil {
+		return
+	}
+
+	return c.doWrite(conn, msg)
+}
+
+// Push 发送消息（异步）
+func (c *serverConn) Push(msg []byte) (err error) {
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	if err = c.checkState(); err != nil {
+		return
+	}
+
+	if msg, err = seal(c.cipher, msg); err != nil {
+		return
+	}
+
+	c.chWrite <- chWrite{typ: dataPacket, msg: msg}
+
+	return
+}
+
+// State 获取连接状态
+func (c *serverConn) State() network.ConnState {
+	return network.ConnState(atomic.LoadInt32(&c.state))
+}
+
+// Close 关闭连接
+func (c *serverConn) Close(force ...bool) error {
+	if len(force) > 0 && force[0] {
+		return c.forceClose(true)
+	} else {
+		return c.graceClose(true)
+	}
+}
+
+// LocalIP 获取本地IP
+func (c *serverConn) LocalIP() (string, error) {
+	addr, err := c.LocalAddr()
+	if err != nil {
+		return "", err
+	}
+
+	return xnet.ExtractIP(addr)
+}
+
+// LocalAddr 获取本地地址
+func (c *serverConn) LocalAddr() (net.Addr, error) {
+	if err := c.checkState(); err != nil {
+		return nil, err
+	}
+
+	c.rw.RLock()
+	conn := c.conn
+	c.rw.RUnlock()
+
+	if conn == nil {
+		return nil, errors.ErrConnectionClosed
+	}
+
+	return conn.LocalAddr(), nil
+}
+
+// RemoteIP 获取远端IP
+func (c *serverConn) RemoteIP() (string, error) {
+	addr, err := c.RemoteAddr()
+	if err != nil {
+		return "", err
+	}
+
+	return xnet.ExtractIP(addr)
+}
+
+// RemoteAddr 获取远端地址
+func (c *serverConn) RemoteAddr() (net.Addr, error) {
+	if err := c.checkState(); err != nil {
+		return nil, err
+	}
+
+	c.rw.RLock()
+	conn := c.conn
+	c.rw.RUnlock()
+
+	if conn == nil {
+		return nil, errors.ErrConnectionClosed
+	}
+
+	return conn.RemoteAddr(), nil
+}
+
+// SetCipher 设置会话加密器
+func (c *serverConn) SetCipher(cipher network.Cipher) {
+	c.rw.Lock()
+	c.cipher = cipher
+	c.rw.Unlock()
+}
+
+// Cipher 获取会话加密器
+func (c *serverConn) Cipher() network.Cipher {
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	return c.cipher
+}
+
+// CheckAndSendPendingMessages 检查并发送待传输消息，websocket连接不缓存离线消息
+func (c *serverConn) CheckAndSendPendingMessages() error {
+	return nil
+}
+
+// 检测连接状态
+func (c *serverConn) checkState() error {
+	switch network.ConnState(atomic.LoadInt32(&c.state)) {
+	case network.ConnHanged:
+		return errors.ErrConnectionHanged
+	case network.ConnClosed:
+		return errors.ErrConnectionClosed
+	default:
+		return nil
+	}
+}
+
+// 初始化连接
+func (c *serverConn) init(cm *serverConnMgr, id int64, conn *websocket.Conn) {
+	c.id = id
+	c.conn = conn
+	c.connMgr = cm
+	c.chWrite = make(chan chWrite, 4096)
+	c.done = make(chan struct{})
+	c.close = make(chan struct{})
+	c.lastHeartbeatTime = xtime.Now().UnixNano()
+	c.cipher = nil
+	atomic.StoreInt64(&c.uid, 0)
+	atomic.StoreInt32(&c.state, int32(network.ConnOpened))
+
+	xcall.Go(func() { c.read(conn) })
+
+	xcall.Go(func() { c.write(conn) })
+
+	if c.connMgr.server.connectHandler != nil {
+		c.connMgr.server.connectHandler(c)
+	}
+}
+
+// 优雅关闭
+func (c *serverConn) graceClose(isNeedRecycle bool) error {
+	if !atomic.CompareAndSwapInt32(&c.state, int32(network.ConnOpened), int32(network.ConnHanged)) {
+		return errors.ErrConnectionNotOpened
+	}
+
+	c.rw.RLock()
+	c.chWrite <- chWrite{typ: closeSig}
+	c.rw.RUnlock()
+
+	<-c.done
+
+	if !atomic.CompareAndSwapInt32(&c.state, int32(network.ConnHanged), int32(network.ConnClosed)) {
+		return errors.ErrConnectionNotHanged
+	}
+
+	c.rw.Lock()
+	close(c.chWrite)
+	close(c.close)
+	close(c.done)
+	conn := c.conn
+	c.conn = nil
+	c.rw.Unlock()
+
+	err := conn.Close()
+
+	if isNeedRecycle {
+		c.connMgr.recycle(conn)
+	}
+
+	if c.connMgr.server.disconnectHandler != nil {
+		c.connMgr.server.disconnectHandler(c)
+	}
+
+	return err
+}
+
+// 强制关闭
+func (c *serverConn) forceClose(isNeedRecycle bool) error {
+	if !atomic.CompareAndSwapInt32(&c.state, int32(network.ConnOpened), int32(network.ConnClosed)) {
+		if !atomic.CompareAndSwapInt32(&c.state, int32(network.ConnHanged), int32(network.ConnClosed)) {
+			return errors.ErrConnectionClosed
+		}
+	}
+
+	c.rw.Lock()
+	close(c.chWrite)
+	close(c.close)
+	close(c.done)
+	conn := c.conn
+	c.conn = nil
+	c.rw.Unlock()
+
+	err := conn.Close()
+
+	if isNeedRecycle {
+		c.connMgr.recycle(conn)
+	}
+
+	if c.connMgr.server.disconnectHandler != nil {
+		c.connMgr.server.disconnectHandler(c)
+	}
+
+	return err
+}
+
+// 读取消息，源连接由初始化时传入，避免与关闭时清空c.conn并发读写
+func (c *serverConn) read(conn *websocket.Conn) {
+	for {
+		select {
+		case <-c.close:
+			return
+		default:
+			msgType, msg, err := conn.ReadMessage()
+			if err != nil {
+				_ = c.forceClose(true)
+				return
+			}
+
+			if msgType != websocket.BinaryMessage {
+				continue
+			}
+
+			if c.connMgr.server.opts.heartbeatInterval > 0 {
+				atomic.StoreInt64(&c.lastHeartbeatTime, xtime.Now().UnixNano())
+			}
+
+			switch c.State() {
+			case network.ConnHanged:
+				continue
+			case network.ConnClosed:
+				return
+			default:
+				// ignore
+			}
+
+			isHeartbeat, err := packet.CheckHeartbeat(msg)
+			if err != nil {
+				log.Errorf("check heartbeat message error: %v", err)
+				continue
+			}
+
+			// ignore heartbeat packet
+			if isHeartbeat {
+				// responsive heartbeat
+				if c.connMgr.server.opts.heartbeatMechanism == RespHeartbeat {
+					if heartbeat, err := packet.PackHeartbeat(); err != nil {
+						log.Errorf("pack heartbeat message error: %v", err)
+					} else {
+						if err = c.doWrite(conn, heartbeat); err != nil {
+							log.Errorf("write heartbeat message error: %v", err)
+						}
+					}
+				}
+				continue
+			}
+
+			// ignore empty packet
+			if len(msg) == 0 {
+				continue
+			}
+
+			if c.connMgr.server.receiveHandler != nil {
+				c.connMgr.server.receiveHandler(c, msg)
+			}
+		}
+	}
+}
+
+// 写入消息
+func (c *serverConn) write(conn *websocket.Conn) {
+	var (
+		ticker *time.Ticker
+	)
+
+	if c.connMgr.server.opts.heartbeatInterval > 0 {
+		ticker = time.NewTicker(c.connMgr.server.opts.heartbeatInterval)
+		defer ticker.Stop()
+	} else {
+		ticker = &time.Ticker{C: make(chan time.Time, 1)}
+	}
+
+	for {
+		select {
+		case r, ok := <-c.chWrite:
+			if !ok {
+				return
+			}
+
+			if r.typ == closeSig {
+				c.rw.RLock()
+				c.done <- struct{}{}
+				c.rw.RUnlock()
+				return
+			}
+
+			if c.isClosed() {
+				return
+			}
+
+			if err := c.doWrite(conn, r.msg); err != nil {
+				log.Errorf("write data message error: %v", err)
+			}
+		case <-ticker.C:
+			deadline := xtime.Now().Add(-2 * c.connMgr.server.opts.heartbeatInterval).UnixNano()
+			if atomic.LoadInt64(&c.lastHeartbeatTime) < deadline {
+				log.Debugf("connection heartbeat timeout, cid: %d, uid: %d", c.id, c.UID())
+				_ = c.forceClose(true)
+				return
+			} else {
+				if c.connMgr.server.opts.heartbeatMechanism == TickHeartbeat {
+					if c.isClosed() {
+						return
+					}
+
+					if heartbeat, err := packet.PackHeartbeat(); err != nil {
+						log.Errorf("pack heartbeat message error: %v", err)
+					} else {
+						// send heartbeat packet
+						if err = c.doWrite(conn, heartbeat); err != nil {
+							log.Errorf("write heartbeat message error: %v", err)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// 写入二进制帧
+func (c *serverConn) doWrite(conn *websocket.Conn, msg []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	return conn.WriteMessage(websocket.BinaryMessage, msg)
+}
+
+// 是否已关闭
+func (c *serverConn) isClosed() bool {
+	return network.ConnState(atomic.LoadInt32(&c.state)) == network.ConnClosed
+}
@@ -0,0 +1,130 @@
+package ws
+
+import (
+	"gatesvr/errors"
+	"gatesvr/filter"
+	"gatesvr/log"
+	"gatesvr/metrics"
+	"gatesvr/utils/xcall"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/gorilla/websocket"
+)
+
+type serverConnMgr struct {
+	id         int64        // 连接ID
+	total      int64        // 总连接数
+	server     *server      // 服务器
+	pool       sync.Pool    // 连接池
+	partitions []*partition // 连接管理
+}
+
+func newServerConnMgr(server *server) *serverConnMgr {
+	cm := &serverConnMgr{}
+	cm.server = server
+	cm.pool = sync.Pool{New: func() interface{} { return &serverConn{} }}
+	cm.partitions = make([]*partition, 100)
+
+	for i := 0; i < len(cm.partitions); i++ {
+		cm.partitions[i] = &partition{connections: make(map[*websocket.Conn]*serverConn)}
+	}
+
+	return cm
+}
+
+// 关闭连接
+func (cm *serverConnMgr) close() {
+	var wg sync.WaitGroup
+
+	wg.Add(len(cm.partitions))
+
+	for i := range cm.partitions {
+		p := cm.partitions[i]
+
+		xcall.Go(func() {
+			p.close()
+			wg.Done()
+		})
+	}
+
+	wg.Wait()
+}
+
+// 分配连接
+func (cm *serverConnMgr) allocate(c *websocket.Conn) error {
+	if isBlack := filter.BlackListCheck(c.RemoteAddr()); isBlack {
+		log.Errorf("black list check failed, ip = %v", c.RemoteAddr())
+		metrics.NetworkRejections.Add(1, "ws", "blacklist")
+		return errors.ErrBlackUser
+	}
+	if atomic.LoadInt64(&cm.total) >= int64(cm.server.opts.maxConnNum) {
+		metrics.NetworkRejections.Add(1, "ws", "max_conn")
+		return errors.ErrTooManyConnection
+	}
+	if !filter.TryConnect(c.RemoteAddr()) {
+		metrics.NetworkRejections.Add(1, "ws", "max_conn_per_ip")
+		return errors.ErrTooManyConnection
+	}
+	id := atomic.AddInt64(&cm.id, 1)
+	conn := cm.pool.Get().(*serverConn)
+	// 先存储再初始化，初始化后读写协程即已启动，连接立即断开时回收需能找到连接以释放单IP连接数
+	cm.partitions[cm.index(c)].store(c, conn)
+	metrics.NetworkConnections.Set(float64(atomic.AddInt64(&cm.total, 1)), "ws")
+	conn.init(cm, id, c)
+
+	return nil
+}
+
+// 回收连接
+func (cm *serverConnMgr) recycle(c *websocket.Conn) {
+	if conn, ok := cm.partitions[cm.index(c)].delete(c); ok {
+		cm.pool.Put(conn)
+		metrics.NetworkConnections.Set(float64(atomic.AddInt64(&cm.total, -1)), "ws")
+		filter.Disconnect(c.RemoteAddr())
+	}
+}
+
+// 计算连接所在分片
+func (cm *serverConnMgr) index(c *websocket.Conn) int {
+	return int(uintptr(unsafe.Pointer(c)) % uintptr(len(cm.partitions)))
+}
+
+type partition struct {
+	rw          sync.RWMutex
+	connections map[*websocket.Conn]*serverConn
+}
+
+// 存储连接
+func (p *partition) store(c *websocket.Conn, conn *serverConn) {
+	p.rw.Lock()
+	p.connections[c] = conn
+	p.rw.Unlock()
+}
+
+// 删除连接
+func (p *partition) delete(c *websocket.Conn) (*serverConn, bool) {
+	p.rw.Lock()
+	conn, ok := p.connections[c]
+	if ok {
+		delete(p.connections, c)
+	}
+	p.rw.Unlock()
+
+	return conn, ok
+}
+
+// 关闭该分片内的所有连接
+func (p *partition) close() {
+	p.rw.RLock()
+	conns := make([]*serverConn, 0, len(p.connections))
+	for _, conn := range p.connections {
+		conns = append(conns, conn)
+	}
+	p.rw.RUnlock()
+
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+}
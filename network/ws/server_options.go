@@ -0,0 +1,138 @@
+package ws
+
+import (
+	"gatesvr/etc"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultServerAddr               = ":3553"
+	defaultServerPath               = "/"
+	defaultServerMaxMsgLen          = 1024 * 1024
+	defaultServerMaxConnNum         = 5000
+	defaultServerCheckOrigin        = "*"
+	defaultServerHandshakeTimeout   = "10s"
+	defaultServerHeartbeatInterval  = "10s"
+	defaultServerHeartbeatMechanism = "resp"
+)
+
+const (
+	defaultServerAddrKey               = "etc.network.ws.server.addr"
+	defaultServerPathKey               = "etc.network.ws.server.path"
+	defaultServerMaxMsgLenKey          = "etc.network.ws.server.maxMsgLen"
+	defaultServerMaxConnNumKey         = "etc.network.ws.server.maxConnNum"
+	defaultServerKeyFileKey            = "etc.network.ws.server.keyFile"
+	defaultServerCertFileKey           = "etc.network.ws.server.certFile"
+	defaultServerOriginsKey            = "etc.network.ws.server.origins"
+	defaultServerHandshakeTimeoutKey   = "etc.network.ws.server.handshakeTimeout"
+	defaultServerHeartbeatIntervalKey  = "etc.network.ws.server.heartbeatInterval"
+	defaultServerHeartbeatMechanismKey = "etc.network.ws.server.heartbeatMechanism"
+)
+
+const (
+	RespHeartbeat HeartbeatMechanism = "resp" // 响应式心跳
+	TickHeartbeat HeartbeatMechanism = "tick" // 主动定时心跳
+)
+
+type HeartbeatMechanism string
+
+type CheckOriginFunc func(r *http.Request) bool
+
+type ServerOption func(o *serverOptions)
+
+type serverOptions struct {
+	addr               string             // 监听地址，默认0.0.0.0:3553
+	path               string             // 升级路径，默认/
+	maxMsgLen          int                // 最大消息长度（字节），默认1mb
+	maxConnNum         int                // 最大连接数，默认5000
+	keyFile            string             // 秘钥文件
+	certFile           string             // 证书文件
+	checkOrigin        CheckOriginFunc    // 跨域检测
+	handshakeTimeout   time.Duration      // 握手超时时间，默认10s
+	heartbeatInterval  time.Duration      // 心跳检测间隔时间，默认10s
+	heartbeatMechanism HeartbeatMechanism // 心跳机制，默认resp
+}
+
+func defaultServerOptions() *serverOptions {
+	origins := etc.Get(defaultServerOriginsKey, []string{defaultServerCheckOrigin}).Strings()
+
+	return &serverOptions{
+		addr:               etc.Get(defaultServerAddrKey, defaultServerAddr).String(),
+		path:               etc.Get(defaultServerPathKey, defaultServerPath).String(),
+		maxMsgLen:          etc.Get(defaultServerMaxMsgLenKey, defaultServerMaxMsgLen).Int(),
+		maxConnNum:         etc.Get(defaultServerMaxConnNumKey, defaultServerMaxConnNum).Int(),
+		keyFile:            etc.Get(defaultServerKeyFileKey).String(),
+		certFile:           etc.Get(defaultServerCertFileKey).String(),
+		checkOrigin:        newOriginChecker(origins),
+		handshakeTimeout:   etc.Get(defaultServerHandshakeTimeoutKey, defaultServerHandshakeTimeout).Duration(),
+		heartbeatInterval:  etc.Get(defaultServerHeartbeatIntervalKey, defaultServerHeartbeatInterval).Duration(),
+		heartbeatMechanism: HeartbeatMechanism(etc.Get(defaultServerHeartbeatMechanismKey, defaultServerHeartbeatMechanism).String()),
+	}
+}
+
+// WithServerListenAddr 设置监听地址
+func WithServerListenAddr(addr string) ServerOption {
+	return func(o *serverOptions) { o.addr = addr }
+}
+
+// WithServerPath 设置升级路径
+func WithServerPath(path string) ServerOption {
+	return func(o *serverOptions) { o.path = path }
+}
+
+// WithServerMaxMsgLen 设置消息最大长度
+func WithServerMaxMsgLen(maxMsgLen int) ServerOption {
+	return func(o *serverOptions) { o.maxMsgLen = maxMsgLen }
+}
+
+// WithServerMaxConnNum 设置连接的最大连接数
+func WithServerMaxConnNum(maxConnNum int) ServerOption {
+	return func(o *serverOptions) { o.maxConnNum = maxConnNum }
+}
+
+// WithServerCredentials 设置证书和秘钥
+func WithServerCredentials(certFile, keyFile string) ServerOption {
+	return func(o *serverOptions) { o.keyFile, o.certFile = keyFile, certFile }
+}
+
+// WithServerCheckOrigin 设置Websocket跨域检测函数
+func WithServerCheckOrigin(checkOrigin CheckOriginFunc) ServerOption {
+	return func(o *serverOptions) { o.checkOrigin = checkOrigin }
+}
+
+// WithServerHandshakeTimeout 设置握手超时时间
+func WithServerHandshakeTimeout(handshakeTimeout time.Duration) ServerOption {
+	return func(o *serverOptions) { o.handshakeTimeout = handshakeTimeout }
+}
+
+// WithServerHeartbeatInterval 设置心跳检测间隔时间
+func WithServerHeartbeatInterval(heartbeatInterval time.Duration) ServerOption {
+	return func(o *serverOptions) { o.heartbeatInterval = heartbeatInterval }
+}
+
+// WithServerHeartbeatMechanism 设置心跳机制
+func WithServerHeartbeatMechanism(heartbeatMechanism HeartbeatMechanism) ServerOption {
+	return func(o *serverOptions) { o.heartbeatMechanism = heartbeatMechanism }
+}
+
+// 根据允许的来源列表生成跨域检测函数，未携带Origin头的请求来自非浏览器客户端，不做跨域检测
+func newOriginChecker(origins []string) CheckOriginFunc {
+	allowed := make(map[string]struct{}, len(origins))
+	for _, origin := range origins {
+		if origin == defaultServerCheckOrigin {
+			return func(r *http.Request) bool { return true }
+		}
+		allowed[origin] = struct{}{}
+	}
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+
+		_, ok := allowed[origin]
+		return ok
+	}
+}
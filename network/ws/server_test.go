@@ -0,0 +1,125 @@
+package ws_test
+
+import (
+	"gatesvr/filter"
+	"gatesvr/network"
+	"gatesvr/network/ws"
+	"gatesvr/packet"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestServer_Echo(t *testing.T) {
+	server := ws.NewServer(
+		ws.WithServerListenAddr("127.0.0.1:3573"),
+		ws.WithServerPath("/ws"),
+	)
+
+	server.OnReceive(func(conn network.Conn, msg []byte) {
+		if err := conn.Push(msg); err != nil {
+			t.Errorf("push message failed: %v", err)
+		}
+	})
+
+	if err := server.Start(); err != nil {
+		t.Fatalf("start server failed: %v", err)
+	}
+	defer server.Stop()
+
+	chMsg := make(chan []byte, 1)
+
+	client := ws.NewClient(ws.WithClientDialUrl("ws://127.0.0.1:3573/ws"))
+
+	client.OnReceive(func(conn network.Conn, msg []byte) {
+		chMsg <- msg
+	})
+
+	conn, err := client.Dial()
+	if err != nil {
+		t.Fatalf("client dial failed: %v", err)
+	}
+	defer conn.Close(true)
+
+	msg, err := packet.PackMessage(&packet.Message{
+		Route:  1,
+		Buffer: []byte("hello server~~"),
+	})
+	if err != nil {
+		t.Fatalf("pack message failed: %v", err)
+	}
+
+	if err = conn.Push(msg); err != nil {
+		t.Fatalf("push message failed: %v", err)
+	}
+
+	select {
+	case data := <-chMsg:
+		message, err := packet.UnpackMessage(data)
+		if err != nil {
+			t.Fatalf("unpack message failed: %v", err)
+		}
+
+		if string(message.Buffer) != "hello server~~" {
+			t.Fatalf("unexpected message: %s", string(message.Buffer))
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("receive message timeout")
+	}
+}
+
+func TestServer_CheckOrigin(t *testing.T) {
+	server := ws.NewServer(
+		ws.WithServerListenAddr("127.0.0.1:3574"),
+		ws.WithServerCheckOrigin(func(r *http.Request) bool {
+			return r.Header.Get("Origin") == "https://game.example.com"
+		}),
+	)
+
+	if err := server.Start(); err != nil {
+		t.Fatalf("start server failed: %v", err)
+	}
+	defer server.Stop()
+
+	header := http.Header{}
+	header.Set("Origin", "https://evil.example.com")
+
+	if _, _, err := websocket.DefaultDialer.Dial("ws://127.0.0.1:3574/", header); err == nil {
+		t.Fatal("expected upgrade to be rejected")
+	}
+}
+
+func TestServer_CloseImmediately(t *testing.T) {
+	f := filter.NewFilter()
+	filter.SetFilter(f)
+
+	server := ws.NewServer(ws.WithServerListenAddr("127.0.0.1:3575"))
+
+	// 连接回调耗时较长时，读协程已感知到断开并回收连接
+	server.OnConnect(func(conn network.Conn) {
+		time.Sleep(10 * time.Millisecond)
+	})
+
+	if err := server.Start(); err != nil {
+		t.Fatalf("start server failed: %v", err)
+	}
+	defer server.Stop()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws://127.0.0.1:3575/", nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+
+	// 对端在接入后立即断开
+	_ = conn.Close()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for f.ConnCount("127.0.0.1") != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("per-ip connection count leaked: %d", f.ConnCount("127.0.0.1"))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -1,12 +1,20 @@
 package packet
 
 type Message struct {
-	Seq        int32  // 序列号
-	Route      int32  // 路由ID
-	IsCritical bool   // 是否关键消息
-	Buffer     []byte // 消息内容
+	Seq          int32  // 序列号
+	Route        int32  // 路由ID
+	IsCritical   bool   // 是否关键消息
+	IsCompressed bool   // 消息内容是否已压缩
+	Buffer       []byte // 消息内容
 }
 type Notification struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
 }
+
+// Migration 网关迁移通知，客户端收到后应重连至Addrs中的网关
+type Migration struct {
+	Code    int      `json:"code"`
+	Message string   `json:"message"`
+	Addrs   []string `json:"addrs"`
+}
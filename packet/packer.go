@@ -17,6 +17,7 @@ const (
 	heartbeatBit  = 1 << 7
 	criticalBit   = 1 << 7 // 关键包标识
 	uncriticalBit = 0 << 7 // 普通标识
+	compressedBit = 1 << 6 // 压缩标识
 )
 
 type NocopyReader interface {
@@ -42,6 +43,8 @@ type Packer interface {
 	PackMessage(message *Message) ([]byte, error)
 	// UnpackMessage 解包消息
 	UnpackMessage(data []byte) (*Message, error)
+	// SealMessage 加密已打包消息的消息体
+	SealMessage(data []byte, seal func(seq int32, buffer []byte) ([]byte, error)) ([]byte, error)
 	// PackHeartbeat 打包心跳
 	PackHeartbeat() ([]byte, error)
 	// CheckHeartbeat 检测心跳包
@@ -213,11 +216,7 @@ func (p *defaultPacker) PackMessage(message *Message) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	if message.IsCritical {
-		err = binary.Write(buf, p.opts.byteOrder, uint8(criticalBit))
-	} else {
-		err = binary.Write(buf, p.opts.byteOrder, uint8(uncriticalBit))
-	}
+	err = binary.Write(buf, p.opts.byteOrder, flag(message))
 
 	switch p.opts.routeBytes {
 	case 1:
@@ -284,11 +283,7 @@ func (p *defaultPacker) PackBuffer(message *Message) (buffer.Buffer, error) {
 	writer.WriteInt32s(p.opts.byteOrder, int32(size))
 	writer.WriteInt8s(int8(dataBit))
 
-	if message.IsCritical {
-		writer.WriteUint8s(criticalBit)
-	} else {
-		writer.WriteUint8s(uncriticalBit)
-	}
+	writer.WriteUint8s(flag(message))
 	switch p.opts.routeBytes {
 	case 1:
 		writer.WriteInt8s(int8(message.Route))
@@ -317,11 +312,11 @@ func (p *defaultPacker) PackBuffer(message *Message) (buffer.Buffer, error) {
 // UnpackMessage 解包消息
 func (p *defaultPacker) UnpackMessage(data []byte) (*Message, error) {
 	var (
-		ln           = defaultSizeBytes + defaultHeaderBytes + defaultFlagBytes + p.opts.routeBytes + p.opts.seqBytes
-		reader       = bytes.NewReader(data)
-		size         uint32
-		header       uint8
-		criticalFlag uint8
+		ln     = defaultSizeBytes + defaultHeaderBytes + defaultFlagBytes + p.opts.routeBytes + p.opts.seqBytes
+		reader = bytes.NewReader(data)
+		size   uint32
+		header uint8
+		flags  uint8
 	)
 
 	if len(data)-ln < 0 {
@@ -346,14 +341,15 @@ func (p *defaultPacker) UnpackMessage(data []byte) (*Message, error) {
 		return nil, errors.ErrInvalidMessage
 	}
 
-	// 读取关键包标识
-	err = binary.Read(reader, p.opts.byteOrder, &criticalFlag)
+	// 读取消息标识
+	err = binary.Read(reader, p.opts.byteOrder, &flags)
 	if err != nil {
 		return nil, err
 	}
 
 	message := &Message{}
-	message.IsCritical = criticalFlag == criticalBit
+	message.IsCritical = flags&criticalBit == criticalBit
+	message.IsCompressed = flags&compressedBit == compressedBit
 
 	switch p.opts.routeBytes {
 	case 1:
@@ -418,6 +414,52 @@ func (p *defaultPacker) UnpackMessage(data []byte) (*Message, error) {
 	return message, nil
 }
 
+// SealMessage 加密已打包消息的消息体，seal以消息中的序列号及原消息体生成新的消息体
+// 仅替换消息体并修正消息长度，路由、序列号及标识原样保留，无需解包后重新打包
+func (p *defaultPacker) SealMessage(data []byte, seal func(seq int32, buffer []byte) ([]byte, error)) ([]byte, error) {
+	ln := defaultSizeBytes + defaultHeaderBytes + defaultFlagBytes + p.opts.routeBytes + p.opts.seqBytes
+
+	if len(data) < ln+defaultMagicBytes || uint64(len(data))-defaultSizeBytes != uint64(p.opts.byteOrder.Uint32(data)) {
+		return nil, errors.ErrInvalidMessage
+	}
+
+	if data[defaultSizeBytes]&heartbeatBit == heartbeatBit || p.opts.byteOrder.Uint16(data[len(data)-defaultMagicBytes:]) != 0x7e {
+		return nil, errors.ErrInvalidMessage
+	}
+
+	var seq int32
+	switch offset := ln - p.opts.seqBytes; p.opts.seqBytes {
+	case 1:
+		seq = int32(int8(data[offset]))
+	case 2:
+		seq = int32(int16(p.opts.byteOrder.Uint16(data[offset:])))
+	case 4:
+		seq = int32(p.opts.byteOrder.Uint32(data[offset:]))
+	}
+
+	buffer, err := seal(seq, data[ln:len(data)-defaultMagicBytes])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(buffer) > p.opts.bufferBytes {
+		return nil, errors.ErrMessageTooLarge
+	}
+
+	msg := make([]byte, ln+len(buffer)+defaultMagicBytes)
+	copy(msg, data[:ln])
+	copy(msg[ln:], buffer)
+	copy(msg[ln+len(buffer):], data[len(data)-defaultMagicBytes:])
+	p.opts.byteOrder.PutUint32(msg, uint32(len(msg)-defaultSizeBytes))
+
+	return msg, nil
+}
+
+// BufferBytes 获取消息体的最大字节数
+func (p *defaultPacker) BufferBytes() int {
+	return p.opts.bufferBytes
+}
+
 // PackHeartbeat 打包心跳
 func (p *defaultPacker) PackHeartbeat() ([]byte, error) {
 	if !p.opts.heartbeatTime {
@@ -477,3 +519,18 @@ func (p *defaultPacker) CheckHeartbeat(data []byte) (bool, error) {
 
 	return header&heartbeatBit == heartbeatBit, nil
 }
+
+// 生成消息标识
+func flag(message *Message) uint8 {
+	f := uint8(uncriticalBit)
+
+	if message.IsCritical {
+		f |= criticalBit
+	}
+
+	if message.IsCompressed {
+		f |= compressedBit
+	}
+
+	return f
+}
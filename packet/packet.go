@@ -38,6 +38,11 @@ func UnpackMessage(data []byte) (*Message, error) {
 	return globalPacker.UnpackMessage(data)
 }
 
+// SealMessage 加密已打包消息的消息体
+func SealMessage(data []byte, seal func(seq int32, buffer []byte) ([]byte, error)) ([]byte, error) {
+	return globalPacker.SealMessage(data, seal)
+}
+
 // PackHeartbeat 打包心跳
 func PackHeartbeat() ([]byte, error) {
 	return globalPacker.PackHeartbeat()
@@ -47,3 +52,12 @@ func PackHeartbeat() ([]byte, error) {
 func CheckHeartbeat(data []byte) (bool, error) {
 	return globalPacker.CheckHeartbeat(data)
 }
+
+// BufferBytes 获取打包器允许的消息体最大字节数，打包器未提供时返回0
+func BufferBytes() int {
+	if p, ok := globalPacker.(interface{ BufferBytes() int }); ok {
+		return p.BufferBytes()
+	}
+
+	return 0
+}
@@ -225,3 +225,79 @@ func BenchmarkDefaultPacker_UnpackMessage(b *testing.B) {
 		}
 	}
 }
+
+func TestCompressedMessagePackUnpack(t *testing.T) {
+	messages := []*packet.Message{
+		{Seq: 1, Route: 1, Buffer: []byte("compressed data"), IsCompressed: true},
+		{Seq: 2, Route: 2, Buffer: []byte("critical compressed data"), IsCritical: true, IsCompressed: true},
+		{Seq: 3, Route: 3, Buffer: []byte("plain data")},
+	}
+
+	for _, msg := range messages {
+		data, err := packer.PackMessage(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		unpackedMsg, err := packer.UnpackMessage(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if unpackedMsg.IsCompressed != msg.IsCompressed || unpackedMsg.IsCritical != msg.IsCritical || !bytes.Equal(unpackedMsg.Buffer, msg.Buffer) {
+			t.Fatalf("unpacked message does not match original, seq: %d", msg.Seq)
+		}
+
+		buf, err := packer.PackBuffer(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(buf.Bytes(), data) {
+			t.Fatalf("PackBuffer and PackMessage mismatch, seq: %d", msg.Seq)
+		}
+		buf.Release()
+	}
+}
+
+func TestSealMessage(t *testing.T) {
+	msg := &packet.Message{Seq: 5, Route: 3, Buffer: []byte("plain data"), IsCritical: true, IsCompressed: true}
+
+	data, err := packer.PackMessage(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := packer.SealMessage(data, func(seq int32, buffer []byte) ([]byte, error) {
+		if seq != msg.Seq {
+			t.Fatalf("seal seq mismatch, want: %d got: %d", msg.Seq, seq)
+		}
+
+		return append([]byte("sealed:"), buffer...), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unpackedMsg, err := packer.UnpackMessage(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if unpackedMsg.Seq != msg.Seq || unpackedMsg.Route != msg.Route || unpackedMsg.IsCritical != msg.IsCritical || unpackedMsg.IsCompressed != msg.IsCompressed {
+		t.Fatalf("sealed message header does not match original")
+	}
+
+	if string(unpackedMsg.Buffer) != "sealed:plain data" {
+		t.Fatalf("sealed message buffer mismatch: %s", unpackedMsg.Buffer)
+	}
+
+	heartbeat, err := packer.PackHeartbeat()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = packer.SealMessage(heartbeat, func(seq int32, buffer []byte) ([]byte, error) { return buffer, nil }); err == nil {
+		t.Fatal("seal heartbeat should fail")
+	}
+}
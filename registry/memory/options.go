@@ -0,0 +1,24 @@
+package memory
+
+import (
+	"context"
+)
+
+type Option func(o *options)
+
+type options struct {
+	// 上下文
+	// 默认context.Background
+	ctx context.Context
+}
+
+func defaultOptions() *options {
+	return &options{
+		ctx: context.Background(),
+	}
+}
+
+// WithContext 设置上下文
+func WithContext(ctx context.Context) Option {
+	return func(o *options) { o.ctx = ctx }
+}
@@ -0,0 +1,202 @@
+package memory
+
+import (
+	"context"
+	"gatesvr/registry"
+	"sort"
+	"sync"
+)
+
+const name = "memory"
+
+var _ registry.Registry = &Registry{}
+
+// Registry 内存服务注册发现，服务实例保存在进程内，适用于单进程部署及测试
+type Registry struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	opts     *options
+	rw       sync.RWMutex
+	idx      int64
+	services map[string]map[string]*registry.ServiceInstance // 服务实例（服务名 -> 实例ID -> 实例）
+	watchers map[string]map[int64]*watcher                   // 监听器（服务名 -> 监听器ID -> 监听器）
+}
+
+func NewRegistry(opts ...Option) *Registry {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	r := &Registry{}
+	r.opts = o
+	r.ctx, r.cancel = context.WithCancel(o.ctx)
+	r.services = make(map[string]map[string]*registry.ServiceInstance)
+	r.watchers = make(map[string]map[int64]*watcher)
+
+	return r
+}
+
+// Name 获取服务注册发现组件名
+func (r *Registry) Name() string {
+	return name
+}
+
+// Register 注册服务实例
+func (r *Registry) Register(ctx context.Context, ins *registry.ServiceInstance) error {
+	if err := r.check(ctx); err != nil {
+		return err
+	}
+
+	r.rw.Lock()
+	defer r.rw.Unlock()
+
+	instances, ok := r.services[ins.Name]
+	if !ok {
+		instances = make(map[string]*registry.ServiceInstance)
+		r.services[ins.Name] = instances
+	}
+
+	instances[ins.ID] = clone(ins)
+
+	r.broadcast(ins.Name)
+
+	return nil
+}
+
+// Deregister 解注册服务实例
+func (r *Registry) Deregister(ctx context.Context, ins *registry.ServiceInstance) error {
+	if err := r.check(ctx); err != nil {
+		return err
+	}
+
+	r.rw.Lock()
+	defer r.rw.Unlock()
+
+	instances, ok := r.services[ins.Name]
+	if !ok {
+		return nil
+	}
+
+	if _, ok = instances[ins.ID]; !ok {
+		return nil
+	}
+
+	delete(instances, ins.ID)
+
+	if len(instances) == 0 {
+		delete(r.services, ins.Name)
+	}
+
+	r.broadcast(ins.Name)
+
+	return nil
+}
+
+// Watch 监听相同服务名的服务实例变化
+func (r *Registry) Watch(ctx context.Context, serviceName string) (registry.Watcher, error) {
+	if err := r.check(ctx); err != nil {
+		return nil, err
+	}
+
+	r.rw.Lock()
+	defer r.rw.Unlock()
+
+	r.idx++
+
+	w := newWatcher(r, serviceName, r.idx)
+
+	watchers, ok := r.watchers[serviceName]
+	if !ok {
+		watchers = make(map[int64]*watcher)
+		r.watchers[serviceName] = watchers
+	}
+
+	watchers[w.idx] = w
+
+	return w, nil
+}
+
+// Services 获取服务实例列表
+func (r *Registry) Services(ctx context.Context, serviceName string) ([]*registry.ServiceInstance, error) {
+	if err := r.check(ctx); err != nil {
+		return nil, err
+	}
+
+	r.rw.RLock()
+	defer r.rw.RUnlock()
+
+	return r.snapshot(serviceName), nil
+}
+
+// Close 关闭服务注册发现
+func (r *Registry) Close() error {
+	r.cancel()
+
+	return nil
+}
+
+// 检测上下文状态
+func (r *Registry) check(ctx context.Context) error {
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+
+	return ctx.Err()
+}
+
+// 回收监听器
+func (r *Registry) recycle(serviceName string, idx int64) {
+	r.rw.Lock()
+	defer r.rw.Unlock()
+
+	if watchers, ok := r.watchers[serviceName]; ok {
+		delete(watchers, idx)
+
+		if len(watchers) == 0 {
+			delete(r.watchers, serviceName)
+		}
+	}
+}
+
+// 通知监听器服务实例变化，调用方需持有写锁
+func (r *Registry) broadcast(serviceName string) {
+	watchers, ok := r.watchers[serviceName]
+	if !ok {
+		return
+	}
+
+	for _, w := range watchers {
+		w.notify(r.snapshot(serviceName))
+	}
+}
+
+// 获取服务实例快照，调用方需持有锁
+func (r *Registry) snapshot(serviceName string) []*registry.ServiceInstance {
+	instances := r.services[serviceName]
+	services := make([]*registry.ServiceInstance, 0, len(instances))
+	for _, ins := range instances {
+		services = append(services, clone(ins))
+	}
+
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].ID < services[j].ID
+	})
+
+	return services
+}
+
+// 复制服务实例，避免调用方修改已注册的实例
+func clone(ins *registry.ServiceInstance) *registry.ServiceInstance {
+	c := *ins
+	c.Events = append([]int(nil), ins.Events...)
+	c.Routes = append([]registry.Route(nil), ins.Routes...)
+	c.Services = append([]string(nil), ins.Services...)
+
+	if ins.Load != nil {
+		load := *ins.Load
+		c.Load = &load
+	}
+
+	return &c
+}
@@ -0,0 +1,132 @@
+package memory_test
+
+import (
+	"context"
+	"gatesvr/cluster"
+	"gatesvr/registry"
+	"gatesvr/registry/memory"
+	"testing"
+	"time"
+)
+
+const serviceName = "node"
+
+func TestRegistry_Services(t *testing.T) {
+	ctx := context.Background()
+	reg := memory.NewRegistry()
+
+	ins := &registry.ServiceInstance{
+		ID:     "node-1",
+		Name:   serviceName,
+		Kind:   cluster.Node.String(),
+		State:  cluster.Work.String(),
+		Routes: []registry.Route{{ID: 1}},
+	}
+
+	if err := reg.Register(ctx, ins); err != nil {
+		t.Fatal(err)
+	}
+
+	// 修改调用方持有的实例不影响已注册的实例
+	ins.State = cluster.Busy.String()
+	ins.Routes[0].ID = 2
+
+	services, err := reg.Services(ctx, serviceName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(services) != 1 || services[0].State != cluster.Work.String() || services[0].Routes[0].ID != 1 {
+		t.Fatalf("unexpected services: %+v", services)
+	}
+
+	if err = reg.Deregister(ctx, ins); err != nil {
+		t.Fatal(err)
+	}
+
+	if services, _ = reg.Services(ctx, serviceName); len(services) != 0 {
+		t.Fatalf("services = %d, want 0", len(services))
+	}
+}
+
+func TestRegistry_Watch(t *testing.T) {
+	ctx := context.Background()
+	reg := memory.NewRegistry()
+
+	ins1 := &registry.ServiceInstance{ID: "node-1", Name: serviceName, Kind: cluster.Node.String()}
+	ins2 := &registry.ServiceInstance{ID: "node-2", Name: serviceName, Kind: cluster.Node.String()}
+
+	if err := reg.Register(ctx, ins1); err != nil {
+		t.Fatal(err)
+	}
+
+	watcher, err := reg.Watch(ctx, serviceName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Stop()
+
+	services := next(t, watcher)
+	if len(services) != 1 || services[0].ID != ins1.ID {
+		t.Fatalf("unexpected initial services: %+v", services)
+	}
+
+	if err = reg.Register(ctx, ins2); err != nil {
+		t.Fatal(err)
+	}
+
+	if services = next(t, watcher); len(services) != 2 {
+		t.Fatalf("services = %d, want 2", len(services))
+	}
+
+	if err = reg.Deregister(ctx, ins1); err != nil {
+		t.Fatal(err)
+	}
+
+	if services = next(t, watcher); len(services) != 1 || services[0].ID != ins2.ID {
+		t.Fatalf("unexpected services: %+v", services)
+	}
+}
+
+func TestRegistry_Close(t *testing.T) {
+	reg := memory.NewRegistry()
+
+	watcher, err := reg.Watch(context.Background(), serviceName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next(t, watcher)
+
+	_ = reg.Close()
+
+	if _, err = watcher.Next(); err == nil {
+		t.Fatal("expected error after registry closed")
+	}
+
+	if err = reg.Register(context.Background(), &registry.ServiceInstance{ID: "node-1", Name: serviceName}); err == nil {
+		t.Fatal("expected error after registry closed")
+	}
+}
+
+func next(t *testing.T, watcher registry.Watcher) []*registry.ServiceInstance {
+	t.Helper()
+
+	ch := make(chan []*registry.ServiceInstance, 1)
+
+	go func() {
+		services, err := watcher.Next()
+		if err != nil {
+			t.Error(err)
+		}
+		ch <- services
+	}()
+
+	select {
+	case services := <-ch:
+		return services
+	case <-time.After(time.Second):
+		t.Fatal("watch timeout")
+		return nil
+	}
+}
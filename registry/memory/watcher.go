@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"gatesvr/registry"
+	"sync"
+)
+
+type watcher struct {
+	idx         int64
+	registry    *Registry
+	serviceName string
+	ctx         context.Context
+	cancel      context.CancelFunc
+	mu          sync.Mutex
+	started     bool
+	changed     bool
+	latest      []*registry.ServiceInstance
+	chNotify    chan struct{}
+}
+
+func newWatcher(r *Registry, serviceName string, idx int64) *watcher {
+	w := &watcher{}
+	w.ctx, w.cancel = context.WithCancel(r.ctx)
+	w.idx = idx
+	w.registry = r
+	w.serviceName = serviceName
+	w.chNotify = make(chan struct{}, 1)
+
+	return w
+}
+
+// 通知服务实例变化，仅保留最新的服务实例列表
+func (w *watcher) notify(services []*registry.ServiceInstance) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.started {
+		return
+	}
+
+	w.latest = services
+	w.changed = true
+
+	select {
+	case w.chNotify <- struct{}{}:
+	default:
+	}
+}
+
+// Next 返回服务实例列表
+func (w *watcher) Next() ([]*registry.ServiceInstance, error) {
+	w.mu.Lock()
+	if !w.started {
+		w.started = true
+		w.mu.Unlock()
+
+		return w.registry.Services(w.ctx, w.serviceName)
+	}
+	w.mu.Unlock()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return nil, w.ctx.Err()
+		case <-w.chNotify:
+			w.mu.Lock()
+			services, changed := w.latest, w.changed
+			w.latest, w.changed = nil, false
+			w.mu.Unlock()
+
+			// 变化可能已被上一次调用取走
+			if changed {
+				return services, nil
+			}
+		}
+	}
+}
+
+// Stop 停止监听
+func (w *watcher) Stop() error {
+	w.cancel()
+	w.registry.recycle(w.serviceName, w.idx)
+
+	return nil
+}
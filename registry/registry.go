@@ -50,8 +50,21 @@ type ServiceInstance struct {
 	Services []string `json:"services,omitempty"`
 	// 微服务实体暴露端口
 	Endpoint string `json:"endpoint,omitempty"`
+	// 客户端接入地址，仅网关实例上报
+	Address string `json:"address,omitempty"`
 	// 微服务路由加权轮询权重
 	Weight int `json:"weight,omitempty"`
+	// 服务实例负载，由实例定期上报
+	Load *Load `json:"load,omitempty"`
+}
+
+type Load struct {
+	// Actor数量
+	Actors int `json:"a,omitempty"`
+	// 待处理请求数
+	Pending int `json:"p,omitempty"`
+	// 请求处理平均耗时（微秒）
+	Latency int64 `json:"l,omitempty"`
 }
 
 type Route struct {
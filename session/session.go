@@ -2,7 +2,9 @@ package session
 
 import (
 	"gatesvr/errors"
+	"gatesvr/log"
 	"gatesvr/network"
+	"math"
 	"net"
 	"sync"
 )
@@ -14,6 +16,9 @@ const (
 
 type Kind int
 
+// Checker 发送检查器，发送前按连接检查是否允许发送，如连接握手是否已完成
+type Checker func(conn network.Conn) error
+
 func (k Kind) String() string {
 	switch k {
 	case Conn:
@@ -26,18 +31,30 @@ func (k Kind) String() string {
 }
 
 type Session struct {
-	rw    sync.RWMutex           // 读写锁
-	conns map[int64]network.Conn // 连接会话（连接ID -> network.Conn）
-	users map[int64]network.Conn // 用户会话（用户ID -> network.Conn）
+	rw     sync.RWMutex                  // 读写锁
+	conns  map[int64]network.Conn        // 连接会话（连接ID -> network.Conn）
+	users  map[int64]network.Conn        // 用户会话（用户ID -> network.Conn）
+	groups map[string]map[int64]struct{} // 分组（分组名 -> 连接ID）
+	joined map[int64]map[string]struct{} // 连接加入的分组（连接ID -> 分组名）
+	check  Checker                       // 发送检查器
 }
 
 func NewSession() *Session {
 	return &Session{
-		conns: make(map[int64]network.Conn),
-		users: make(map[int64]network.Conn),
+		conns:  make(map[int64]network.Conn),
+		users:  make(map[int64]network.Conn),
+		groups: make(map[string]map[int64]struct{}),
+		joined: make(map[int64]map[string]struct{}),
 	}
 }
 
+// SetChecker 设置发送检查器
+func (s *Session) SetChecker(checker Checker) {
+	s.rw.Lock()
+	s.check = checker
+	s.rw.Unlock()
+}
+
 // FindConn 添加连接
 func (s *Session) FindConn(kind Kind, target int64) (network.Conn, error) {
 	s.rw.RLock()
@@ -75,8 +92,8 @@ func (s *Session) AddConn(conn network.Conn) {
 	}
 }
 
-// RemConn 移除连接
-func (s *Session) RemConn(conn network.Conn) {
+// RemConn 移除连接，返回连接退出的分组
+func (s *Session) RemConn(conn network.Conn) []string {
 	s.rw.Lock()
 	defer s.rw.Unlock()
 
@@ -87,6 +104,14 @@ func (s *Session) RemConn(conn network.Conn) {
 	if uid != 0 {
 		delete(s.users, uid)
 	}
+
+	groups := make([]string, 0, len(s.joined[cid]))
+	for group := range s.joined[cid] {
+		s.leave(group, cid)
+		groups = append(groups, group)
+	}
+
+	return groups
 }
 
 // Has 是否存在会话
@@ -106,19 +131,33 @@ func (s *Session) Has(kind Kind, target int64) (ok bool, err error) {
 	return
 }
 
-// Bind 绑定用户ID
+// Bind 绑定用户ID，并在释放锁后重放该用户断线期间缓存的消息
 func (s *Session) Bind(cid, uid int64) error {
+	conn, err := s.bind(cid, uid)
+	if err != nil || conn == nil {
+		return err
+	}
+
+	if err = conn.CheckAndSendPendingMessages(); err != nil {
+		log.Warnf("replay pending messages failed, cid: %d uid: %d err: %v", cid, uid, err)
+	}
+
+	return nil
+}
+
+// 绑定用户ID，返回新绑定的连接，已绑定同一用户时返回nil
+func (s *Session) bind(cid, uid int64) (network.Conn, error) {
 	s.rw.Lock()
 	defer s.rw.Unlock()
 
 	conn, err := s.conn(Conn, cid)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if oldUID := conn.UID(); oldUID != 0 {
 		if uid == oldUID {
-			return nil
+			return nil, nil
 		}
 		delete(s.users, oldUID)
 	}
@@ -130,7 +169,7 @@ func (s *Session) Bind(cid, uid int64) error {
 	conn.Bind(uid)
 	//log.Debugf("conn绑定后详情%+v", conn)
 	s.users[uid] = conn
-	return nil
+	return conn, nil
 }
 
 // Unbind 解绑用户ID
@@ -214,6 +253,24 @@ func (s *Session) Close(kind Kind, target int64, force ...bool) error {
 	return conn.Close(force...)
 }
 
+// CloseAll 关闭所有连接，返回关闭的连接数
+func (s *Session) CloseAll(force ...bool) (n int64) {
+	s.rw.RLock()
+	conns := make([]network.Conn, 0, len(s.conns))
+	for _, conn := range s.conns {
+		conns = append(conns, conn)
+	}
+	s.rw.RUnlock()
+
+	for _, conn := range conns {
+		if conn.Close(force...) == nil {
+			n++
+		}
+	}
+
+	return
+}
+
 // Send 发送消息（同步）
 func (s *Session) Send(kind Kind, target int64, msg []byte) error {
 	s.rw.RLock()
@@ -224,6 +281,10 @@ func (s *Session) Send(kind Kind, target int64, msg []byte) error {
 		return err
 	}
 
+	if err = s.checkConn(conn); err != nil {
+		return err
+	}
+
 	return conn.Send(msg)
 }
 
@@ -237,7 +298,7 @@ func (s *Session) Push(kind Kind, target int64, msg []byte) error {
 		return err
 	}
 
-	return conn.Push(msg)
+	return s.push(conn, msg)
 }
 
 // Multicast 推送组播消息（异步）
@@ -265,7 +326,7 @@ func (s *Session) Multicast(kind Kind, targets []int64, msg []byte) (n int64, er
 		if !ok {
 			continue
 		}
-		if conn.Push(msg) == nil {
+		if s.push(conn, msg) == nil {
 			n++
 		}
 	}
@@ -290,7 +351,82 @@ func (s *Session) Broadcast(kind Kind, msg []byte) (n int64, err error) {
 	}
 
 	for _, conn := range conns {
-		if conn.Push(msg) == nil {
+		if s.push(conn, msg) == nil {
+			n++
+		}
+	}
+
+	return
+}
+
+// JoinGroup 加入分组，连接断开时自动退出所有分组，分组名最长65535字节
+func (s *Session) JoinGroup(group string, kind Kind, target int64) error {
+	if group == "" || len(group) > math.MaxUint16 {
+		return errors.ErrInvalidGroup
+	}
+
+	s.rw.Lock()
+	defer s.rw.Unlock()
+
+	conn, err := s.conn(kind, target)
+	if err != nil {
+		return err
+	}
+
+	cid := conn.ID()
+
+	members, ok := s.groups[group]
+	if !ok {
+		members = make(map[int64]struct{})
+		s.groups[group] = members
+	}
+	members[cid] = struct{}{}
+
+	groups, ok := s.joined[cid]
+	if !ok {
+		groups = make(map[string]struct{})
+		s.joined[cid] = groups
+	}
+	groups[group] = struct{}{}
+
+	return nil
+}
+
+// LeaveGroup 退出分组
+func (s *Session) LeaveGroup(group string, kind Kind, target int64) error {
+	if group == "" {
+		return errors.ErrInvalidGroup
+	}
+
+	s.rw.Lock()
+	defer s.rw.Unlock()
+
+	conn, err := s.conn(kind, target)
+	if err != nil {
+		return err
+	}
+
+	s.leave(group, conn.ID())
+
+	return nil
+}
+
+// MulticastGroup 推送分组消息（异步）
+func (s *Session) MulticastGroup(group string, msg []byte) (n int64, err error) {
+	if group == "" {
+		err = errors.ErrInvalidGroup
+		return
+	}
+
+	s.rw.RLock()
+	defer s.rw.RUnlock()
+
+	for cid := range s.groups[group] {
+		conn, ok := s.conns[cid]
+		if !ok {
+			continue
+		}
+		if s.push(conn, msg) == nil {
 			n++
 		}
 	}
@@ -298,6 +434,14 @@ func (s *Session) Broadcast(kind Kind, msg []byte) (n int64, err error) {
 	return
 }
 
+// GroupStat 统计分组成员总数
+func (s *Session) GroupStat(group string) int64 {
+	s.rw.RLock()
+	defer s.rw.RUnlock()
+
+	return int64(len(s.groups[group]))
+}
+
 // Stat 统计会话总数
 func (s *Session) Stat(kind Kind) (int64, error) {
 	s.rw.RLock()
@@ -313,6 +457,43 @@ func (s *Session) Stat(kind Kind) (int64, error) {
 	}
 }
 
+// 检查并推送消息
+func (s *Session) push(conn network.Conn, msg []byte) error {
+	if err := s.checkConn(conn); err != nil {
+		return err
+	}
+
+	return conn.Push(msg)
+}
+
+// 检查连接是否允许发送
+func (s *Session) checkConn(conn network.Conn) error {
+	if s.check == nil {
+		return nil
+	}
+
+	return s.check(conn)
+}
+
+// 连接退出分组，调用方需持有写锁
+func (s *Session) leave(group string, cid int64) {
+	if members, ok := s.groups[group]; ok {
+		delete(members, cid)
+
+		if len(members) == 0 {
+			delete(s.groups, group)
+		}
+	}
+
+	if groups, ok := s.joined[cid]; ok {
+		delete(groups, group)
+
+		if len(groups) == 0 {
+			delete(s.joined, cid)
+		}
+	}
+}
+
 // 获取会话
 func (s *Session) conn(kind Kind, target int64) (network.Conn, error) {
 	switch kind {
@@ -0,0 +1,89 @@
+package session_test
+
+import (
+	"gatesvr/errors"
+	"gatesvr/network"
+	"gatesvr/session"
+	"math"
+	"strings"
+	"testing"
+)
+
+type testConn struct {
+	network.Conn
+	id     int64
+	uid    int64
+	pushed int
+}
+
+func (c *testConn) ID() int64 { return c.id }
+
+func (c *testConn) UID() int64 { return c.uid }
+
+func (c *testConn) Push(msg []byte) error {
+	c.pushed++
+	return nil
+}
+
+func TestSession_Group(t *testing.T) {
+	s := session.NewSession()
+
+	conn1 := &testConn{id: 1, uid: 101}
+	conn2 := &testConn{id: 2, uid: 102}
+	conn3 := &testConn{id: 3}
+
+	s.AddConn(conn1)
+	s.AddConn(conn2)
+	s.AddConn(conn3)
+
+	if err := s.JoinGroup("room", session.User, 101); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.JoinGroup("room", session.User, 102); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.JoinGroup("room", session.Conn, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.JoinGroup("room", session.User, 103); err == nil {
+		t.Fatal("expected error when joining with unknown user")
+	}
+
+	if n, err := s.MulticastGroup("room", []byte("hello")); err != nil || n != 3 {
+		t.Fatalf("multicast total = %d, err = %v, want 3", n, err)
+	}
+
+	if err := s.LeaveGroup("room", session.User, 102); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.JoinGroup(strings.Repeat("r", math.MaxUint16+1), session.Conn, 3); !errors.Is(err, errors.ErrInvalidGroup) {
+		t.Fatalf("expected ErrInvalidGroup for oversized group, got %v", err)
+	}
+
+	// 连接断开后自动退出分组
+	if groups := s.RemConn(conn3); len(groups) != 1 || groups[0] != "room" {
+		t.Fatalf("unexpected left groups: %v", groups)
+	}
+
+	if n := s.GroupStat("room"); n != 1 {
+		t.Fatalf("group members = %d, want 1", n)
+	}
+
+	if n, _ := s.MulticastGroup("room", []byte("hello")); n != 1 {
+		t.Fatalf("multicast total = %d, want 1", n)
+	}
+
+	if conn1.pushed != 2 || conn2.pushed != 1 || conn3.pushed != 1 {
+		t.Fatalf("unexpected pushes: %d %d %d", conn1.pushed, conn2.pushed, conn3.pushed)
+	}
+
+	s.RemConn(conn1)
+
+	if n := s.GroupStat("room"); n != 0 {
+		t.Fatalf("group members = %d, want 0", n)
+	}
+}
@@ -21,6 +21,7 @@ var (
 	TooManyRequests    = NewCode(10, "too many requests")
 	TooManyConnections = NewCode(11, "too many connections")
 	StateError         = NewCode(12, "state error")
+	ServiceUnavailable = NewCode(13, "service unavailable")
 )
 
 type Code struct {
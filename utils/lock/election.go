@@ -0,0 +1,238 @@
+package lock
+
+import (
+	"context"
+	"gatesvr/errors"
+	"gatesvr/log"
+	"sync"
+	"time"
+)
+
+const defaultElectionTTL = 3 * time.Second
+
+type ElectionOption func(o *electionOptions)
+
+type electionOptions struct {
+	// 租约时长
+	// 领导者需在租约到期前续租，否则其他候选者可当选，默认为3s
+	ttl time.Duration
+
+	// 竞选及续租间隔
+	// 默认为租约时长的1/3
+	interval time.Duration
+
+	// 当选回调
+	// 在竞选协程中执行，fence为本次当选的防护令牌
+	onElected func(fence int64)
+
+	// 卸任回调
+	// 在竞选协程中执行，续租失败或停止竞选时回调
+	onRevoked func()
+}
+
+// WithElectionTTL 设置租约时长
+func WithElectionTTL(ttl time.Duration) ElectionOption {
+	return func(o *electionOptions) { o.ttl = ttl }
+}
+
+// WithElectionInterval 设置竞选及续租间隔
+func WithElectionInterval(interval time.Duration) ElectionOption {
+	return func(o *electionOptions) { o.interval = interval }
+}
+
+// WithElectionOnElected 设置当选回调
+func WithElectionOnElected(fn func(fence int64)) ElectionOption {
+	return func(o *electionOptions) { o.onElected = fn }
+}
+
+// WithElectionOnRevoked 设置卸任回调
+func WithElectionOnRevoked(fn func()) ElectionOption {
+	return func(o *electionOptions) { o.onRevoked = fn }
+}
+
+// Election 基于租约锁的选主，同一名称下同一时刻至多存在一个领导者
+// 候选者周期性地尝试获取锁，获取成功即当选，当选后周期性地续租，续租失败时立即卸任
+type Election struct {
+	opts   *electionOptions
+	name   string
+	locker Locker
+	leaser Leaser
+	mu     sync.RWMutex
+	leader bool
+	fence  int64
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewElection 新建选主，maker制造的Locker须实现Leaser接口
+func NewElection(maker Maker, name string, opts ...ElectionOption) (*Election, error) {
+	if maker == nil {
+		return nil, errors.ErrMissingLockMaker
+	}
+
+	o := &electionOptions{ttl: defaultElectionTTL}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.ttl <= 0 {
+		o.ttl = defaultElectionTTL
+	}
+
+	if o.interval <= 0 || o.interval >= o.ttl {
+		o.interval = o.ttl / 3
+	}
+
+	locker := maker.Make(name)
+
+	leaser, ok := locker.(Leaser)
+	if !ok {
+		return nil, errors.ErrLeaseNotSupported
+	}
+
+	return &Election{opts: o, name: name, locker: locker, leaser: leaser}, nil
+}
+
+// Name 获取选主名称
+func (e *Election) Name() string {
+	return e.name
+}
+
+// Start 开始竞选，重复调用无效
+func (e *Election) Start() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+	e.done = make(chan struct{})
+
+	go e.campaign(ctx, e.done)
+}
+
+// Stop 停止竞选，当前为领导者时卸任并释放锁
+func (e *Election) Stop() {
+	e.mu.Lock()
+	cancel, done := e.cancel, e.done
+	e.cancel, e.done = nil, nil
+	e.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+
+	<-done
+}
+
+// IsLeader 检测当前是否为领导者
+func (e *Election) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return e.leader
+}
+
+// Fence 获取当选时的防护令牌，非领导者时返回0
+// 领导者对外部资源进行写操作时应携带该令牌，资源方拒绝令牌小于已见最大令牌的写入，以防止卸任的领导者覆盖新领导者的数据
+func (e *Election) Fence() int64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return e.fence
+}
+
+// 竞选
+func (e *Election) campaign(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(e.opts.interval)
+	defer ticker.Stop()
+
+	for {
+		if e.IsLeader() {
+			e.renew(ctx)
+		} else {
+			e.elect(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			e.resign()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// 尝试当选
+func (e *Election) elect(ctx context.Context) {
+	if err := e.locker.TryAcquire(ctx, e.opts.ttl); err != nil {
+		if !errors.Is(err, errors.ErrIllegalOperation) && ctx.Err() == nil {
+			log.Warnf("election campaign failed, name: %s err: %v", e.name, err)
+		}
+		return
+	}
+
+	fence := e.leaser.Fence()
+
+	e.mu.Lock()
+	e.leader, e.fence = true, fence
+	e.mu.Unlock()
+
+	log.Infof("election elected, name: %s fence: %d", e.name, fence)
+
+	if e.opts.onElected != nil {
+		e.opts.onElected(fence)
+	}
+}
+
+// 续租，续租失败时无法确认租约是否仍然有效，立即卸任
+func (e *Election) renew(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, e.opts.interval)
+	defer cancel()
+
+	if err := e.leaser.Renew(ctx); err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return
+		}
+
+		log.Warnf("election lease renewal failed, name: %s err: %v", e.name, err)
+
+		e.revoke()
+	}
+}
+
+// 主动卸任并释放锁
+func (e *Election) resign() {
+	if !e.IsLeader() {
+		return
+	}
+
+	e.revoke()
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.opts.interval)
+	defer cancel()
+
+	if err := e.locker.Release(ctx); err != nil {
+		log.Warnf("election lock release failed, name: %s err: %v", e.name, err)
+	}
+}
+
+// 卸任
+func (e *Election) revoke() {
+	e.mu.Lock()
+	e.leader, e.fence = false, 0
+	e.mu.Unlock()
+
+	log.Infof("election revoked, name: %s", e.name)
+
+	if e.opts.onRevoked != nil {
+		e.opts.onRevoked()
+	}
+}
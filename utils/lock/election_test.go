@@ -0,0 +1,66 @@
+package lock_test
+
+import (
+	"gatesvr/utils/lock"
+	"gatesvr/utils/lock/memory"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestElection_Failover(t *testing.T) {
+	var (
+		maker    = memory.NewMaker()
+		elected  atomic.Int32
+		revoked  atomic.Int32
+		fences   = make(chan int64, 2)
+		newElect = func() *lock.Election {
+			e, err := lock.NewElection(maker, "leader",
+				lock.WithElectionTTL(90*time.Millisecond),
+				lock.WithElectionOnElected(func(fence int64) {
+					elected.Add(1)
+					fences <- fence
+				}),
+				lock.WithElectionOnRevoked(func() { revoked.Add(1) }),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return e
+		}
+	)
+
+	e1, e2 := newElect(), newElect()
+	e1.Start()
+	defer e1.Stop()
+
+	first := <-fences
+
+	e2.Start()
+	defer e2.Stop()
+
+	time.Sleep(200 * time.Millisecond)
+
+	if !e1.IsLeader() || e2.IsLeader() || elected.Load() != 1 {
+		t.Fatalf("unexpected leaders, e1: %v e2: %v elected: %d", e1.IsLeader(), e2.IsLeader(), elected.Load())
+	}
+
+	e1.Stop()
+
+	if revoked.Load() != 1 || e1.Fence() != 0 {
+		t.Fatalf("leader is not revoked, revoked: %d", revoked.Load())
+	}
+
+	select {
+	case second := <-fences:
+		if second <= first {
+			t.Fatalf("fence is not increased, first: %d second: %d", first, second)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("new leader is not elected")
+	}
+
+	if !e2.IsLeader() || e2.Fence() == 0 {
+		t.Fatal("unexpected leader state")
+	}
+}
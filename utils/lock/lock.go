@@ -28,6 +28,14 @@ type Locker interface {
 	Release(ctx context.Context) error
 }
 
+// Leaser 租约锁，Locker实现该接口后可主动续租并获取防护令牌，可用于选主
+type Leaser interface {
+	// Renew 续租锁，锁已过期或被其他持有者获取时返回错误
+	Renew(ctx context.Context) error
+	// Fence 获取最近一次成功获取锁时生成的防护令牌，令牌随每次获取单调递增
+	Fence() int64
+}
+
 // SetMaker 设置Locker制造商
 func SetMaker(maker Maker) {
 	globalMaker = maker
@@ -0,0 +1,182 @@
+package memory
+
+import (
+	"context"
+	"gatesvr/errors"
+	"gatesvr/utils/lock"
+	"gatesvr/utils/xuuid"
+	"sync"
+	"time"
+)
+
+const defaultExpiration = 3 * time.Second
+
+var _ lock.Maker = &Maker{}
+
+// Maker 进程内的Locker制造商，适用于单节点部署及测试
+type Maker struct {
+	mu     sync.Mutex
+	leases map[string]*lease
+	fences map[string]int64
+}
+
+// 锁租约
+type lease struct {
+	version  string
+	deadline time.Time // 到期时间，为零值时直至释放前一直有效
+}
+
+// 检测租约是否有效
+func (l *lease) valid(now time.Time) bool {
+	return l.deadline.IsZero() || now.Before(l.deadline)
+}
+
+// 计算到期时间
+func deadline(now time.Time, expiration time.Duration) time.Time {
+	if expiration <= 0 {
+		return time.Time{}
+	}
+
+	return now.Add(expiration)
+}
+
+func NewMaker() *Maker {
+	return &Maker{leases: make(map[string]*lease), fences: make(map[string]int64)}
+}
+
+// Make 制造一个Locker
+func (m *Maker) Make(name string) lock.Locker {
+	return &Locker{maker: m, name: name, version: xuuid.UUID()}
+}
+
+// Close 关闭构建器
+func (m *Maker) Close() error {
+	return nil
+}
+
+// 获取锁，返回防护令牌，锁已被占用时返回0
+func (m *Maker) acquire(name, version string, expiration time.Duration) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	if l, ok := m.leases[name]; ok && l.valid(now) {
+		return 0
+	}
+
+	m.leases[name] = &lease{version: version, deadline: deadline(now, expiration)}
+	m.fences[name]++
+
+	return m.fences[name]
+}
+
+// 续租锁
+func (m *Maker) renewal(name, version string, expiration time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	l, ok := m.leases[name]
+	if !ok || l.version != version || !l.valid(now) {
+		return errors.ErrIllegalOperation
+	}
+
+	l.deadline = deadline(now, expiration)
+
+	return nil
+}
+
+// 释放锁
+func (m *Maker) release(name, version string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l, ok := m.leases[name]
+	if !ok {
+		return nil
+	}
+
+	if l.version != version {
+		return errors.ErrIllegalOperation
+	}
+
+	delete(m.leases, name)
+
+	return nil
+}
+
+var _ lock.Leaser = &Locker{}
+
+type Locker struct {
+	maker      *Maker
+	name       string
+	version    string
+	mu         sync.Mutex
+	fence      int64
+	expiration time.Duration
+}
+
+// Acquire 获取锁，锁在释放前一直有效
+func (l *Locker) Acquire(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		if fence := l.maker.acquire(l.name, l.version, 0); fence > 0 {
+			l.mu.Lock()
+			l.fence, l.expiration = fence, 0
+			l.mu.Unlock()
+
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// TryAcquire 尝试获取锁
+func (l *Locker) TryAcquire(_ context.Context, expiration ...time.Duration) error {
+	ttl := defaultExpiration
+	if len(expiration) > 0 && expiration[0] > 0 {
+		ttl = expiration[0]
+	}
+
+	fence := l.maker.acquire(l.name, l.version, ttl)
+	if fence == 0 {
+		return errors.ErrIllegalOperation
+	}
+
+	l.mu.Lock()
+	l.fence, l.expiration = fence, ttl
+	l.mu.Unlock()
+
+	return nil
+}
+
+// Release 释放锁
+func (l *Locker) Release(_ context.Context) error {
+	return l.maker.release(l.name, l.version)
+}
+
+// Renew 续租锁，续租时长与获取锁时的过期时间一致
+func (l *Locker) Renew(_ context.Context) error {
+	l.mu.Lock()
+	expiration := l.expiration
+	l.mu.Unlock()
+
+	return l.maker.renewal(l.name, l.version, expiration)
+}
+
+// Fence 获取最近一次成功获取锁时生成的防护令牌
+func (l *Locker) Fence() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.fence
+}
@@ -0,0 +1,49 @@
+package memory_test
+
+import (
+	"context"
+	"gatesvr/errors"
+	"gatesvr/utils/lock"
+	"gatesvr/utils/lock/memory"
+	"testing"
+	"time"
+)
+
+func TestLocker_Lease(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		maker = memory.NewMaker()
+		l1    = maker.Make("lockName")
+		l2    = maker.Make("lockName")
+	)
+
+	if err := l1.TryAcquire(ctx, 30*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l2.TryAcquire(ctx); !errors.Is(err, errors.ErrIllegalOperation) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+
+	if err := l1.(lock.Leaser).Renew(ctx); err == nil {
+		t.Fatal("expired lease is renewed")
+	}
+
+	if err := l2.TryAcquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if f1, f2 := l1.(lock.Leaser).Fence(), l2.(lock.Leaser).Fence(); f2 <= f1 {
+		t.Fatalf("fence is not increased, f1: %d f2: %d", f1, f2)
+	}
+
+	if err := l1.Release(ctx); !errors.Is(err, errors.ErrIllegalOperation) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := l2.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
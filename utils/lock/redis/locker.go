@@ -2,32 +2,73 @@ package redis
 
 import (
 	"context"
+	"gatesvr/utils/lock"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+var _ lock.Leaser = &Locker{}
+
 type Locker struct {
-	maker   *Maker
-	key     string
-	version string
-	rw      sync.RWMutex
-	timer   *time.Timer
+	maker      *Maker
+	key        string
+	version    string
+	rw         sync.RWMutex
+	timer      *time.Timer
+	fence      atomic.Int64
+	expiration time.Duration
 }
 
 // Acquire 获取锁
 func (l *Locker) Acquire(ctx context.Context) error {
-	if err := l.maker.acquire(ctx, l.key, l.version); err != nil {
+	fence, err := l.maker.acquire(ctx, l.key, l.version)
+	if err != nil {
 		return err
 	}
 
+	l.fence.Store(fence)
+
+	l.rw.Lock()
+	l.expiration = l.maker.opts.expiration
 	l.timer = time.AfterFunc(l.maker.opts.expiration/2, l.renewal)
+	l.rw.Unlock()
 
 	return nil
 }
 
 // TryAcquire 尝试获取锁
 func (l *Locker) TryAcquire(ctx context.Context, expiration ...time.Duration) error {
-	return l.maker.tryAcquire(ctx, l.key, l.version, expiration...)
+	fence, err := l.maker.tryAcquire(ctx, l.key, l.version, expiration...)
+	if err != nil {
+		return err
+	}
+
+	l.fence.Store(fence)
+
+	l.rw.Lock()
+	if len(expiration) > 0 {
+		l.expiration = expiration[0]
+	} else {
+		l.expiration = l.maker.opts.expiration
+	}
+	l.rw.Unlock()
+
+	return nil
+}
+
+// Renew 续租锁，续租时长与获取锁时的过期时间一致
+func (l *Locker) Renew(ctx context.Context) error {
+	l.rw.RLock()
+	expiration := l.expiration
+	l.rw.RUnlock()
+
+	return l.maker.renewal(ctx, l.key, l.version, expiration)
+}
+
+// Fence 获取最近一次成功获取锁时生成的防护令牌
+func (l *Locker) Fence() int64 {
+	return l.fence.Load()
 }
 
 // Release 释放锁
@@ -43,7 +84,7 @@ func (l *Locker) Release(ctx context.Context) error {
 
 // 续租锁
 func (l *Locker) renewal() {
-	if err := l.maker.renewal(context.Background(), l.key, l.version); err != nil {
+	if err := l.maker.renewal(context.Background(), l.key, l.version, l.maker.opts.expiration); err != nil {
 		return
 	}
 
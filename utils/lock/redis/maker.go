@@ -6,6 +6,7 @@ import (
 	"gatesvr/utils/lock"
 	"gatesvr/utils/xconv"
 	"gatesvr/utils/xuuid"
+	"strings"
 
 	"github.com/go-redis/redis/v8"
 	"time"
@@ -14,6 +15,7 @@ import (
 type Maker struct {
 	opts          *options
 	builtin       bool
+	acquireScript *redis.Script
 	releaseScript *redis.Script
 	renewalScript *redis.Script
 }
@@ -30,6 +32,7 @@ func NewMaker(opts ...Option) *Maker {
 
 	m := &Maker{}
 	m.opts = o
+	m.acquireScript = redis.NewScript(acquireScript)
 	m.releaseScript = redis.NewScript(releaseScript)
 	m.renewalScript = redis.NewScript(renewalScript)
 
@@ -71,26 +74,23 @@ func (m *Maker) Close() error {
 	return nil
 }
 
-// 执行获取锁操作
-func (m *Maker) acquire(ctx context.Context, key, version string) error {
-	var (
-		args    = redis.SetArgs{Mode: "NX", TTL: m.opts.expiration}
-		retries int
-	)
+// 执行获取锁操作，返回防护令牌
+func (m *Maker) acquire(ctx context.Context, key, version string) (int64, error) {
+	var retries int
 
 	for {
-		val, err := m.opts.client.SetArgs(ctx, key, version, args).Result()
-		if err != nil && !errors.Is(err, redis.Nil) {
-			return err
+		fence, err := m.doAcquire(ctx, key, version, m.opts.expiration)
+		if err != nil {
+			return 0, err
 		}
 
-		if val == "OK" {
-			return nil
+		if fence > 0 {
+			return fence, nil
 		}
 
 		if m.opts.acquireMaxRetries > 0 {
 			if retries > m.opts.acquireMaxRetries {
-				return errors.ErrDeadlineExceeded
+				return 0, errors.ErrDeadlineExceeded
 			}
 
 			retries++
@@ -100,24 +100,41 @@ func (m *Maker) acquire(ctx context.Context, key, version string) error {
 	}
 }
 
-// 尝试获取锁
-func (m *Maker) tryAcquire(ctx context.Context, key, version string, expiration ...time.Duration) error {
-	args := redis.SetArgs{Mode: "NX", TTL: m.opts.expiration}
+// 尝试获取锁，返回防护令牌
+func (m *Maker) tryAcquire(ctx context.Context, key, version string, expiration ...time.Duration) (int64, error) {
+	ttl := m.opts.expiration
 
 	if len(expiration) > 0 {
-		args.TTL = expiration[0]
+		ttl = expiration[0]
 	}
 
-	val, err := m.opts.client.SetArgs(ctx, key, version, args).Result()
-	if err != nil && !errors.Is(err, redis.Nil) {
-		return err
+	fence, err := m.doAcquire(ctx, key, version, ttl)
+	if err != nil {
+		return 0, err
 	}
 
-	if val != "OK" {
-		return errors.ErrIllegalOperation
+	if fence == 0 {
+		return 0, errors.ErrIllegalOperation
 	}
 
-	return nil
+	return fence, nil
+}
+
+// 获取锁并递增防护令牌，锁已被占用时返回0
+func (m *Maker) doAcquire(ctx context.Context, key, version string, expiration time.Duration) (int64, error) {
+	return m.acquireScript.Run(ctx, m.opts.client, []string{key, fenceKey(key)}, version, expiration.Milliseconds()).Int64()
+}
+
+// 防护令牌键，保证集群模式下与锁键落在同一槽位且不改变锁键本身
+// 锁键含哈希标签时沿用其标签，否则以整个锁键作为哈希标签
+func fenceKey(key string) string {
+	if i := strings.IndexByte(key, '{'); i >= 0 {
+		if j := strings.IndexByte(key[i+1:], '}'); j > 0 {
+			return key + ":fence"
+		}
+	}
+
+	return "{" + key + "}:fence"
 }
 
 // 执行释放锁操作
@@ -135,8 +152,8 @@ func (m *Maker) release(ctx context.Context, key, version string) error {
 }
 
 // 执行续租锁操作
-func (m *Maker) renewal(ctx context.Context, key, version string) error {
-	rst, err := m.renewalScript.Run(ctx, m.opts.client, []string{key}, version, m.opts.expiration.Milliseconds()).StringSlice()
+func (m *Maker) renewal(ctx context.Context, key, version string, expiration time.Duration) error {
+	rst, err := m.renewalScript.Run(ctx, m.opts.client, []string{key}, version, expiration.Milliseconds()).StringSlice()
 	if err != nil {
 		return err
 	}
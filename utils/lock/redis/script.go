@@ -1,5 +1,14 @@
 package redis
 
+// 获取锁，获取成功时递增并返回防护令牌，失败时返回0
+const acquireScript = `
+	if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+		return redis.call('INCR', KEYS[2])
+	end
+
+	return 0
+`
+
 // 释放锁
 const releaseScript = `
 	local val = redis.call('GET', KEYS[1])
@@ -0,0 +1,319 @@
+package xcron
+
+import (
+	"fmt"
+	"gatesvr/errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 字段取值范围
+type bounds struct {
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	seconds = bounds{0, 59, nil}
+	minutes = bounds{0, 59, nil}
+	hours   = bounds{0, 23, nil}
+	doms    = bounds{1, 31, nil}
+	months  = bounds{1, 12, map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dows = bounds{0, 7, map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// 预定义表达式
+var descriptors = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// Schedule cron调度计划
+type Schedule struct {
+	second, minute, hour, dom, month, dow uint64
+	domStar, dowStar                      bool           // 日、星期字段是否为任意值
+	loc                                   *time.Location // 时区，为空时使用传入时间的时区
+}
+
+// Parse 解析cron表达式
+// 支持5段（分 时 日 月 星期）及6段（秒 分 时 日 月 星期）格式，以及@yearly、@monthly、@weekly、@daily、@hourly等预定义表达式
+// 字段支持*、?、数值、范围（a-b）、步长（*/n、a-b/n、a/n）及逗号分隔的列表，月份及星期支持英文缩写
+// 表达式可以TZ=或CRON_TZ=前缀指定时区，例如：CRON_TZ=Asia/Shanghai 0 5 * * *
+func Parse(spec string) (*Schedule, error) {
+	return ParseInLocation(spec, nil)
+}
+
+// ParseInLocation 在指定时区下解析cron表达式，表达式中指定的时区优先
+func ParseInLocation(spec string, loc *time.Location) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if strings.HasPrefix(spec, "TZ=") || strings.HasPrefix(spec, "CRON_TZ=") {
+		tz, rest, _ := strings.Cut(spec, " ")
+		_, name, _ := strings.Cut(tz, "=")
+
+		l, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, errors.NewError(fmt.Sprintf("invalid time zone %q", name), errors.ErrInvalidCronSpec)
+		}
+
+		loc, spec = l, strings.TrimSpace(rest)
+	}
+
+	if strings.HasPrefix(spec, "@") {
+		descriptor, ok := descriptors[strings.ToLower(spec)]
+		if !ok {
+			return nil, errors.NewError(fmt.Sprintf("unrecognized descriptor %q", spec), errors.ErrInvalidCronSpec)
+		}
+
+		spec = descriptor
+	}
+
+	fields := strings.Fields(spec)
+
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, errors.NewError(fmt.Sprintf("expected 5 or 6 fields, found %d: %q", len(fields), spec), errors.ErrInvalidCronSpec)
+	}
+
+	s := &Schedule{loc: loc}
+
+	var err error
+
+	if s.second, err = parseField(fields[0], seconds); err != nil {
+		return nil, err
+	}
+
+	if s.minute, err = parseField(fields[1], minutes); err != nil {
+		return nil, err
+	}
+
+	if s.hour, err = parseField(fields[2], hours); err != nil {
+		return nil, err
+	}
+
+	if s.dom, err = parseField(fields[3], doms); err != nil {
+		return nil, err
+	}
+
+	if s.month, err = parseField(fields[4], months); err != nil {
+		return nil, err
+	}
+
+	if s.dow, err = parseField(fields[5], dows); err != nil {
+		return nil, err
+	}
+
+	// 星期日可表示为0或7
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+
+	s.domStar = isStar(fields[3])
+	s.dowStar = isStar(fields[5])
+
+	return s, nil
+}
+
+// 是否为任意值
+func isStar(field string) bool {
+	return field == "*" || field == "?"
+}
+
+// 解析字段，返回取值的位图
+func parseField(field string, b bounds) (uint64, error) {
+	var bitmap uint64
+
+	for _, expr := range strings.Split(field, ",") {
+		bit, err := parseRange(expr, b)
+		if err != nil {
+			return 0, err
+		}
+
+		bitmap |= bit
+	}
+
+	return bitmap, nil
+}
+
+// 解析范围表达式
+func parseRange(expr string, b bounds) (uint64, error) {
+	var (
+		start, end, step uint = 0, 0, 1
+		err              error
+	)
+
+	rng, stepExpr, hasStep := strings.Cut(expr, "/")
+
+	switch {
+	case rng == "*" || rng == "?":
+		start, end = b.min, b.max
+	default:
+		low, high, hasHigh := strings.Cut(rng, "-")
+
+		if start, err = parseValue(low, b); err != nil {
+			return 0, err
+		}
+
+		switch {
+		case hasHigh:
+			if end, err = parseValue(high, b); err != nil {
+				return 0, err
+			}
+		case hasStep:
+			end = b.max
+		default:
+			end = start
+		}
+	}
+
+	if hasStep {
+		if step, err = parseUint(stepExpr); err != nil || step == 0 {
+			return 0, errors.NewError(fmt.Sprintf("invalid step %q", expr), errors.ErrInvalidCronSpec)
+		}
+	}
+
+	if start < b.min || end > b.max || start > end {
+		return 0, errors.NewError(fmt.Sprintf("value out of range [%d, %d]: %q", b.min, b.max, expr), errors.ErrInvalidCronSpec)
+	}
+
+	var bitmap uint64
+	for i := start; i <= end; i += step {
+		bitmap |= 1 << i
+	}
+
+	return bitmap, nil
+}
+
+// 解析数值或名称
+func parseValue(expr string, b bounds) (uint, error) {
+	if v, ok := b.names[strings.ToLower(expr)]; ok {
+		return v, nil
+	}
+
+	v, err := parseUint(expr)
+	if err != nil {
+		return 0, errors.NewError(fmt.Sprintf("invalid value %q", expr), errors.ErrInvalidCronSpec)
+	}
+
+	return v, nil
+}
+
+func parseUint(expr string) (uint, error) {
+	v, err := strconv.ParseUint(expr, 10, 8)
+	return uint(v), err
+}
+
+// Location 获取时区，未指定时区时返回nil
+func (s *Schedule) Location() *time.Location {
+	return s.loc
+}
+
+// Next 获取t之后的下一个触发时间，返回时间位于计划的时区中，五年内无触发时间时返回零值
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := s.loc
+	if loc == nil {
+		loc = t.Location()
+	}
+
+	t = t.In(loc).Truncate(time.Second).Add(time.Second)
+
+	limit := t.Year() + 5
+	added := false
+
+wrap:
+	if t.Year() > limit {
+		return time.Time{}
+	}
+
+	for !has(s.month, uint(t.Month())) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+		}
+
+		t = t.AddDate(0, 1, 0)
+
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+
+	for !s.matchDay(t) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		}
+
+		t = t.AddDate(0, 0, 1)
+
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+
+	for !has(s.hour, uint(t.Hour())) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+		}
+
+		t = t.Add(time.Hour)
+
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+
+	for !has(s.minute, uint(t.Minute())) {
+		if !added {
+			added = true
+			t = t.Truncate(time.Minute)
+		}
+
+		t = t.Add(time.Minute)
+
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+
+	for !has(s.second, uint(t.Second())) {
+		t = t.Add(time.Second)
+
+		if t.Second() == 0 {
+			goto wrap
+		}
+	}
+
+	return t
+}
+
+// 检测日期是否匹配，日及星期均有限定时满足其一即可
+func (s *Schedule) matchDay(t time.Time) bool {
+	domMatch := has(s.dom, uint(t.Day()))
+	dowMatch := has(s.dow, uint(t.Weekday()))
+
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
+
+func has(bitmap uint64, v uint) bool {
+	return bitmap&(1<<v) != 0
+}
@@ -0,0 +1,56 @@
+package xcron_test
+
+import (
+	"gatesvr/errors"
+	"gatesvr/utils/xcron"
+	"testing"
+	"time"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	base := time.Date(2024, 2, 28, 23, 59, 30, 500, time.UTC)
+
+	for _, c := range []struct {
+		spec   string
+		expect time.Time
+	}{
+		{spec: "* * * * * *", expect: time.Date(2024, 2, 28, 23, 59, 31, 0, time.UTC)},
+		{spec: "*/15 * * * *", expect: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 5 * * *", expect: time.Date(2024, 2, 29, 5, 0, 0, 0, time.UTC)},
+		{spec: "30 4 1 * *", expect: time.Date(2024, 3, 1, 4, 30, 0, 0, time.UTC)},
+		{spec: "0 0 29 2 *", expect: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 * * mon-fri", expect: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 * * 7", expect: time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 1 * sun", expect: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "@hourly", expect: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{spec: "@yearly", expect: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "10-20/5 9 * jan,mar *", expect: time.Date(2024, 3, 1, 9, 10, 0, 0, time.UTC)},
+		{spec: "CRON_TZ=Asia/Shanghai 0 8 * * *", expect: time.Date(2024, 2, 29, 8, 0, 0, 0, shanghai)},
+	} {
+		s, err := xcron.Parse(c.spec)
+		if err != nil {
+			t.Fatalf("parse %q failed: %v", c.spec, err)
+		}
+
+		if next := s.Next(base); !next.Equal(c.expect) {
+			t.Fatalf("unexpected next time of %q: %v", c.spec, next)
+		}
+	}
+
+	if s, _ := xcron.Parse("0 0 30 2 *"); !s.Next(base).IsZero() {
+		t.Fatal("expected no next time")
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "@every", "TZ=Nowhere * * * * *"} {
+		if _, err := xcron.Parse(spec); !errors.Is(err, errors.ErrInvalidCronSpec) {
+			t.Fatalf("unexpected error of %q: %v", spec, err)
+		}
+	}
+}